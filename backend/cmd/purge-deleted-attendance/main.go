@@ -0,0 +1,30 @@
+// Command purge-deleted-attendance permanently deletes the attendance that was
+// soft deleted, with its punches and photos, and drops the deleted_at column.
+// Attendance is no longer soft deleted, and the server refuses to migrate the
+// database until this has been run once.
+package main
+
+import (
+	"face-verification-backend/internal/config"
+	"face-verification-backend/internal/database"
+	"log"
+)
+
+func main() {
+	cfg := config.Load()
+
+	db, err := database.Connect(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	purged, err := database.PurgeDeletedAttendance(db)
+	if err != nil {
+		log.Fatal("Failed to purge deleted attendance:", err)
+	}
+	log.Printf("Purged %d deleted attendance record(s)", purged)
+
+	if err := database.Migrate(db, cfg.DayBoundaryHour); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+}
@@ -0,0 +1,45 @@
+// Command reencrypt-embeddings rewrites the stored face embeddings with the
+// active encryption key. Run it after adding a new key and making it active
+// with EMBEDDING_ENCRYPTION_KEY_ID, then remove the old key from
+// EMBEDDING_ENCRYPTION_KEYS. It also encrypts embeddings stored before
+// encryption was enabled.
+package main
+
+import (
+	"face-verification-backend/internal/config"
+	"face-verification-backend/internal/database"
+	"face-verification-backend/internal/encryption"
+	"face-verification-backend/internal/repositories"
+	"flag"
+	"log"
+)
+
+func main() {
+	batchSize := flag.Int("batch-size", 100, "embeddings rewritten per query")
+	flag.Parse()
+
+	cfg := config.Load()
+
+	keyring, err := encryption.LoadKeyring(cfg.EmbeddingEncryptionKeyID, cfg.EmbeddingEncryptionKeys)
+	if err != nil {
+		log.Fatal("Failed to load embedding encryption keys:", err)
+	}
+	if keyring == nil {
+		log.Fatal("EMBEDDING_ENCRYPTION_KEYS is not set")
+	}
+
+	db, err := database.Connect(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := database.Migrate(db, cfg.DayBoundaryHour); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
+	repo := repositories.NewFaceEmbeddingRepository(db, keyring)
+	rewritten, err := repo.ReencryptAll(*batchSize)
+	if err != nil {
+		log.Fatalf("Re-encrypted %d embedding(s) before failing: %v", rewritten, err)
+	}
+	log.Printf("Re-encrypted %d embedding(s) with key %s", rewritten, keyring.ActiveKeyID())
+}
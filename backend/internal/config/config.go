@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
@@ -12,9 +13,72 @@ type Config struct {
 	DatabaseURL         string
 	JWTSecret           string
 	FaceRecognitionURL  string
+	FaceVerifier        string
+	FaceMatchThreshold  float64
 	CloudinaryCloudName string
 	CloudinaryAPIKey    string
 	CloudinaryAPISecret string
+	DayBoundaryHour     int
+	GeofenceMode        string
+
+	AccessTokenTTLMinutes int
+	RefreshTokenTTLDays   int
+
+	// Users with these emails are made admins at startup
+	AdminEmails string
+
+	PasswordResetURL        string
+	PasswordResetTTLMinutes int
+
+	Mailer        string
+	MailFrom      string
+	MailOutboxDir string
+	SMTPHost      string
+	SMTPPort      int
+	SMTPUsername  string
+	SMTPPassword  string
+
+	FaceServiceTimeoutSeconds         int
+	FaceServiceRetries                int
+	FaceServiceBreakerThreshold       int
+	FaceServiceBreakerCooldownSeconds int
+	FaceServiceDegradedMode           bool
+	FaceServiceKey                    string
+
+	FaceModelName                   string
+	FaceModelVersion                string
+	FaceLegacyModelName             string
+	FaceLegacyModelVersion          string
+	FaceModelMigrationIntervalHours int
+
+	// Mismatches followed by a verified attempt within this many minutes
+	// count as false rejects in the threshold report
+	FalseRejectRetryMinutes int
+
+	EmbeddingEncryptionKeyID string
+	EmbeddingEncryptionKeys  string
+
+	KioskMinMargin float64
+
+	EnrollmentMinCaptures   int
+	EnrollmentMinFacePixels int
+	EnrollmentMinSharpness  float64
+	EnrollmentMinBrightness float64
+	EnrollmentMaxBrightness float64
+
+	LivenessMode                string
+	LivenessChallengeTTLSeconds int
+	LivenessActions             int
+	LivenessMinScore            float64
+
+	PhotoNearDuplicateDistance int
+	PhotoMaxCaptureSkewMinutes int
+	PhotoHistoryDays           int
+
+	AutoClockOutEnabled         bool
+	AutoClockOutAfterMinutes    int
+	AutoClockOutMaxSessionHours int
+	AutoClockOutIntervalMinutes int
 }
 
 func Load() *Config {
@@ -36,9 +100,69 @@ func Load() *Config {
 		DatabaseURL:         databaseURL,
 		JWTSecret:           getEnv("JWT_SECRET", "your-secret-key-change-in-production-min-32-chars"),
 		FaceRecognitionURL:  getEnv("FACE_RECOGNITION_URL", "http://localhost:5001"),
+		FaceVerifier:        getEnv("FACE_VERIFIER", "http"),
+		FaceMatchThreshold:  getEnvFloat("FACE_MATCH_THRESHOLD", 0.62),
 		CloudinaryCloudName: getEnv("CLOUDINARY_CLOUD_NAME", ""),
 		CloudinaryAPIKey:    getEnv("CLOUDINARY_API_KEY", ""),
 		CloudinaryAPISecret: getEnv("CLOUDINARY_API_SECRET", ""),
+		DayBoundaryHour:     getEnvInt("DAY_BOUNDARY_HOUR", 0),
+		GeofenceMode:        getEnv("GEOFENCE_MODE", "flag"),
+
+		AccessTokenTTLMinutes: getEnvInt("ACCESS_TOKEN_TTL_MINUTES", 15),
+		RefreshTokenTTLDays:   getEnvInt("REFRESH_TOKEN_TTL_DAYS", 30),
+
+		AdminEmails: getEnv("ADMIN_EMAILS", ""),
+
+		PasswordResetURL:        getEnv("PASSWORD_RESET_URL", "http://localhost:8080/reset-password"),
+		PasswordResetTTLMinutes: getEnvInt("PASSWORD_RESET_TTL_MINUTES", 30),
+
+		Mailer:        getEnv("MAILER", ""),
+		MailFrom:      getEnv("MAIL_FROM", "no-reply@localhost"),
+		MailOutboxDir: getEnv("MAIL_OUTBOX_DIR", ""),
+		SMTPHost:      getEnv("SMTP_HOST", ""),
+		SMTPPort:      getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:  getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:  getEnv("SMTP_PASSWORD", ""),
+
+		FaceServiceTimeoutSeconds:         getEnvInt("FACE_SERVICE_TIMEOUT_SECONDS", 10),
+		FaceServiceRetries:                getEnvInt("FACE_SERVICE_RETRIES", 2),
+		FaceServiceBreakerThreshold:       getEnvInt("FACE_SERVICE_BREAKER_THRESHOLD", 5),
+		FaceServiceBreakerCooldownSeconds: getEnvInt("FACE_SERVICE_BREAKER_COOLDOWN_SECONDS", 30),
+		FaceServiceDegradedMode:           getEnvBool("FACE_SERVICE_DEGRADED_MODE", false),
+		FaceServiceKey:                    getEnv("FACE_SERVICE_KEY", ""),
+
+		FaceModelName:                   getEnv("FACE_MODEL_NAME", "buffalo_l"),
+		FaceModelVersion:                getEnv("FACE_MODEL_VERSION", "1"),
+		FaceLegacyModelName:             getEnv("FACE_LEGACY_MODEL_NAME", ""),
+		FaceLegacyModelVersion:          getEnv("FACE_LEGACY_MODEL_VERSION", ""),
+		FaceModelMigrationIntervalHours: getEnvInt("FACE_MODEL_MIGRATION_INTERVAL_HOURS", 24),
+
+		FalseRejectRetryMinutes: getEnvInt("FALSE_REJECT_RETRY_MINUTES", 10),
+
+		EmbeddingEncryptionKeyID: getEnv("EMBEDDING_ENCRYPTION_KEY_ID", ""),
+		EmbeddingEncryptionKeys:  getEnv("EMBEDDING_ENCRYPTION_KEYS", ""),
+
+		KioskMinMargin: getEnvFloat("KIOSK_MIN_MARGIN", 0.05),
+
+		EnrollmentMinCaptures:   getEnvInt("ENROLLMENT_MIN_CAPTURES", 3),
+		EnrollmentMinFacePixels: getEnvInt("ENROLLMENT_MIN_FACE_PIXELS", 112),
+		EnrollmentMinSharpness:  getEnvFloat("ENROLLMENT_MIN_SHARPNESS", 50),
+		EnrollmentMinBrightness: getEnvFloat("ENROLLMENT_MIN_BRIGHTNESS", 60),
+		EnrollmentMaxBrightness: getEnvFloat("ENROLLMENT_MAX_BRIGHTNESS", 200),
+
+		LivenessMode:                getEnv("LIVENESS_MODE", "flag"),
+		LivenessChallengeTTLSeconds: getEnvInt("LIVENESS_CHALLENGE_TTL_SECONDS", 120),
+		LivenessActions:             getEnvInt("LIVENESS_ACTIONS", 2),
+		LivenessMinScore:            getEnvFloat("LIVENESS_MIN_SCORE", 0.8),
+
+		PhotoNearDuplicateDistance: getEnvInt("PHOTO_NEAR_DUPLICATE_DISTANCE", 5),
+		PhotoMaxCaptureSkewMinutes: getEnvInt("PHOTO_MAX_CAPTURE_SKEW_MINUTES", 10),
+		PhotoHistoryDays:           getEnvInt("PHOTO_HISTORY_DAYS", 90),
+
+		AutoClockOutEnabled:         getEnvBool("AUTO_CLOCK_OUT_ENABLED", true),
+		AutoClockOutAfterMinutes:    getEnvInt("AUTO_CLOCK_OUT_AFTER_MINUTES", 120),
+		AutoClockOutMaxSessionHours: getEnvInt("AUTO_CLOCK_OUT_MAX_SESSION_HOURS", 12),
+		AutoClockOutIntervalMinutes: getEnvInt("AUTO_CLOCK_OUT_INTERVAL_MINUTES", 15),
 	}
 }
 
@@ -48,3 +172,30 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
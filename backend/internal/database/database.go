@@ -1,12 +1,19 @@
 package database
 
 import (
+	"errors"
 	"face-verification-backend/internal/models"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
+// ErrSoftDeletedAttendance is returned by Migrate while attendance still has the
+// deleted_at column of when it was soft deleted.
+var ErrSoftDeletedAttendance = errors.New("attendances still has a deleted_at column, run cmd/purge-deleted-attendance first")
+
 func Connect(databaseURL string) (*gorm.DB, error) {
 	db, err := gorm.Open(mysql.Open(databaseURL), &gorm.Config{})
 	if err != nil {
@@ -16,17 +23,230 @@ func Connect(databaseURL string) (*gorm.DB, error) {
 	return db, nil
 }
 
-func Migrate(db *gorm.DB) error {
+// Migrate brings the schema up to date. dayBoundaryHour is the configured hour
+// workdays start at, used to give older attendance its workday.
+func Migrate(db *gorm.DB, dayBoundaryHour int) error {
+	// Attendance is deleted for good now. Soft-deleted rows would show up again
+	// and keep their workday taken, so they are purged by an explicit step
+	// first.
+	if db.Migrator().HasColumn(&models.Attendance{}, "deleted_at") {
+		return ErrSoftDeletedAttendance
+	}
+
 	// Set foreign key checks
 	db.Exec("SET FOREIGN_KEY_CHECKS=0")
 	defer db.Exec("SET FOREIGN_KEY_CHECKS=1")
-	
-	return db.AutoMigrate(
+
+	// Users may enrol several face embeddings, drop the old one-per-user index
+	if db.Migrator().HasIndex(&models.FaceEmbedding{}, "idx_face_embeddings_user_id") {
+		if err := db.Migrator().DropIndex(&models.FaceEmbedding{}, "idx_face_embeddings_user_id"); err != nil {
+			return err
+		}
+	}
+
+	// Deleted embeddings used to be kept with deleted_at set. Erase them, a
+	// deleted face template must not stay in the database.
+	if db.Migrator().HasColumn(&models.FaceEmbedding{}, "deleted_at") {
+		if err := db.Exec("DELETE FROM face_embeddings WHERE deleted_at IS NOT NULL").Error; err != nil {
+			return err
+		}
+		if err := db.Migrator().DropColumn(&models.FaceEmbedding{}, "deleted_at"); err != nil {
+			return err
+		}
+	}
+
+	if err := db.AutoMigrate(
 		&models.User{},
 		&models.Attendance{},
+		&models.AttendancePunch{},
 		&models.FaceEmbedding{},
 		&models.Task{},
 		&models.Training{},
-	)
+		&models.Shift{},
+		&models.Office{},
+		&models.UserOffice{},
+		&models.LeaveType{},
+		&models.LeaveRequest{},
+		&models.LeaveBalance{},
+		&models.AttendanceCorrection{},
+		&models.AttendanceCorrectionHistory{},
+		&models.Holiday{},
+		&models.WorkingDayRule{},
+		&models.OvertimeRequest{},
+		&models.FaceVerificationAttempt{},
+		&models.ServiceCredential{},
+		&models.EmbeddingAuditEntry{},
+		&models.KioskDevice{},
+		&models.LivenessChallenge{},
+		&models.PunchPhoto{},
+		&models.FaceEnrollment{},
+		&models.FaceEnrollmentCapture{},
+		&models.AuthSession{},
+		&models.PasswordResetToken{},
+	); err != nil {
+		return err
+	}
+
+	// Backfill the workday of attendance created before work_date existed
+	if err := db.Exec("UPDATE attendances SET work_date = DATE(DATE_SUB(COALESCE(clock_in, created_at), INTERVAL ? HOUR)) WHERE work_date IS NULL",
+		dayBoundaryHour).Error; err != nil {
+		return err
+	}
+
+	// One attendance per user and workday. Days recorded more than once before
+	// that was enforced are merged first, or the index could not be created.
+	if !db.Migrator().HasIndex(&models.Attendance{}, "idx_attendances_user_work_date") {
+		if err := mergeDuplicateAttendance(db); err != nil {
+			return err
+		}
+		if err := db.Exec("CREATE UNIQUE INDEX idx_attendances_user_work_date ON attendances (user_id, work_date)").Error; err != nil {
+			return err
+		}
+	}
+
+	// Pending leave requested before balance_id existed reserved its days on the
+	// balance of its type and year
+	if err := db.Exec(`UPDATE leave_requests r JOIN leave_balances b
+		ON b.user_id = r.user_id AND b.leave_type_id = r.leave_type_id AND b.year = YEAR(r.start_date)
+		SET r.balance_id = b.id
+		WHERE r.status = ? AND (r.balance_id IS NULL OR r.balance_id = '')`, models.LeaveStatusPending).Error; err != nil {
+		return err
+	}
+
+	// Embeddings enrolled before captured_at existed were captured when created
+	return db.Exec("UPDATE face_embeddings SET captured_at = created_at WHERE captured_at IS NULL").Error
 }
 
+// mergeDuplicateAttendance folds every workday recorded more than once into its
+// earliest attendance.
+func mergeDuplicateAttendance(db *gorm.DB) error {
+	var days []struct {
+		UserID   string
+		WorkDate time.Time
+	}
+	if err := db.Model(&models.Attendance{}).Select("user_id, work_date").
+		Group("user_id, work_date").Having("COUNT(*) > 1").
+		Scan(&days).Error; err != nil {
+		return err
+	}
+
+	for _, day := range days {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return mergeAttendanceDay(tx, day.UserID, day.WorkDate)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeAttendanceDay moves the punches and references of the user's other
+// attendance on the workday to the earliest one, which spans from the first
+// clock-in to the last clock-out, and deletes the others. Attendance from
+// before punches existed gets punches from its clock-in and clock-out first,
+// so every session of the day is kept.
+func mergeAttendanceDay(tx *gorm.DB, userID string, workDate time.Time) error {
+	var records []*models.Attendance
+	if err := tx.Preload("Punches").
+		Where("user_id = ? AND work_date = ?", userID, workDate.Format("2006-01-02")).
+		Order("clock_in IS NULL, clock_in ASC, created_at ASC").
+		Find(&records).Error; err != nil {
+		return err
+	}
+	if len(records) < 2 {
+		return nil
+	}
+
+	kept := records[0]
+	var last *models.Attendance
+	var mergedIDs []string
+	for _, record := range records {
+		if len(record.Punches) == 0 && record.ClockIn != nil {
+			if err := tx.Create(legacyPunches(record)).Error; err != nil {
+				return err
+			}
+		}
+		if record.ClockIn != nil {
+			last = record
+		}
+		if record != kept {
+			mergedIDs = append(mergedIDs, record.ID)
+		}
+	}
+
+	for _, model := range []interface{}{&models.AttendancePunch{}, &models.PunchPhoto{}, &models.AttendanceCorrection{}, &models.AttendanceCorrectionHistory{}} {
+		if err := tx.Unscoped().Model(model).Where("attendance_id IN ?", mergedIDs).Update("attendance_id", kept.ID).Error; err != nil {
+			return err
+		}
+	}
+	if last != nil {
+		if err := tx.Model(&models.Attendance{}).Where("id = ?", kept.ID).Updates(map[string]interface{}{
+			"clock_out":          last.ClockOut,
+			"clock_out_photo":    last.ClockOutPhoto,
+			"clock_out_location": last.ClockOutLocation,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("id IN ?", mergedIDs).Delete(&models.Attendance{}).Error
+}
+
+// legacyPunches returns the punches of attendance recorded before punches
+// existed, from its clock-in and clock-out columns.
+func legacyPunches(attendance *models.Attendance) []*models.AttendancePunch {
+	punches := []*models.AttendancePunch{{
+		ID:           uuid.New().String(),
+		AttendanceID: attendance.ID,
+		UserID:       attendance.UserID,
+		Type:         models.PunchTypeClockIn,
+		PunchedAt:    *attendance.ClockIn,
+		Photo:        attendance.ClockInPhoto,
+		Location:     attendance.ClockInLocation,
+		CreatedAt:    time.Now(),
+	}}
+	if attendance.ClockOut != nil {
+		punches = append(punches, &models.AttendancePunch{
+			ID:           uuid.New().String(),
+			AttendanceID: attendance.ID,
+			UserID:       attendance.UserID,
+			Type:         models.PunchTypeClockOut,
+			PunchedAt:    *attendance.ClockOut,
+			Photo:        attendance.ClockOutPhoto,
+			Location:     attendance.ClockOutLocation,
+			CreatedAt:    time.Now(),
+		})
+	}
+	return punches
+}
+
+// PurgeDeletedAttendance permanently removes soft-deleted attendance with its
+// punches and photos, then drops the deleted_at column. It returns how many
+// attendance records were removed.
+func PurgeDeletedAttendance(db *gorm.DB) (int64, error) {
+	if !db.Migrator().HasColumn(&models.Attendance{}, "deleted_at") {
+		return 0, nil
+	}
+
+	var purged int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		deleted := tx.Model(&models.Attendance{}).Select("id").Where("deleted_at IS NOT NULL")
+		for _, model := range []interface{}{&models.AttendancePunch{}, &models.PunchPhoto{}} {
+			if !tx.Migrator().HasTable(model) {
+				continue
+			}
+			if err := tx.Where("attendance_id IN (?)", deleted).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Where("deleted_at IS NOT NULL").Delete(&models.Attendance{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return purged, err
+	}
+	return purged, db.Migrator().DropColumn(&models.Attendance{}, "deleted_at")
+}
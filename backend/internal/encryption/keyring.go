@@ -0,0 +1,170 @@
+// Package encryption implements envelope encryption of sensitive columns.
+//
+// Each value is encrypted with its own random data key using AES-256-GCM. The
+// data key is then encrypted ("wrapped") with a master key from the keyring.
+// Rows store the master key ID with the wrapped data key, so master keys can
+// be rotated by re-wrapping rows without touching the others.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// KeySize is the size of master and data keys, for AES-256.
+const KeySize = 32
+
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// Envelope is an encrypted value with what is needed to decrypt it. The
+// fields are base64 encoded so they fit text columns.
+type Envelope struct {
+	KeyID      string // master key that wrapped the data key
+	WrappedKey string // data key encrypted with the master key
+	Ciphertext string // value encrypted with the data key
+}
+
+// Keyring holds the master keys. New values are encrypted with the active
+// key; the others are kept to decrypt values written before a rotation.
+type Keyring struct {
+	activeKeyID string
+	keys        map[string][]byte
+}
+
+// NewKeyring returns a keyring encrypting with activeKeyID.
+func NewKeyring(activeKeyID string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("%w: active key %q is not configured", ErrUnknownKey, activeKeyID)
+	}
+	for id, key := range keys {
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("encryption key %q must be %d bytes, got %d", id, KeySize, len(key))
+		}
+	}
+	return &Keyring{activeKeyID: activeKeyID, keys: keys}, nil
+}
+
+// ParseKeys parses master keys written as "id:base64key,id:base64key".
+func ParseKeys(spec string) (map[string][]byte, error) {
+	keys := make(map[string][]byte)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid encryption key %q, expected id:base64key", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return keys, nil
+}
+
+func (k *Keyring) ActiveKeyID() string {
+	return k.activeKeyID
+}
+
+// Seal encrypts plaintext under a new data key wrapped with the active key.
+// additionalData, e.g. the row ID, is authenticated but not stored: the same
+// value must be passed to Open, so a ciphertext cannot be moved to another row.
+func (k *Keyring) Seal(plaintext, additionalData []byte) (*Envelope, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := seal(dataKey, plaintext, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	wrappedKey, err := seal(k.keys[k.activeKeyID], dataKey, []byte(k.activeKeyID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		KeyID:      k.activeKeyID,
+		WrappedKey: base64.StdEncoding.EncodeToString(wrappedKey),
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	}, nil
+}
+
+// Open decrypts an envelope produced by Seal with the same additionalData.
+func (k *Keyring) Open(envelope Envelope, additionalData []byte) ([]byte, error) {
+	masterKey, ok := k.keys[envelope.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, envelope.KeyID)
+	}
+
+	wrappedKey, err := base64.StdEncoding.DecodeString(envelope.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wrapped key: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(envelope.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+
+	dataKey, err := open(masterKey, wrappedKey, []byte(envelope.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return open(dataKey, ciphertext, additionalData)
+}
+
+// seal returns the random nonce followed by the AES-GCM ciphertext.
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(key, sealed, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// LoadKeyring builds the keyring from configuration: the active key ID and
+// the keys as accepted by ParseKeys. It returns nil when no keys are set.
+func LoadKeyring(activeKeyID, keys string) (*Keyring, error) {
+	parsed, err := ParseKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed) == 0 {
+		return nil, nil
+	}
+	return NewKeyring(activeKeyID, parsed)
+}
@@ -0,0 +1,167 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestParseKeys(t *testing.T) {
+	k1 := base64.StdEncoding.EncodeToString(testKey(1))
+	k2 := base64.StdEncoding.EncodeToString(testKey(2))
+
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string][]byte
+		wantErr bool
+	}{
+		{"empty", "", map[string][]byte{}, false},
+		{"one key", "k1:" + k1, map[string][]byte{"k1": testKey(1)}, false},
+		{"several keys with spaces", " k1:" + k1 + " , k2:" + k2 + " ,", map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, false},
+		{"missing id", ":" + k1, nil, true},
+		{"missing separator", k1, nil, true},
+		{"invalid base64", "k1:not base64!", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKeys(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d keys, want %d", len(got), len(tt.want))
+			}
+			for id, key := range tt.want {
+				if !bytes.Equal(got[id], key) {
+					t.Errorf("key %q = %x, want %x", id, got[id], key)
+				}
+			}
+		})
+	}
+}
+
+func TestNewKeyring(t *testing.T) {
+	tests := []struct {
+		name        string
+		activeKeyID string
+		keys        map[string][]byte
+		wantErr     bool
+		wantUnknown bool
+	}{
+		{"valid", "k1", map[string][]byte{"k1": testKey(1)}, false, false},
+		{"active key missing", "k2", map[string][]byte{"k1": testKey(1)}, true, true},
+		{"key too short", "k1", map[string][]byte{"k1": testKey(1)[:16]}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyring(tt.activeKeyID, tt.keys)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrUnknownKey) != tt.wantUnknown {
+				t.Errorf("error = %v, want ErrUnknownKey %v", err, tt.wantUnknown)
+			}
+		})
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	keyring, err := NewKeyring("k1", map[string][]byte{"k1": testKey(1), "k2": testKey(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("[0.12, -0.5, 0.33]")
+	envelope, err := keyring.Seal(plaintext, []byte("row-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if envelope.KeyID != "k1" {
+		t.Errorf("KeyID = %q, want the active key", envelope.KeyID)
+	}
+	if strings.Contains(envelope.Ciphertext, string(plaintext)) {
+		t.Error("ciphertext contains the plaintext")
+	}
+
+	tampered := *envelope
+	ciphertext, _ := base64.StdEncoding.DecodeString(envelope.Ciphertext)
+	ciphertext[len(ciphertext)-1] ^= 1
+	tampered.Ciphertext = base64.StdEncoding.EncodeToString(ciphertext)
+
+	tests := []struct {
+		name           string
+		envelope       Envelope
+		additionalData string
+		wantErr        bool
+	}{
+		{"same additional data", *envelope, "row-1", false},
+		{"additional data of another row", *envelope, "row-2", true},
+		{"missing additional data", *envelope, "", true},
+		{"wrapped with another master key", Envelope{KeyID: "k2", WrappedKey: envelope.WrappedKey, Ciphertext: envelope.Ciphertext}, "row-1", true},
+		{"unknown master key", Envelope{KeyID: "k3", WrappedKey: envelope.WrappedKey, Ciphertext: envelope.Ciphertext}, "row-1", true},
+		{"tampered ciphertext", tampered, "row-1", true},
+		{"invalid base64", Envelope{KeyID: "k1", WrappedKey: "%%%", Ciphertext: envelope.Ciphertext}, "row-1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := keyring.Open(tt.envelope, []byte(tt.additionalData))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, plaintext) {
+				t.Errorf("Open() = %q, want %q", got, plaintext)
+			}
+		})
+	}
+}
+
+func TestOpenAfterRotation(t *testing.T) {
+	old, err := NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, err := old.Seal([]byte("secret"), []byte("row-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := NewKeyring("k2", map[string][]byte{"k1": testKey(1), "k2": testKey(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := rotated.Open(*envelope, []byte("row-1"))
+	if err != nil {
+		t.Fatalf("values sealed before the rotation must still open: %v", err)
+	}
+	if string(got) != "secret" {
+		t.Errorf("Open() = %q, want %q", got, "secret")
+	}
+}
+
+func TestLoadKeyringWithoutKeys(t *testing.T) {
+	keyring, err := LoadKeyring("", "")
+	if err != nil || keyring != nil {
+		t.Fatalf("LoadKeyring() = %v, %v, want no keyring and no error", keyring, err)
+	}
+}
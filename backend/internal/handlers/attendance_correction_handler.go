@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/services"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AttendanceCorrectionHandler struct {
+	correctionService services.AttendanceCorrectionService
+}
+
+func NewAttendanceCorrectionHandler(correctionService services.AttendanceCorrectionService) *AttendanceCorrectionHandler {
+	return &AttendanceCorrectionHandler{correctionService: correctionService}
+}
+
+type ReviewCorrectionRequest struct {
+	Note string `json:"note"`
+}
+
+// RequestCorrection accepts a multipart form with work_date (YYYY-MM-DD),
+// clock_in and/or clock_out (RFC3339), reason and an optional evidence photo.
+func (h *AttendanceCorrectionHandler) RequestCorrection(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	workDate, err := time.ParseInLocation("2006-01-02", c.PostForm("work_date"), time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid work_date"})
+		return
+	}
+
+	clockIn, err := parseOptionalTime(c.PostForm("clock_in"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid clock_in"})
+		return
+	}
+	clockOut, err := parseOptionalTime(c.PostForm("clock_out"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid clock_out"})
+		return
+	}
+
+	reason := c.PostForm("reason")
+	if reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+		return
+	}
+
+	var evidencePath string
+	if file, err := c.FormFile("evidence"); err == nil {
+		evidencePath, err = saveTempUpload(file, "evidence-*")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save evidence photo"})
+			return
+		}
+		defer os.Remove(evidencePath)
+	}
+
+	correction, err := h.correctionService.RequestCorrection(userID.(string), workDate, clockIn, clockOut, reason, evidencePath)
+	if err != nil {
+		c.JSON(correctionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": correction})
+}
+
+func (h *AttendanceCorrectionHandler) GetMyCorrections(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	corrections, err := h.correctionService.GetUserCorrections(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": corrections})
+}
+
+func (h *AttendanceCorrectionHandler) GetCorrections(c *gin.Context) {
+	corrections, err := h.correctionService.GetCorrections(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": corrections})
+}
+
+func (h *AttendanceCorrectionHandler) ApproveCorrection(c *gin.Context) {
+	h.reviewCorrection(c, h.correctionService.ApproveCorrection)
+}
+
+func (h *AttendanceCorrectionHandler) RejectCorrection(c *gin.Context) {
+	h.reviewCorrection(c, h.correctionService.RejectCorrection)
+}
+
+func (h *AttendanceCorrectionHandler) GetCorrectionHistory(c *gin.Context) {
+	attendanceID := c.Param("id")
+	if attendanceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "attendance id is required"})
+		return
+	}
+
+	history, err := h.correctionService.GetCorrectionHistory(attendanceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": history})
+}
+
+func (h *AttendanceCorrectionHandler) reviewCorrection(c *gin.Context, review func(correctionID, reviewerID, note string) (*models.AttendanceCorrection, error)) {
+	reviewerID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req ReviewCorrectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correction, err := review(c.Param("id"), reviewerID.(string), req.Note)
+	if err != nil {
+		c.JSON(correctionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": correction})
+}
+
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// correctionErrorStatus maps correction service errors to HTTP status codes
+func correctionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrCorrectionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrCorrectionSelfApproval):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrCorrectionNotPending),
+		errors.Is(err, services.ErrCorrectionExists):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
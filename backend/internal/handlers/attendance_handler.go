@@ -1,8 +1,16 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"face-verification-backend/internal/services"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -36,17 +44,37 @@ func (h *AttendanceHandler) ClockIn(c *gin.Context) {
 	}
 
 	// Save uploaded file temporarily
-	photoPath := "/tmp/" + file.Filename
-	if err := c.SaveUploadedFile(file, photoPath); err != nil {
+	photoPath, err := saveTempUpload(file, "punch-*"+filepath.Ext(file.Filename))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save photo"})
 		return
 	}
+	defer os.Remove(photoPath)
+
+	coords, err := parseCoordinates(c, location)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	attendance, err := h.attendanceService.ClockIn(userID.(string), photoPath, location)
+	face, err := parseFaceSample(c, photoPath)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	liveness, cleanup, err := parseLivenessResponse(c, userID.(string))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	defer cleanup()
+	face.Liveness = liveness
+
+	attendance, err := h.attendanceService.ClockIn(userID.(string), face, location, coords)
+	if err != nil {
+		c.JSON(attendanceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"data": attendance})
 }
@@ -71,18 +99,58 @@ func (h *AttendanceHandler) ClockOut(c *gin.Context) {
 	}
 
 	// Save uploaded file temporarily
-	photoPath := "/tmp/" + file.Filename
-	if err := c.SaveUploadedFile(file, photoPath); err != nil {
+	photoPath, err := saveTempUpload(file, "punch-*"+filepath.Ext(file.Filename))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save photo"})
 		return
 	}
+	defer os.Remove(photoPath)
 
-	attendance, err := h.attendanceService.ClockOut(userID.(string), photoPath, location)
+	// Liveness is only challenged on clock-in, frames sent here are ignored
+	face, err := parseFaceSample(c, photoPath)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	attendance, err := h.attendanceService.ClockOut(userID.(string), face, location)
+	if err != nil {
+		c.JSON(attendanceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": attendance})
+}
+
+func (h *AttendanceHandler) StartBreak(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	attendance, err := h.attendanceService.StartBreak(userID.(string), c.PostForm("location"))
+	if err != nil {
+		c.JSON(attendanceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": attendance})
+}
+
+func (h *AttendanceHandler) EndBreak(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	attendance, err := h.attendanceService.EndBreak(userID.(string), c.PostForm("location"))
+	if err != nil {
+		c.JSON(attendanceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"data": attendance})
 }
 
@@ -133,3 +201,120 @@ func (h *AttendanceHandler) GetHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": attendances})
 }
 
+// parseCoordinates reads the clock position from the latitude/longitude form
+// fields, falling back to a "latitude,longitude" location string. It returns
+// nil when only a free-text address was submitted.
+func parseCoordinates(c *gin.Context, location string) (*services.Coordinates, error) {
+	latStr := c.PostForm("latitude")
+	lngStr := c.PostForm("longitude")
+	if latStr == "" && lngStr == "" {
+		coords, err := services.ParseCoordinates(location)
+		if err != nil {
+			return nil, nil
+		}
+		return coords, nil
+	}
+
+	latitude, err := strconv.ParseFloat(latStr, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latitude")
+	}
+	longitude, err := strconv.ParseFloat(lngStr, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid longitude")
+	}
+	return services.NewCoordinates(latitude, longitude)
+}
+
+// parseFaceSample pairs the uploaded photo with the optional face embedding
+// computed on the device, sent as a JSON array in the embedding form field.
+func parseFaceSample(c *gin.Context, photoPath string) (services.FaceSample, error) {
+	face := services.FaceSample{PhotoPath: photoPath}
+	if raw := c.PostForm("embedding"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &face.Embedding); err != nil {
+			return face, fmt.Errorf("invalid embedding, expected a JSON array of numbers")
+		}
+	}
+	face.Model = services.FaceModel{
+		Name:    c.PostForm("embedding_model"),
+		Version: c.PostForm("embedding_model_version"),
+	}
+	return face, nil
+}
+
+// parseLivenessResponse saves the frames answering a liveness challenge issued
+// to subject. It returns nil when no challenge was answered. The returned
+// cleanup removes the saved frames and must be called once the punch is done.
+func parseLivenessResponse(c *gin.Context, subject string) (*services.LivenessResponse, func(), error) {
+	liveness := &services.LivenessResponse{ChallengeID: c.PostForm("liveness_challenge_id"), Subject: subject}
+	cleanup := func() {
+		for _, framePath := range liveness.FramePaths {
+			os.Remove(framePath)
+		}
+	}
+	if liveness.ChallengeID == "" {
+		return nil, cleanup, nil
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, cleanup, fmt.Errorf("invalid liveness frames")
+	}
+	for _, file := range form.File["frames"] {
+		framePath, err := saveTempUpload(file, "liveness-*")
+		if err != nil {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("failed to save liveness frame")
+		}
+		liveness.FramePaths = append(liveness.FramePaths, framePath)
+	}
+	return liveness, cleanup, nil
+}
+
+// saveTempUpload copies an uploaded file to a new temporary file named after
+// pattern, as in os.CreateTemp. The client file name is not used in the path.
+func saveTempUpload(file *multipart.FileHeader, pattern string) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	return dst.Name(), nil
+}
+
+// attendanceErrorStatus maps attendance service errors to HTTP status codes
+func attendanceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrAlreadyClockedIn),
+		errors.Is(err, services.ErrAlreadyClockedOut),
+		errors.Is(err, services.ErrNotClockedIn),
+		errors.Is(err, services.ErrAlreadyOnBreak),
+		errors.Is(err, services.ErrNotOnBreak),
+		errors.Is(err, services.ErrReenrollmentRequired),
+		errors.Is(err, services.ErrPhotoReplay):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrFaceMismatch),
+		errors.Is(err, services.ErrOutsideGeofence),
+		errors.Is(err, services.ErrLivenessFailed),
+		errors.Is(err, services.ErrLivenessPhotoMismatch):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrFaceServiceUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadRequest
+	}
+}
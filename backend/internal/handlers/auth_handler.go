@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"face-verification-backend/internal/services"
 	"net/http"
 	"strings"
@@ -9,11 +10,15 @@ import (
 )
 
 type AuthHandler struct {
-	authService services.AuthService
+	authService          services.AuthService
+	passwordResetService services.PasswordResetService
 }
 
-func NewAuthHandler(authService services.AuthService) *AuthHandler {
-	return &AuthHandler{authService: authService}
+func NewAuthHandler(authService services.AuthService, passwordResetService services.PasswordResetService) *AuthHandler {
+	return &AuthHandler{
+		authService:          authService,
+		passwordResetService: passwordResetService,
+	}
 }
 
 type LoginRequest struct {
@@ -29,18 +34,118 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	user, token, err := h.authService.Login(req.Email, req.Password)
+	user, tokens, err := h.authService.Login(req.Email, req.Password, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data":  user,
-		"token": token,
+		"data":          user,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Refresh exchanges a refresh token for a new access token and refresh token.
+// The old refresh token stops working.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Refresh token harus diisi"})
+		return
+	}
+
+	tokens, err := h.authService.Refresh(req.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 	})
 }
 
+// Logout revokes the session of the access token
+func (h *AuthHandler) Logout(c *gin.Context) {
+	sessionID, exists := c.Get("session_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+		return
+	}
+
+	if err := h.authService.Logout(sessionID.(string)); err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logout berhasil"})
+}
+
+// GetSessions lists the devices the current user is signed in on
+func (h *AuthHandler) GetSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+		return
+	}
+
+	sessions, err := h.authService.GetSessions(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	currentID, _ := c.Get("session_id")
+	c.JSON(http.StatusOK, gin.H{"data": sessions, "current_session_id": currentID})
+}
+
+// RevokeSession signs the current user out on another device
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+		return
+	}
+
+	if err := h.authService.RevokeSession(userID.(string), c.Param("id")); err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sesi berhasil dicabut"})
+}
+
+// GetUserSessions lists the active sessions of a user, for admins
+func (h *AuthHandler) GetUserSessions(c *gin.Context) {
+	sessions, err := h.authService.GetSessions(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+// RevokeUserSessions signs a user out on every device, e.g. when a phone is lost
+func (h *AuthHandler) RevokeUserSessions(c *gin.Context) {
+	revoked, err := h.authService.RevokeAllSessions(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Semua sesi berhasil dicabut", "revoked": revoked})
+}
+
 type RegisterRequest struct {
 	Name       string `json:"name" binding:"required"`
 	Email      string `json:"email" binding:"required,email"`
@@ -52,6 +157,11 @@ type ForgotPasswordRequest struct {
 	Email string `json:"email" binding:"required,email"`
 }
 
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -80,14 +190,37 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	err := h.authService.ForgotPassword(req.Email)
-	if err != nil {
+	if err := h.passwordResetService.RequestReset(req.Email, c.ClientIP()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengirim link reset password, silakan coba lagi"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Jika email terdaftar, link reset password telah dikirim ke email Anda",
+	})
+}
+
+// ResetPassword sets a new password with the token from a reset link
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorMsg := formatValidationError(err)
+		c.JSON(http.StatusBadRequest, gin.H{"message": errorMsg})
+		return
+	}
+
+	err := h.passwordResetService.ResetPassword(req.Token, req.Password)
+	if errors.Is(err, services.ErrInvalidResetToken) {
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mereset password, silakan coba lagi"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Link reset password telah dikirim ke email Anda",
+		"message": "Password berhasil direset, silakan login dengan password baru",
 	})
 }
 
@@ -99,16 +232,31 @@ func (h *AuthHandler) GetMe(c *gin.Context) {
 	}
 
 	// TODO: Get user from repository
-	// For now, just return userID
+	// For now, just return what the token says
+	role, _ := c.Get("role")
+	permissions, _ := c.Get("permissions")
 	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{"id": userID},
+		"data": gin.H{"id": userID, "role": role, "permissions": permissions},
 	})
 }
 
+// sessionErrorStatus maps session errors to HTTP status codes
+func sessionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidRefreshToken),
+		errors.Is(err, services.ErrSessionRevoked):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrSessionNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // formatValidationError formats validation errors to be more user-friendly
 func formatValidationError(err error) string {
 	errStr := err.Error()
-	
+
 	// Map common validation errors to user-friendly messages
 	if strings.Contains(errStr, "required") {
 		if strings.Contains(errStr, "Name") {
@@ -125,16 +273,15 @@ func formatValidationError(err error) string {
 		}
 		return "Semua field wajib diisi"
 	}
-	
+
 	if strings.Contains(errStr, "email") {
 		return "Format email tidak valid"
 	}
-	
+
 	if strings.Contains(errStr, "min") {
 		return "Password minimal 6 karakter"
 	}
-	
+
 	// Return original error if no specific mapping found
 	return "Data yang dimasukkan tidak valid"
 }
-
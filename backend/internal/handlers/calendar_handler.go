@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"face-verification-backend/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarHandler struct {
+	calendarService services.CalendarService
+}
+
+func NewCalendarHandler(calendarService services.CalendarService) *CalendarHandler {
+	return &CalendarHandler{calendarService: calendarService}
+}
+
+type CreateHolidayRequest struct {
+	Date string `json:"date" binding:"required"` // YYYY-MM-DD
+	Name string `json:"name" binding:"required"`
+}
+
+type SetWorkingDaysRequest struct {
+	Weekdays []int `json:"weekdays"` // 0 = Sunday
+}
+
+// GetHolidays lists the holidays of a year, the current one by default
+func (h *CalendarHandler) GetHolidays(c *gin.Context) {
+	year := time.Now().Year()
+	if yearStr := c.Query("year"); yearStr != "" {
+		parsed, err := strconv.Atoi(yearStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid year"})
+			return
+		}
+		year = parsed
+	}
+
+	startDate := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	endDate := time.Date(year, time.December, 31, 0, 0, 0, 0, time.Local)
+	holidays, err := h.calendarService.GetHolidays(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": holidays})
+}
+
+func (h *CalendarHandler) CreateHoliday(c *gin.Context) {
+	var req CreateHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid date, expected YYYY-MM-DD"})
+		return
+	}
+
+	holiday, err := h.calendarService.CreateHoliday(date, req.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": holiday})
+}
+
+func (h *CalendarHandler) DeleteHoliday(c *gin.Context) {
+	holidayID := c.Param("id")
+	if holidayID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "holiday id is required"})
+		return
+	}
+
+	if err := h.calendarService.DeleteHoliday(holidayID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "holiday deleted successfully"})
+}
+
+// ImportHolidays imports the events of an uploaded .ics file as holidays
+func (h *CalendarHandler) ImportHolidays(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "calendar file is required"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "failed to read calendar file"})
+		return
+	}
+	defer src.Close()
+
+	result, err := h.calendarService.ImportICS(src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+func (h *CalendarHandler) GetWorkingDays(c *gin.Context) {
+	weekdays, err := h.calendarService.GetWorkingWeekdays()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	days := make([]int, 0, len(weekdays))
+	for _, weekday := range weekdays {
+		days = append(days, int(weekday))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"weekdays": days}})
+}
+
+// SetWorkingDays replaces the weekdays that are working days
+func (h *CalendarHandler) SetWorkingDays(c *gin.Context) {
+	var req SetWorkingDaysRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	weekdays := make([]time.Weekday, 0, len(req.Weekdays))
+	for _, day := range req.Weekdays {
+		weekdays = append(weekdays, time.Weekday(day))
+	}
+
+	if err := h.calendarService.SetWorkingWeekdays(weekdays); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "working days updated successfully"})
+}
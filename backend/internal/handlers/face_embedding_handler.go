@@ -1,65 +1,192 @@
 package handlers
 
 import (
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/repositories"
 	"face-verification-backend/internal/services"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type FaceEmbeddingHandler struct {
 	embeddingService services.FaceEmbeddingService
+	auditService     services.EmbeddingAuditService
 }
 
-func NewFaceEmbeddingHandler(embeddingService services.FaceEmbeddingService) *FaceEmbeddingHandler {
+func NewFaceEmbeddingHandler(embeddingService services.FaceEmbeddingService, auditService services.EmbeddingAuditService) *FaceEmbeddingHandler {
 	return &FaceEmbeddingHandler{
 		embeddingService: embeddingService,
+		auditService:     auditService,
 	}
 }
 
-type SaveEmbeddingRequest struct {
-	UserID    string `json:"user_id" binding:"required"`
-	Embedding string `json:"embedding" binding:"required"` // JSON array string
-}
-
-// SaveEmbedding saves or updates face embedding for a user
+// GetEmbedding retrieves the face embeddings of a user produced by the active
+// model. "embedding" holds the most recent one for clients that only support a
+// single reference face.
 // This endpoint is typically called by the face recognition service
-func (h *FaceEmbeddingHandler) SaveEmbedding(c *gin.Context) {
-	var req SaveEmbeddingRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+func (h *FaceEmbeddingHandler) GetEmbedding(c *gin.Context) {
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
 		return
 	}
 
-	embedding, err := h.embeddingService.SaveEmbedding(req.UserID, req.Embedding)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save embedding: " + err.Error()})
+	embeddings, err := h.embeddingService.GetActiveEmbeddings(userID)
+	if errors.Is(err, services.ErrReenrollmentRequired) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
 	}
+	if err != nil || len(embeddings) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Embedding not found"})
+		return
+	}
+	h.audit(c, models.EmbeddingAuditActionRead, userID, "")
+
+	vectors := make([]gin.H, 0, len(embeddings))
+	for _, embedding := range embeddings {
+		vectors = append(vectors, gin.H{
+			"id":          embedding.ID,
+			"label":       embedding.Label,
+			"captured_at": embedding.CapturedAt,
+			"embedding":   embedding.Embedding,
+		})
+	}
 
+	model := h.embeddingService.ActiveModel()
 	c.JSON(http.StatusOK, gin.H{
-		"message":      "Embedding saved successfully",
-		"embedding_id": embedding.ID,
+		"user_id":       userID,
+		"model_name":    model.Name,
+		"model_version": model.Version,
+		"embedding":     embeddings[0].Embedding,
+		"embeddings":    vectors,
 	})
 }
 
-// GetEmbedding retrieves face embedding by user ID
-// This endpoint is typically called by the face recognition service
-func (h *FaceEmbeddingHandler) GetEmbedding(c *gin.Context) {
-	userID := c.Param("user_id")
-	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+// GetMyEmbeddings lists the reference faces enrolled for the current user
+func (h *FaceEmbeddingHandler) GetMyEmbeddings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	h.listEmbeddings(c, userID.(string))
+}
+
+func (h *FaceEmbeddingHandler) DeleteMyEmbedding(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	embedding, err := h.embeddingService.GetEmbeddingByUserID(userID)
+	h.deleteEmbedding(c, userID.(string))
+}
+
+func (h *FaceEmbeddingHandler) GetUserEmbeddings(c *gin.Context) {
+	h.listEmbeddings(c, c.Param("user_id"))
+}
+
+func (h *FaceEmbeddingHandler) DeleteUserEmbedding(c *gin.Context) {
+	h.deleteEmbedding(c, c.Param("user_id"))
+}
+
+func (h *FaceEmbeddingHandler) listEmbeddings(c *gin.Context, userID string) {
+	embeddings, err := h.embeddingService.GetEmbeddings(userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Embedding not found"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.audit(c, models.EmbeddingAuditActionRead, userID, "")
 
+	c.JSON(http.StatusOK, gin.H{"data": embeddings})
+}
+
+func (h *FaceEmbeddingHandler) deleteEmbedding(c *gin.Context, userID string) {
+	embeddingID := c.Param("id")
+	err := h.embeddingService.DeleteEmbedding(userID, embeddingID)
+	if errors.Is(err, services.ErrEmbeddingNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.audit(c, models.EmbeddingAuditActionDelete, userID, embeddingID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "embedding deleted successfully"})
+}
+
+// audit records an access to a user's embeddings by the calling service or
+// user. An empty embeddingID stands for all of the user's embeddings.
+func (h *FaceEmbeddingHandler) audit(c *gin.Context, action, userID, embeddingID string) {
+	auditEmbeddingAccess(c, h.auditService, action, userID, embeddingID)
+}
+
+// auditEmbeddingAccess records an access to a user's embeddings made by the
+// request's caller.
+func auditEmbeddingAccess(c *gin.Context, auditService services.EmbeddingAuditService, action, userID, embeddingID string) {
+	entry := &models.EmbeddingAuditEntry{
+		Action:      action,
+		UserID:      userID,
+		EmbeddingID: embeddingID,
+		ClientIP:    c.ClientIP(),
+	}
+	if value, exists := c.Get("service_credential"); exists {
+		credential := value.(*models.ServiceCredential)
+		entry.ActorType = models.EmbeddingAuditActorService
+		entry.ActorID = credential.ID
+		entry.ActorName = credential.Name
+	} else if actorID, exists := c.Get("user_id"); exists {
+		entry.ActorType = models.EmbeddingAuditActorUser
+		entry.ActorID = actorID.(string)
+	}
+	auditService.Record(entry)
+}
+
+// GetReenrollmentRequired lists the users whose enrolled faces were all
+// produced by a previous face model
+func (h *FaceEmbeddingHandler) GetReenrollmentRequired(c *gin.Context) {
+	users, err := h.embeddingService.GetReenrollmentRequired()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	model := h.embeddingService.ActiveModel()
 	c.JSON(http.StatusOK, gin.H{
-		"user_id":   embedding.UserID,
-		"embedding": embedding.Embedding,
+		"data":          users,
+		"model_name":    model.Name,
+		"model_version": model.Version,
 	})
 }
+
+// GetAuditEntries lists embedding reads and writes, newest first, filtered by
+// user_id, actor_id, action and limit
+func (h *FaceEmbeddingHandler) GetAuditEntries(c *gin.Context) {
+	filter := repositories.EmbeddingAuditFilter{
+		UserID:  c.Query("user_id"),
+		ActorID: c.Query("actor_id"),
+		Action:  c.Query("action"),
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := h.auditService.GetEntries(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/services"
+	"io"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type FaceEnrollmentHandler struct {
+	enrollmentService services.FaceEnrollmentService
+	auditService      services.EmbeddingAuditService
+}
+
+func NewFaceEnrollmentHandler(enrollmentService services.FaceEnrollmentService, auditService services.EmbeddingAuditService) *FaceEnrollmentHandler {
+	return &FaceEnrollmentHandler{
+		enrollmentService: enrollmentService,
+		auditService:      auditService,
+	}
+}
+
+type ReviewEnrollmentRequest struct {
+	Note string `json:"note"`
+}
+
+// StartEnrollment opens an enrollment session for the current user
+func (h *FaceEnrollmentHandler) StartEnrollment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	enrollment, err := h.enrollmentService.StartEnrollment(userID.(string))
+	if err != nil {
+		c.JSON(enrollmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": enrollment})
+}
+
+// AddCapture uploads a photo (multipart `photo`, optional `label`) to an open
+// enrollment. The capture is returned with the result of its quality checks.
+func (h *FaceEnrollmentHandler) AddCapture(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	file, err := c.FormFile("photo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "photo is required"})
+		return
+	}
+
+	photoPath := "/tmp/enrollment-" + uuid.New().String() + filepath.Ext(file.Filename)
+	if err := c.SaveUploadedFile(file, photoPath); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save photo"})
+		return
+	}
+
+	capture, err := h.enrollmentService.AddCapture(userID.(string), c.Param("id"), photoPath, c.PostForm("label"))
+	if err != nil {
+		c.JSON(enrollmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if capture.EmbeddingID != "" {
+		auditEmbeddingAccess(c, h.auditService, models.EmbeddingAuditActionWrite, userID.(string), capture.EmbeddingID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": capture})
+}
+
+// SubmitEnrollment sends an enrollment for HR approval
+func (h *FaceEnrollmentHandler) SubmitEnrollment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	enrollment, err := h.enrollmentService.SubmitEnrollment(userID.(string), c.Param("id"))
+	if err != nil {
+		c.JSON(enrollmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": enrollment})
+}
+
+func (h *FaceEnrollmentHandler) CancelEnrollment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	enrollment, err := h.enrollmentService.CancelEnrollment(userID.(string), c.Param("id"))
+	if err != nil {
+		c.JSON(enrollmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": enrollment})
+}
+
+func (h *FaceEnrollmentHandler) GetMyEnrollments(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	enrollments, err := h.enrollmentService.GetUserEnrollments(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": enrollments})
+}
+
+// GetEnrollments lists enrollments for review, filtered by status
+func (h *FaceEnrollmentHandler) GetEnrollments(c *gin.Context) {
+	enrollments, err := h.enrollmentService.GetEnrollments(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": enrollments})
+}
+
+func (h *FaceEnrollmentHandler) GetEnrollment(c *gin.Context) {
+	enrollment, err := h.enrollmentService.GetEnrollment(c.Param("id"))
+	if err != nil {
+		c.JSON(enrollmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": enrollment})
+}
+
+func (h *FaceEnrollmentHandler) ApproveEnrollment(c *gin.Context) {
+	h.reviewEnrollment(c, h.enrollmentService.ApproveEnrollment)
+}
+
+func (h *FaceEnrollmentHandler) RejectEnrollment(c *gin.Context) {
+	h.reviewEnrollment(c, h.enrollmentService.RejectEnrollment)
+}
+
+func (h *FaceEnrollmentHandler) reviewEnrollment(c *gin.Context, review func(enrollmentID, reviewerID, note string) (*models.FaceEnrollment, error)) {
+	reviewerID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req ReviewEnrollmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrollment, err := review(c.Param("id"), reviewerID.(string), req.Note)
+	if err != nil {
+		c.JSON(enrollmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": enrollment})
+}
+
+// enrollmentErrorStatus maps enrollment service errors to HTTP status codes
+func enrollmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrEnrollmentNotFound),
+		errors.Is(err, services.ErrEmbeddingNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrEnrollmentSelfApproval):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrEnrollmentInProgress),
+		errors.Is(err, services.ErrEnrollmentNotOpen),
+		errors.Is(err, services.ErrEnrollmentNotPending),
+		errors.Is(err, services.ErrTooManyCaptures):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrNotEnoughCaptures):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, services.ErrFaceServiceUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadRequest
+	}
+}
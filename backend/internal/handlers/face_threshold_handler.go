@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"face-verification-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FaceThresholdHandler struct {
+	thresholdService services.FaceThresholdService
+}
+
+func NewFaceThresholdHandler(thresholdService services.FaceThresholdService) *FaceThresholdHandler {
+	return &FaceThresholdHandler{thresholdService: thresholdService}
+}
+
+// SetFaceThresholdRequest sets a threshold override. A null threshold removes
+// the override.
+type SetFaceThresholdRequest struct {
+	FaceMatchThreshold *float64 `json:"face_match_threshold"`
+}
+
+// SetUserThreshold overrides the face match threshold of one user
+func (h *FaceThresholdHandler) SetUserThreshold(c *gin.Context) {
+	var req SetFaceThresholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	user, err := h.thresholdService.SetUserThreshold(c.Param("user_id"), req.FaceMatchThreshold)
+	if err != nil {
+		c.JSON(faceThresholdErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": user})
+}
+
+// SetSiteThreshold overrides the face match threshold of everyone clocking in at an office
+func (h *FaceThresholdHandler) SetSiteThreshold(c *gin.Context) {
+	var req SetFaceThresholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	office, err := h.thresholdService.SetSiteThreshold(c.Param("id"), req.FaceMatchThreshold)
+	if err != nil {
+		c.JSON(faceThresholdErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": office})
+}
+
+func faceThresholdErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidThreshold) {
+		return http.StatusBadRequest
+	}
+	return http.StatusNotFound
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"face-verification-backend/internal/repositories"
+	"face-verification-backend/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FaceVerificationAttemptHandler struct {
+	attemptService services.FaceVerificationAttemptService
+}
+
+func NewFaceVerificationAttemptHandler(attemptService services.FaceVerificationAttemptService) *FaceVerificationAttemptHandler {
+	return &FaceVerificationAttemptHandler{attemptService: attemptService}
+}
+
+// GetAttempts lists face verification attempts, newest first, filtered by
+// user_id, outcome, start_date and end_date (YYYY-MM-DD, inclusive) and limit
+func (h *FaceVerificationAttemptHandler) GetAttempts(c *gin.Context) {
+	filter := repositories.FaceVerificationAttemptFilter{
+		UserID:  c.Query("user_id"),
+		Outcome: c.Query("outcome"),
+	}
+
+	if startDate := c.Query("start_date"); startDate != "" {
+		from, err := time.ParseInLocation("2006-01-02", startDate, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid start_date, expected YYYY-MM-DD"})
+			return
+		}
+		filter.From = from
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		to, err := time.ParseInLocation("2006-01-02", endDate, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid end_date, expected YYYY-MM-DD"})
+			return
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid limit"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	attempts, err := h.attemptService.GetAttempts(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": attempts})
+}
+
+// GetThresholdReport reports the false-reject rate of each threshold used
+// between start_date and end_date (YYYY-MM-DD, inclusive)
+func (h *FaceVerificationAttemptHandler) GetThresholdReport(c *gin.Context) {
+	var from, to time.Time
+	if startDate := c.Query("start_date"); startDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", startDate, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid start_date, expected YYYY-MM-DD"})
+			return
+		}
+		from = parsed
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", endDate, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid end_date, expected YYYY-MM-DD"})
+			return
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	report, err := h.attemptService.GetThresholdReport(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/services"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KioskHandler struct {
+	kioskService services.KioskService
+}
+
+func NewKioskHandler(kioskService services.KioskService) *KioskHandler {
+	return &KioskHandler{kioskService: kioskService}
+}
+
+type RegisterKioskDeviceRequest struct {
+	Name     string `json:"name" binding:"required"`
+	OfficeID string `json:"office_id" binding:"required"`
+}
+
+// ClockIn identifies the employee in front of the kiosk and clocks them in.
+// It takes a photo and, optionally, the embedding computed on the device.
+func (h *KioskHandler) ClockIn(c *gin.Context) {
+	value, exists := c.Get("kiosk_device")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	device := value.(*models.KioskDevice)
+
+	file, err := c.FormFile("photo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "photo is required"})
+		return
+	}
+
+	// Save uploaded file temporarily
+	photoPath, err := saveTempUpload(file, "kiosk-*"+filepath.Ext(file.Filename))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save photo"})
+		return
+	}
+	defer os.Remove(photoPath)
+
+	face, err := parseFaceSample(c, photoPath)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	liveness, cleanup, err := parseLivenessResponse(c, services.KioskLivenessSubject(device.ID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer cleanup()
+	face.Liveness = liveness
+
+	result, err := h.kioskService.ClockIn(device, face)
+	if err != nil {
+		c.JSON(kioskErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// RegisterDevice registers a kiosk at an office. The device key is only
+// returned here.
+func (h *KioskHandler) RegisterDevice(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req RegisterKioskDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	device, key, err := h.kioskService.RegisterDevice(req.Name, req.OfficeID, adminID.(string))
+	if err != nil {
+		c.JSON(kioskErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": device, "key": key})
+}
+
+func (h *KioskHandler) GetDevices(c *gin.Context) {
+	devices, err := h.kioskService.GetDevices()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": devices})
+}
+
+func (h *KioskHandler) RevokeDevice(c *gin.Context) {
+	device, err := h.kioskService.RevokeDevice(c.Param("id"))
+	if err != nil {
+		c.JSON(kioskErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": device})
+}
+
+// kioskErrorStatus maps kiosk service errors to HTTP status codes, falling
+// back to the attendance ones for the clock-in itself
+func kioskErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrKioskDeviceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrKioskDeviceRevoked):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrFaceNotIdentified),
+		errors.Is(err, services.ErrNoEnrolledSiteMembers):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrAmbiguousFace):
+		return http.StatusUnprocessableEntity
+	default:
+		return attendanceErrorStatus(err)
+	}
+}
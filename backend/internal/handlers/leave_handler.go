@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/services"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LeaveHandler struct {
+	leaveService services.LeaveService
+}
+
+func NewLeaveHandler(leaveService services.LeaveService) *LeaveHandler {
+	return &LeaveHandler{leaveService: leaveService}
+}
+
+type CreateLeaveTypeRequest struct {
+	Code                string `json:"code" binding:"required"`
+	Name                string `json:"name" binding:"required"`
+	AnnualAllowanceDays int    `json:"annual_allowance_days"`
+	Paid                *bool  `json:"paid"`
+}
+
+type UpdateLeaveTypeRequest struct {
+	Name                string `json:"name"`
+	AnnualAllowanceDays *int   `json:"annual_allowance_days"`
+	Paid                *bool  `json:"paid"`
+}
+
+type CreateLeaveRequest struct {
+	LeaveTypeID string `json:"leave_type_id" binding:"required"`
+	StartDate   string `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate     string `json:"end_date" binding:"required"`   // YYYY-MM-DD
+	Reason      string `json:"reason"`
+}
+
+type ReviewLeaveRequest struct {
+	Note string `json:"note"`
+}
+
+func (h *LeaveHandler) GetLeaveTypes(c *gin.Context) {
+	leaveTypes, err := h.leaveService.GetLeaveTypes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": leaveTypes})
+}
+
+func (h *LeaveHandler) CreateLeaveType(c *gin.Context) {
+	var req CreateLeaveTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	paid := true
+	if req.Paid != nil {
+		paid = *req.Paid
+	}
+
+	leaveType, err := h.leaveService.CreateLeaveType(req.Code, req.Name, req.AnnualAllowanceDays, paid)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": leaveType})
+}
+
+func (h *LeaveHandler) UpdateLeaveType(c *gin.Context) {
+	leaveTypeID := c.Param("id")
+	if leaveTypeID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "leave type id is required"})
+		return
+	}
+
+	var req UpdateLeaveTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	leaveType, err := h.leaveService.UpdateLeaveType(leaveTypeID, req.Name, req.AnnualAllowanceDays, req.Paid)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": leaveType})
+}
+
+func (h *LeaveHandler) RequestLeave(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	var req CreateLeaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	startDate, err := time.ParseInLocation("2006-01-02", req.StartDate, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid start_date format"})
+		return
+	}
+	endDate, err := time.ParseInLocation("2006-01-02", req.EndDate, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid end_date format"})
+		return
+	}
+
+	request, err := h.leaveService.RequestLeave(userID.(string), req.LeaveTypeID, startDate, endDate, req.Reason)
+	if err != nil {
+		c.JSON(leaveErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": request})
+}
+
+func (h *LeaveHandler) GetMyLeaveRequests(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	requests, err := h.leaveService.GetUserLeaveRequests(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": requests})
+}
+
+func (h *LeaveHandler) CancelLeave(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	request, err := h.leaveService.CancelLeave(userID.(string), c.Param("id"))
+	if err != nil {
+		c.JSON(leaveErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": request})
+}
+
+func (h *LeaveHandler) GetMyBalances(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	year := time.Now().Year()
+	if yearStr := c.Query("year"); yearStr != "" {
+		parsed, err := strconv.Atoi(yearStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "invalid year"})
+			return
+		}
+		year = parsed
+	}
+
+	balances, err := h.leaveService.GetBalances(userID.(string), year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": balances})
+}
+
+func (h *LeaveHandler) GetLeaveRequests(c *gin.Context) {
+	requests, err := h.leaveService.GetLeaveRequests(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": requests})
+}
+
+func (h *LeaveHandler) ApproveLeave(c *gin.Context) {
+	h.reviewLeave(c, h.leaveService.ApproveLeave)
+}
+
+func (h *LeaveHandler) RejectLeave(c *gin.Context) {
+	h.reviewLeave(c, h.leaveService.RejectLeave)
+}
+
+func (h *LeaveHandler) reviewLeave(c *gin.Context, review func(requestID, reviewerID, note string) (*models.LeaveRequest, error)) {
+	reviewerID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	var req ReviewLeaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	request, err := review(c.Param("id"), reviewerID.(string), req.Note)
+	if err != nil {
+		c.JSON(leaveErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": request})
+}
+
+// leaveErrorStatus maps leave service errors to HTTP status codes
+func leaveErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrLeaveNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrLeaveSelfReview):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrLeaveNotPending),
+		errors.Is(err, services.ErrLeaveOverlap):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LivenessHandler struct {
+	livenessService services.LivenessService
+}
+
+func NewLivenessHandler(livenessService services.LivenessService) *LivenessHandler {
+	return &LivenessHandler{livenessService: livenessService}
+}
+
+// IssueChallenge returns the actions the user must perform on camera before
+// clocking in
+func (h *LivenessHandler) IssueChallenge(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	h.issue(c, userID.(string))
+}
+
+func (h *LivenessHandler) IssueKioskChallenge(c *gin.Context) {
+	value, exists := c.Get("kiosk_device")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	h.issue(c, services.KioskLivenessSubject(value.(*models.KioskDevice).ID))
+}
+
+func (h *LivenessHandler) issue(c *gin.Context, subject string) {
+	challenge, err := h.livenessService.IssueChallenge(subject)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": challenge})
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"face-verification-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OfficeHandler struct {
+	officeService services.OfficeService
+}
+
+func NewOfficeHandler(officeService services.OfficeService) *OfficeHandler {
+	return &OfficeHandler{officeService: officeService}
+}
+
+type CreateOfficeRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	Address      string   `json:"address"`
+	Latitude     *float64 `json:"latitude" binding:"required"`
+	Longitude    *float64 `json:"longitude" binding:"required"`
+	RadiusMeters float64  `json:"radius_meters" binding:"required"`
+}
+
+type UpdateOfficeRequest struct {
+	Name         string   `json:"name"`
+	Address      string   `json:"address"`
+	Latitude     *float64 `json:"latitude"`
+	Longitude    *float64 `json:"longitude"`
+	RadiusMeters *float64 `json:"radius_meters"`
+}
+
+type AssignOfficesRequest struct {
+	OfficeIDs []string `json:"office_ids"`
+}
+
+func (h *OfficeHandler) CreateOffice(c *gin.Context) {
+	var req CreateOfficeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	office, err := h.officeService.CreateOffice(req.Name, req.Address, *req.Latitude, *req.Longitude, req.RadiusMeters)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": office})
+}
+
+func (h *OfficeHandler) GetOffices(c *gin.Context) {
+	offices, err := h.officeService.GetOffices()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": offices})
+}
+
+func (h *OfficeHandler) GetOffice(c *gin.Context) {
+	officeID := c.Param("id")
+	if officeID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "office id is required"})
+		return
+	}
+
+	office, err := h.officeService.GetOffice(officeID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "office not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": office})
+}
+
+func (h *OfficeHandler) UpdateOffice(c *gin.Context) {
+	officeID := c.Param("id")
+	if officeID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "office id is required"})
+		return
+	}
+
+	var req UpdateOfficeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	office, err := h.officeService.UpdateOffice(officeID, req.Name, req.Address, req.Latitude, req.Longitude, req.RadiusMeters)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": office})
+}
+
+func (h *OfficeHandler) DeleteOffice(c *gin.Context) {
+	officeID := c.Param("id")
+	if officeID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "office id is required"})
+		return
+	}
+
+	if err := h.officeService.DeleteOffice(officeID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "office deleted successfully"})
+}
+
+// AssignOffices replaces the offices a user may clock in at
+func (h *OfficeHandler) AssignOffices(c *gin.Context) {
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "user_id is required"})
+		return
+	}
+
+	var req AssignOfficesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := h.officeService.AssignOffices(userID, req.OfficeIDs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "offices assigned successfully"})
+}
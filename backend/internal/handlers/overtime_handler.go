@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"face-verification-backend/internal/services"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OvertimeHandler struct {
+	overtimeService services.OvertimeService
+}
+
+func NewOvertimeHandler(overtimeService services.OvertimeService) *OvertimeHandler {
+	return &OvertimeHandler{overtimeService: overtimeService}
+}
+
+type CreateOvertimeRequest struct {
+	WorkDate string `json:"work_date" binding:"required"` // YYYY-MM-DD
+	Minutes  int    `json:"minutes" binding:"required"`
+	Reason   string `json:"reason"`
+}
+
+type ReviewOvertimeRequest struct {
+	Note    string `json:"note"`
+	Minutes *int   `json:"minutes"` // approve a different number of minutes than requested
+}
+
+func (h *OvertimeHandler) RequestOvertime(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	var req CreateOvertimeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	workDate, err := time.ParseInLocation("2006-01-02", req.WorkDate, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid work_date, expected YYYY-MM-DD"})
+		return
+	}
+
+	request, err := h.overtimeService.RequestOvertime(userID.(string), workDate, req.Minutes, req.Reason)
+	if err != nil {
+		c.JSON(overtimeErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": request})
+}
+
+func (h *OvertimeHandler) GetMyOvertimeRequests(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	requests, err := h.overtimeService.GetUserOvertimeRequests(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": requests})
+}
+
+func (h *OvertimeHandler) GetMySummary(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	startDate, endDate, err := parsePeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	summary, err := h.overtimeService.GetSummary(userID.(string), startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": summary})
+}
+
+func (h *OvertimeHandler) GetOvertimeRequests(c *gin.Context) {
+	requests, err := h.overtimeService.GetOvertimeRequests(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": requests})
+}
+
+func (h *OvertimeHandler) ApproveOvertime(c *gin.Context) {
+	reviewerID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	var req ReviewOvertimeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	request, err := h.overtimeService.ApproveOvertime(c.Param("id"), reviewerID.(string), req.Note, req.Minutes)
+	if err != nil {
+		c.JSON(overtimeErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": request})
+}
+
+func (h *OvertimeHandler) RejectOvertime(c *gin.Context) {
+	reviewerID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	var req ReviewOvertimeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	request, err := h.overtimeService.RejectOvertime(c.Param("id"), reviewerID.(string), req.Note)
+	if err != nil {
+		c.JSON(overtimeErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": request})
+}
+
+// GetSummaries returns the payroll overtime summary of one user (?user_id=) or
+// of every user with overtime in the period
+func (h *OvertimeHandler) GetSummaries(c *gin.Context) {
+	startDate, endDate, err := parsePeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	if userID := c.Query("user_id"); userID != "" {
+		summary, err := h.overtimeService.GetSummary(userID, startDate, endDate)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": summary})
+		return
+	}
+
+	summaries, err := h.overtimeService.GetSummaries(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": summaries})
+}
+
+// parsePeriod reads the inclusive start_date and end_date (YYYY-MM-DD) query parameters
+func parsePeriod(c *gin.Context) (time.Time, time.Time, error) {
+	startDate, err := time.ParseInLocation("2006-01-02", c.Query("start_date"), time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start_date, expected YYYY-MM-DD")
+	}
+	endDate, err := time.ParseInLocation("2006-01-02", c.Query("end_date"), time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid end_date, expected YYYY-MM-DD")
+	}
+	if endDate.Before(startDate) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end_date must not be before start_date")
+	}
+	return startDate, endDate, nil
+}
+
+// overtimeErrorStatus maps overtime service errors to HTTP status codes
+func overtimeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrOvertimeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrOvertimeSelfReview):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrOvertimeNotPending),
+		errors.Is(err, services.ErrOvertimeExists):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resetPasswordPage is the page password reset links open. It reads the token
+// from the URL and posts the new password to /api/v1/auth/reset-password.
+const resetPasswordPage = `<!DOCTYPE html>
+<html lang="id">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Reset Password</title>
+<style>
+body { font-family: sans-serif; max-width: 360px; margin: 48px auto; padding: 0 16px; }
+label { display: block; margin-top: 12px; }
+input { width: 100%; padding: 8px; box-sizing: border-box; }
+button { margin-top: 16px; width: 100%; padding: 10px; }
+#message { margin-top: 16px; }
+</style>
+</head>
+<body>
+<h2>Reset Password</h2>
+<form id="form">
+<label>Password baru <input type="password" id="password" minlength="6" required></label>
+<label>Ulangi password <input type="password" id="confirm" minlength="6" required></label>
+<button type="submit">Simpan</button>
+</form>
+<p id="message"></p>
+<script>
+const token = new URLSearchParams(location.search).get("token") || "";
+const message = document.getElementById("message");
+document.getElementById("form").addEventListener("submit", async (e) => {
+  e.preventDefault();
+  const password = document.getElementById("password").value;
+  if (password !== document.getElementById("confirm").value) {
+    message.textContent = "Password tidak sama";
+    return;
+  }
+  try {
+    const res = await fetch("/api/v1/auth/reset-password", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ token, password }),
+    });
+    const body = await res.json();
+    message.textContent = body.message;
+    if (res.ok) document.getElementById("form").remove();
+  } catch (err) {
+    message.textContent = "Gagal mereset password, silakan coba lagi";
+  }
+});
+</script>
+</body>
+</html>
+`
+
+// ResetPasswordPage serves the form password reset links open
+func (h *AuthHandler) ResetPasswordPage(c *gin.Context) {
+	// The token is in the URL; keep it out of the Referer of other requests
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(resetPasswordPage))
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"face-verification-backend/internal/services"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ServiceCredentialHandler struct {
+	credentialService services.ServiceCredentialService
+}
+
+func NewServiceCredentialHandler(credentialService services.ServiceCredentialService) *ServiceCredentialHandler {
+	return &ServiceCredentialHandler{credentialService: credentialService}
+}
+
+type CreateServiceCredentialRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type RotateServiceCredentialRequest struct {
+	GraceMinutes *int `json:"grace_minutes"` // how long the old key keeps working, defaults to an hour
+}
+
+// CreateCredential issues a service key. The key is only returned here.
+func (h *ServiceCredentialHandler) CreateCredential(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	var req CreateServiceCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	credential, key, err := h.credentialService.CreateCredential(req.Name, req.Scopes, adminID.(string), req.ExpiresAt)
+	if err != nil {
+		c.JSON(serviceCredentialErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": credential, "key": key})
+}
+
+func (h *ServiceCredentialHandler) GetCredentials(c *gin.Context) {
+	credentials, err := h.credentialService.GetCredentials()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": credentials})
+}
+
+// RotateCredential issues a replacement key. The old key keeps working for
+// the grace period so the service can be reconfigured without downtime.
+func (h *ServiceCredentialHandler) RotateCredential(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	var req RotateServiceCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	grace := services.DefaultKeyRotationGrace
+	if req.GraceMinutes != nil {
+		grace = time.Duration(*req.GraceMinutes) * time.Minute
+	}
+
+	credential, key, err := h.credentialService.RotateCredential(c.Param("id"), adminID.(string), grace)
+	if err != nil {
+		c.JSON(serviceCredentialErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": credential, "key": key})
+}
+
+func (h *ServiceCredentialHandler) RevokeCredential(c *gin.Context) {
+	credential, err := h.credentialService.RevokeCredential(c.Param("id"))
+	if err != nil {
+		c.JSON(serviceCredentialErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": credential})
+}
+
+// serviceCredentialErrorStatus maps service credential errors to HTTP status codes
+func serviceCredentialErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrServiceCredentialNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrServiceCredentialInactive):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
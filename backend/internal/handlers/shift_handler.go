@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"face-verification-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShiftHandler struct {
+	shiftService services.ShiftService
+}
+
+func NewShiftHandler(shiftService services.ShiftService) *ShiftHandler {
+	return &ShiftHandler{shiftService: shiftService}
+}
+
+type CreateShiftRequest struct {
+	Name               string `json:"name" binding:"required"`
+	StartTime          string `json:"start_time" binding:"required"`
+	EndTime            string `json:"end_time" binding:"required"`
+	GracePeriodMinutes int    `json:"grace_period_minutes"`
+}
+
+type UpdateShiftRequest struct {
+	Name               string `json:"name"`
+	StartTime          string `json:"start_time"`
+	EndTime            string `json:"end_time"`
+	GracePeriodMinutes *int   `json:"grace_period_minutes"`
+}
+
+type AssignShiftRequest struct {
+	ShiftID string `json:"shift_id"`
+}
+
+func (h *ShiftHandler) CreateShift(c *gin.Context) {
+	var req CreateShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	shift, err := h.shiftService.CreateShift(req.Name, req.StartTime, req.EndTime, req.GracePeriodMinutes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": shift})
+}
+
+func (h *ShiftHandler) GetShifts(c *gin.Context) {
+	shifts, err := h.shiftService.GetShifts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": shifts})
+}
+
+func (h *ShiftHandler) GetShift(c *gin.Context) {
+	shiftID := c.Param("id")
+	if shiftID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "shift id is required"})
+		return
+	}
+
+	shift, err := h.shiftService.GetShift(shiftID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "shift not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": shift})
+}
+
+func (h *ShiftHandler) UpdateShift(c *gin.Context) {
+	shiftID := c.Param("id")
+	if shiftID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "shift id is required"})
+		return
+	}
+
+	var req UpdateShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	shift, err := h.shiftService.UpdateShift(shiftID, req.Name, req.StartTime, req.EndTime, req.GracePeriodMinutes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": shift})
+}
+
+func (h *ShiftHandler) DeleteShift(c *gin.Context) {
+	shiftID := c.Param("id")
+	if shiftID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "shift id is required"})
+		return
+	}
+
+	if err := h.shiftService.DeleteShift(shiftID); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrShiftNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrShiftInUse):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "shift deleted successfully"})
+}
+
+// AssignShift assigns a shift to a user. An empty shift_id clears the assignment.
+func (h *ShiftHandler) AssignShift(c *gin.Context) {
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "user_id is required"})
+		return
+	}
+
+	var req AssignShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := h.shiftService.AssignShift(userID, req.ShiftID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrUserNotFound) || errors.Is(err, services.ErrShiftNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "shift assigned successfully"})
+}
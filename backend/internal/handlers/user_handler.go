@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"face-verification-backend/internal/models"
 	"face-verification-backend/internal/services"
 	"net/http"
 
@@ -94,7 +96,7 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.ChangePassword(userID.(string), req.OldPassword, req.NewPassword); err != nil {
+	if err := h.userService.ChangePassword(userID.(string), c.GetString("session_id"), req.OldPassword, req.NewPassword); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
@@ -102,3 +104,49 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "password changed successfully"})
 }
 
+type UpdateRoleRequest struct {
+	Role        string   `json:"role" binding:"required"`
+	Permissions []string `json:"permissions"`
+}
+
+// GetRoles lists the roles and the permissions each one grants
+func (h *UserHandler) GetRoles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"roles":       models.RolePermissions,
+			"permissions": models.AllPermissions,
+		},
+	})
+}
+
+// UpdateUserRole sets the role of a user and any permissions granted on top of
+// it. The user is signed out on every device and gets the new role on the next
+// login.
+func (h *UserHandler) UpdateUserRole(c *gin.Context) {
+	actorID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "unauthorized"})
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	user, err := h.userService.UpdateRole(actorID.(string), c.Param("user_id"), req.Role, req.Permissions)
+	if err != nil {
+		status := http.StatusNotFound
+		switch {
+		case errors.Is(err, services.ErrInvalidRole), errors.Is(err, services.ErrInvalidPermission):
+			status = http.StatusBadRequest
+		case errors.Is(err, services.ErrOwnRole):
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": user})
+}
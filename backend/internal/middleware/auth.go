@@ -2,14 +2,17 @@ package middleware
 
 import (
 	"errors"
+	"face-verification-backend/internal/services"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// AuthMiddleware only lets through requests with a valid access token whose
+// session has not been revoked. The user, session, role and permissions are
+// stored as "user_id", "session_id", "role" and "permissions".
+func AuthMiddleware(authService services.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -25,28 +28,28 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("invalid token")
-			}
-			return []byte(jwtSecret), nil
-		})
-
-		if err != nil {
+		claims, err := authService.ValidateToken(tokenString)
+		if errors.Is(err, services.ErrSessionRevoked) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
+			c.Abort()
+			return
+		}
+		if errors.Is(err, services.ErrInvalidToken) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
 			return
 		}
-
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			userID := claims["user_id"].(string)
-			c.Set("user_id", userID)
-			c.Next()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to validate token"})
+			c.Abort()
 			return
 		}
 
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-		c.Abort()
+		c.Set("user_id", claims.UserID)
+		c.Set("session_id", claims.SessionID)
+		c.Set("role", claims.Role)
+		c.Set("permissions", claims.Permissions)
+		c.Set("claims", claims)
+		c.Next()
 	}
 }
-
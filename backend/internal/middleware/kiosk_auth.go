@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"errors"
+	"face-verification-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeviceKeyHeader carries the key of a kiosk device.
+const DeviceKeyHeader = "X-Device-Key"
+
+// KioskAuthMiddleware only lets through requests from an active kiosk device.
+// The device is stored as "kiosk_device".
+func KioskAuthMiddleware(kioskService services.KioskService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(DeviceKeyHeader)
+		if key == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "device key required"})
+			c.Abort()
+			return
+		}
+
+		device, err := kioskService.Authenticate(key)
+		if errors.Is(err, services.ErrInvalidDeviceKey) || errors.Is(err, services.ErrKioskDeviceRevoked) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to authenticate device"})
+			c.Abort()
+			return
+		}
+
+		c.Set("kiosk_device", device)
+		c.Next()
+	}
+}
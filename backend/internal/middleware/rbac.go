@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"face-verification-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole only lets through users with one of the roles. It must run after
+// AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := accessClaims(c)
+		if !ok {
+			return
+		}
+		for _, role := range roles {
+			if claims.Role == role {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient role"})
+		c.Abort()
+	}
+}
+
+// RequirePermission only lets through users granted the permission, by their
+// role or individually. It must run after AuthMiddleware.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := accessClaims(c)
+		if !ok {
+			return
+		}
+		if !claims.HasPermission(permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "missing permission " + permission})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// accessClaims returns the claims stored by AuthMiddleware, aborting the
+// request when there are none.
+func accessClaims(c *gin.Context) (*services.AccessClaims, bool) {
+	value, exists := c.Get("claims")
+	claims, ok := value.(*services.AccessClaims)
+	if !exists || !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Abort()
+		return nil, false
+	}
+	return claims, true
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"errors"
+	"face-verification-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServiceKeyHeader carries the API key of an internal service.
+const ServiceKeyHeader = "X-Service-Key"
+
+// ServiceAuthMiddleware only lets through requests with an active service key
+// granted the scope. The credential is stored as "service_credential".
+func ServiceAuthMiddleware(credentialService services.ServiceCredentialService, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(ServiceKeyHeader)
+		if key == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "service key required"})
+			c.Abort()
+			return
+		}
+
+		credential, err := credentialService.Authenticate(key)
+		if errors.Is(err, services.ErrInvalidServiceKey) || errors.Is(err, services.ErrServiceCredentialInactive) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to authenticate service"})
+			c.Abort()
+			return
+		}
+
+		if !credential.HasScope(scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "service key lacks scope " + scope})
+			c.Abort()
+			return
+		}
+
+		c.Set("service_credential", credential)
+		c.Next()
+	}
+}
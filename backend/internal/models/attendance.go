@@ -2,24 +2,76 @@ package models
 
 import (
 	"time"
+)
+
+const (
+	AttendanceStatusOnTime     = "on_time"
+	AttendanceStatusLate       = "late"
+	AttendanceStatusEarlyLeave = "early_leave"
+	AttendanceStatusOvertime   = "overtime"
+)
 
-	"gorm.io/gorm"
+const (
+	AttendanceStateNotStarted = "not_started"
+	AttendanceStateClockedIn  = "clocked_in"
+	AttendanceStateOnBreak    = "on_break"
+	AttendanceStateClockedOut = "clocked_out"
+)
+
+// Day statuses reported in the attendance history.
+const (
+	DayStatusPresent       = "present"
+	DayStatusExcused       = "excused"
+	DayStatusAbsent        = "absent"
+	DayStatusNonWorkingDay = "non_working_day"
 )
 
 type Attendance struct {
-	ID              string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
-	UserID          string    `gorm:"index;not null;type:varchar(36)" json:"user_id"`
-	ClockIn         *time.Time `json:"clock_in"`
-	ClockOut        *time.Time `json:"clock_out"`
-	ClockInPhoto    string    `gorm:"type:varchar(500)" json:"clock_in_photo"`
-	ClockOutPhoto   string    `gorm:"type:varchar(500)" json:"clock_out_photo"`
-	ClockInLocation string    `gorm:"type:text" json:"clock_in_location"`
-	ClockOutLocation string   `gorm:"type:text" json:"clock_out_location"`
-	IsVerified      bool      `gorm:"default:false" json:"is_verified"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
-	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
-	
+	ID                    string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID                string     `gorm:"index;not null;type:varchar(36)" json:"user_id"`
+	WorkDate              time.Time  `gorm:"type:date;index" json:"work_date"` // logical workday, may differ from the clock-in date for night shifts; one attendance per user and workday, see database.Migrate
+	ClockIn               *time.Time `json:"clock_in"`
+	ClockOut              *time.Time `json:"clock_out"`
+	ClockInPhoto          string     `gorm:"type:varchar(500)" json:"clock_in_photo"`
+	ClockOutPhoto         string     `gorm:"type:varchar(500)" json:"clock_out_photo"`
+	ClockInLocation       string     `gorm:"type:text" json:"clock_in_location"`
+	ClockOutLocation      string     `gorm:"type:text" json:"clock_out_location"`
+	ClockInLatitude       *float64   `json:"clock_in_latitude"`
+	ClockInLongitude      *float64   `json:"clock_in_longitude"`
+	ClockInOfficeID       string     `gorm:"type:varchar(36)" json:"clock_in_office_id"` // nearest assigned office
+	ClockInDistanceMeters *float64   `json:"clock_in_distance_meters"`
+	OutsideGeofence       bool       `gorm:"default:false" json:"outside_geofence"` // flagged for manager review
+	Corrected             bool       `gorm:"default:false" json:"corrected"`        // changed by an approved correction request
+	AutoClosed            bool       `gorm:"default:false" json:"auto_closed"`      // clocked out by the system, not the employee
+	IsVerified            bool       `gorm:"default:false" json:"is_verified"`
+	LivenessResult        string     `gorm:"type:varchar(20)" json:"liveness_result"` // passed, failed, missing; empty when not checked
+	LivenessScore         *float64   `json:"liveness_score"`
+	LivenessChallengeID   string     `gorm:"type:varchar(36)" json:"liveness_challenge_id"`
+	PhotoFlagged          bool       `gorm:"default:false" json:"photo_flagged"`   // a punch photo needs review
+	PhotoFlags            string     `gorm:"type:varchar(100)" json:"photo_flags"` // comma separated: near_duplicate, capture_time_skew
+	ShiftID               string     `gorm:"type:varchar(36)" json:"shift_id"`
+	ClockInStatus         string     `gorm:"type:varchar(20)" json:"clock_in_status"`  // on_time, late
+	ClockOutStatus        string     `gorm:"type:varchar(20)" json:"clock_out_status"` // on_time, early_leave, overtime
+	LateMinutes           int        `gorm:"type:int;default:0" json:"late_minutes"`
+	EarlyLeaveMinutes     int        `gorm:"type:int;default:0" json:"early_leave_minutes"`
+	OvertimeMinutes       int        `gorm:"type:int;default:0" json:"overtime_minutes"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+
+	// Computed from the punches, not persisted
+	State         string `gorm:"-" json:"state"` // not_started, clocked_in, on_break, clocked_out
+	WorkedMinutes int    `gorm:"-" json:"worked_minutes"`
+	BreakMinutes  int    `gorm:"-" json:"break_minutes"`
+
+	// Set when the day is covered by approved leave, not persisted
+	Excused bool          `gorm:"-" json:"excused"`
+	Leave   *LeaveRequest `gorm:"-" json:"leave,omitempty"`
+
+	// Set from the company calendar in the history, not persisted
+	DayStatus        string `gorm:"-" json:"day_status,omitempty"`         // present, excused, absent, non_working_day
+	NonWorkingReason string `gorm:"-" json:"non_working_reason,omitempty"` // weekend, holiday
+
+	Punches []AttendancePunch `gorm:"foreignKey:AttendanceID" json:"punches"`
+
 	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
 }
-
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CorrectionStatusPending  = "pending"
+	CorrectionStatusApproved = "approved"
+	CorrectionStatusRejected = "rejected"
+)
+
+// AttendanceCorrection is an employee's request to fix the clock times of a workday.
+type AttendanceCorrection struct {
+	ID                string         `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID            string         `gorm:"index;not null;type:varchar(36)" json:"user_id"`
+	WorkDate          time.Time      `gorm:"type:date;not null" json:"work_date"`
+	RequestedClockIn  *time.Time     `json:"requested_clock_in"`
+	RequestedClockOut *time.Time     `json:"requested_clock_out"`
+	Reason            string         `gorm:"type:text;not null" json:"reason"`
+	EvidencePhoto     string         `gorm:"type:varchar(500)" json:"evidence_photo"`
+	Status            string         `gorm:"type:varchar(20);default:'pending'" json:"status"` // pending, approved, rejected
+	AttendanceID      string         `gorm:"type:varchar(36)" json:"attendance_id"`            // set on approval
+	ReviewerID        string         `gorm:"type:varchar(36)" json:"reviewer_id"`
+	ReviewNote        string         `gorm:"type:text" json:"review_note"`
+	ReviewedAt        *time.Time     `json:"reviewed_at"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// AttendanceCorrectionHistory records the values an approved correction
+// replaced. Entries are only ever inserted.
+type AttendanceCorrectionHistory struct {
+	ID               string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	AttendanceID     string     `gorm:"index;not null;type:varchar(36)" json:"attendance_id"`
+	CorrectionID     string     `gorm:"index;not null;type:varchar(36)" json:"correction_id"`
+	PreviousClockIn  *time.Time `json:"previous_clock_in"`
+	PreviousClockOut *time.Time `json:"previous_clock_out"`
+	NewClockIn       *time.Time `json:"new_clock_in"`
+	NewClockOut      *time.Time `json:"new_clock_out"`
+	ApprovedBy       string     `gorm:"not null;type:varchar(36)" json:"approved_by"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+func (AttendanceCorrectionHistory) TableName() string {
+	return "attendance_correction_history"
+}
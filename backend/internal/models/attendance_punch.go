@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	PunchTypeClockIn    = "clock_in"
+	PunchTypeClockOut   = "clock_out"
+	PunchTypeBreakStart = "break_start"
+	PunchTypeBreakEnd   = "break_end"
+)
+
+// AttendancePunch is a single clock or break event within a day's attendance.
+type AttendancePunch struct {
+	ID           string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	AttendanceID string    `gorm:"index;not null;type:varchar(36)" json:"attendance_id"`
+	UserID       string    `gorm:"index;not null;type:varchar(36)" json:"user_id"`
+	Type         string    `gorm:"not null;type:varchar(20)" json:"type"` // clock_in, clock_out, break_start, break_end
+	PunchedAt    time.Time `gorm:"not null" json:"punched_at"`
+	Photo        string    `gorm:"type:varchar(500)" json:"photo"`
+	Location     string    `gorm:"type:text" json:"location"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// AuthSession is a login on a device. It holds the hash of the current refresh
+// token, which is replaced every time the session is refreshed, and the hash of
+// the one before it so that a reused token can be detected.
+type AuthSession struct {
+	ID                string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID            string     `gorm:"index;not null;type:varchar(36)" json:"user_id"`
+	RefreshTokenHash  string     `gorm:"uniqueIndex;not null;type:varchar(64)" json:"-"` // hex SHA-256 of the refresh token
+	PreviousTokenHash string     `gorm:"index;type:varchar(64)" json:"-"`
+	UserAgent         string     `gorm:"type:varchar(255)" json:"user_agent"`
+	ClientIP          string     `gorm:"type:varchar(45)" json:"client_ip"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	LastRefreshedAt   *time.Time `json:"last_refreshed_at"`
+	RevokedAt         *time.Time `json:"revoked_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// IsActive reports whether the session may be used at the given time.
+func (s *AuthSession) IsActive(at time.Time) bool {
+	return s.RevokedAt == nil && at.Before(s.ExpiresAt)
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	HolidaySourceManual = "manual"
+	HolidaySourceICS    = "ics"
+)
+
+// Holiday is a company-wide non-working day, such as a public holiday.
+type Holiday struct {
+	ID          string         `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Date        time.Time      `gorm:"type:date;index;not null" json:"date"`
+	Name        string         `gorm:"not null;type:varchar(255)" json:"name"`
+	Source      string         `gorm:"type:varchar(20);default:'manual'" json:"source"` // manual, ics
+	ExternalUID string         `gorm:"type:varchar(255);index" json:"external_uid"`     // UID of the imported calendar event
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// WorkingDayRule marks a weekday (0 = Sunday) as a working day or not.
+type WorkingDayRule struct {
+	Weekday      int       `gorm:"primaryKey;autoIncrement:false" json:"weekday"`
+	IsWorkingDay bool      `gorm:"not null" json:"is_working_day"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	EmbeddingAuditActionRead   = "read"
+	EmbeddingAuditActionWrite  = "write"
+	EmbeddingAuditActionDelete = "delete"
+)
+
+// Who accessed the embedding.
+const (
+	EmbeddingAuditActorService = "service"
+	EmbeddingAuditActorUser    = "user"
+)
+
+// EmbeddingAuditEntry records one read or write of a user's face embeddings.
+type EmbeddingAuditEntry struct {
+	ID          string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Action      string    `gorm:"index;not null;type:varchar(20)" json:"action"`  // read, write, delete
+	UserID      string    `gorm:"index;not null;type:varchar(36)" json:"user_id"` // owner of the embedding
+	EmbeddingID string    `gorm:"type:varchar(36)" json:"embedding_id"`
+	ActorType   string    `gorm:"not null;type:varchar(20)" json:"actor_type"` // service, user
+	ActorID     string    `gorm:"index;type:varchar(36)" json:"actor_id"`      // service credential or user
+	ActorName   string    `gorm:"type:varchar(100)" json:"actor_name"`
+	ClientIP    string    `gorm:"type:varchar(45)" json:"client_ip"`
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+}
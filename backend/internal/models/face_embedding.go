@@ -2,18 +2,25 @@ package models
 
 import (
 	"time"
-
-	"gorm.io/gorm"
 )
 
+// FaceEmbedding is one enrolled reference face of a user. A user may have
+// several, e.g. with and without glasses, and is matched against the best one.
+// Deleting an embedding erases it, it is never soft deleted.
 type FaceEmbedding struct {
-	ID        string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
-	UserID    string    `gorm:"uniqueIndex;not null;type:varchar(36)" json:"user_id"`
-	Embedding string    `gorm:"type:text;not null" json:"-"` // JSON array of floats
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
-	
+	ID               string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID           string    `gorm:"index:idx_face_embeddings_user;not null;type:varchar(36)" json:"user_id"`
+	Embedding        string    `gorm:"type:text;not null" json:"-"`    // JSON array of floats, encrypted at rest when EncryptionKeyID is set
+	Label            string    `gorm:"type:varchar(100)" json:"label"` // e.g. "glasses", "beard"
+	CapturedAt       time.Time `json:"captured_at"`
+	ModelName        string    `gorm:"type:varchar(100);index:idx_face_embeddings_model" json:"model_name"` // e.g. buffalo_l
+	ModelVersion     string    `gorm:"type:varchar(50);index:idx_face_embeddings_model" json:"model_version"`
+	Dimension        int       `gorm:"type:int;default:0" json:"dimension"`
+	Pending          bool      `gorm:"default:false;index" json:"pending"` // captured in an enrollment not approved yet, not used for verification
+	EncryptionKeyID  string    `gorm:"type:varchar(50);index" json:"-"`    // master key wrapping EncryptedDataKey, empty for plain text
+	EncryptedDataKey string    `gorm:"type:varchar(255)" json:"-"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
 	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
 }
-
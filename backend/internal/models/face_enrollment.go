@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	EnrollmentStatusOpen      = "open"     // taking captures
+	EnrollmentStatusPending   = "pending"  // submitted, waiting for HR
+	EnrollmentStatusApproved  = "approved" // embeddings are active
+	EnrollmentStatusRejected  = "rejected"
+	EnrollmentStatusCancelled = "cancelled"
+)
+
+// Reasons a capture fails the quality checks.
+const (
+	CaptureRejectionNoFace        = "no_face"
+	CaptureRejectionMultipleFaces = "multiple_faces"
+	CaptureRejectionFaceTooSmall  = "face_too_small"
+	CaptureRejectionBlurry        = "blurry"
+	CaptureRejectionTooDark       = "too_dark"
+	CaptureRejectionTooBright     = "too_bright"
+)
+
+// FaceEnrollment is a session in which a user captures their reference faces.
+// The embeddings of the captures stay pending, unused for verification, until
+// HR approves the enrollment.
+type FaceEnrollment struct {
+	ID          string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID      string     `gorm:"index;not null;type:varchar(36)" json:"user_id"`
+	Status      string     `gorm:"index;not null;type:varchar(20)" json:"status"` // open, pending, approved, rejected, cancelled
+	SubmittedAt *time.Time `json:"submitted_at"`
+	ReviewedBy  string     `gorm:"type:varchar(36)" json:"reviewed_by"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+	ReviewNote  string     `gorm:"type:text" json:"review_note"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	Captures []FaceEnrollmentCapture `gorm:"foreignKey:EnrollmentID" json:"captures"`
+}
+
+// FaceEnrollmentCapture is one photo taken during an enrollment, with the
+// result of its quality checks. Only captures that passed have an embedding.
+type FaceEnrollmentCapture struct {
+	ID           string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	EnrollmentID string    `gorm:"index;not null;type:varchar(36)" json:"enrollment_id"`
+	Photo        string    `gorm:"type:varchar(500)" json:"photo"`
+	Label        string    `gorm:"type:varchar(100)" json:"label"`
+	Passed       bool      `gorm:"default:false" json:"passed"`
+	FaceCount    int       `gorm:"type:int;default:0" json:"face_count"`
+	FaceSize     int       `gorm:"type:int;default:0" json:"face_size"` // shorter side of the face in pixels
+	Sharpness    float64   `json:"sharpness"`                           // variance of the Laplacian of the face
+	Brightness   float64   `json:"brightness"`                          // mean luminance of the face, 0-255
+	Rejections   string    `gorm:"type:varchar(255)" json:"rejections"` // comma separated reasons
+	EmbeddingID  string    `gorm:"type:varchar(36)" json:"embedding_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	VerificationOutcomeVerified = "verified"
+	VerificationOutcomeMismatch = "mismatch"
+	VerificationOutcomeDegraded = "degraded"
+	VerificationOutcomeError    = "error"
+	// VerificationOutcomeRejected is a punch refused before the face was
+	// compared, e.g. outside the geofence, a replayed photo or failed liveness.
+	VerificationOutcomeRejected = "rejected"
+)
+
+// FaceVerificationAttempt records one face check made for a punch, whatever its
+// outcome, to tune thresholds and investigate disputed punches.
+type FaceVerificationAttempt struct {
+	ID              string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID          string    `gorm:"index;not null;type:varchar(36)" json:"user_id"`
+	PunchType       string    `gorm:"type:varchar(20)" json:"punch_type"` // clock_in, clock_out
+	Verifier        string    `gorm:"type:varchar(20)" json:"verifier"`   // http, embedding
+	Similarity      *float64  `json:"similarity"`
+	Threshold       *float64  `json:"threshold"`
+	ThresholdSource string    `gorm:"type:varchar(10)" json:"threshold_source"`       // default, site, user, or service when the face service applied its own
+	OfficeID        string    `gorm:"type:varchar(36)" json:"office_id"`              // site of the punch, when known
+	Outcome         string    `gorm:"index;not null;type:varchar(20)" json:"outcome"` // verified, mismatch, degraded, error, rejected
+	FailureReason   string    `gorm:"type:text" json:"failure_reason"`
+	Photo           string    `gorm:"type:varchar(500)" json:"photo"` // only kept for accepted punches
+	AttemptedAt     time.Time `gorm:"index;not null" json:"attempted_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// KioskDevice is a shared clock-in terminal installed at an office. It
+// authenticates with its own key and identifies employees by their face.
+type KioskDevice struct {
+	ID         string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Name       string     `gorm:"not null;type:varchar(100)" json:"name"`
+	OfficeID   string     `gorm:"index;not null;type:varchar(36)" json:"office_id"`
+	KeyPrefix  string     `gorm:"uniqueIndex;not null;type:varchar(20)" json:"key_prefix"`
+	KeyHash    string     `gorm:"not null;type:varchar(64)" json:"-"` // hex SHA-256 of the key
+	RevokedAt  *time.Time `json:"revoked_at"`
+	LastSeenAt *time.Time `json:"last_seen_at"`
+	CreatedBy  string     `gorm:"type:varchar(36)" json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Office *Office `gorm:"foreignKey:OfficeID" json:"office,omitempty"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	LeaveStatusPending   = "pending"
+	LeaveStatusApproved  = "approved"
+	LeaveStatusRejected  = "rejected"
+	LeaveStatusCancelled = "cancelled"
+)
+
+type LeaveType struct {
+	ID                  string         `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Code                string         `gorm:"uniqueIndex;not null;type:varchar(50)" json:"code"` // e.g. annual, sick, permit
+	Name                string         `gorm:"not null;type:varchar(100)" json:"name"`
+	AnnualAllowanceDays int            `gorm:"type:int;default:0" json:"annual_allowance_days"` // 0 means not limited by a balance
+	Paid                bool           `gorm:"default:true" json:"paid"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+type LeaveRequest struct {
+	ID          string         `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID      string         `gorm:"index;not null;type:varchar(36)" json:"user_id"`
+	LeaveTypeID string         `gorm:"index;not null;type:varchar(36)" json:"leave_type_id"`
+	StartDate   time.Time      `gorm:"type:date;not null" json:"start_date"`
+	EndDate     time.Time      `gorm:"type:date;not null" json:"end_date"`
+	Days        int            `gorm:"type:int;not null" json:"days"`      // working days covered by the request
+	BalanceID   string         `gorm:"type:varchar(36)" json:"balance_id"` // balance the days are reserved on, empty for unlimited leave types
+	Reason      string         `gorm:"type:text" json:"reason"`
+	Status      string         `gorm:"type:varchar(20);default:'pending'" json:"status"` // pending, approved, rejected, cancelled
+	ReviewerID  string         `gorm:"type:varchar(36)" json:"reviewer_id"`
+	ReviewNote  string         `gorm:"type:text" json:"review_note"`
+	ReviewedAt  *time.Time     `json:"reviewed_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	LeaveType LeaveType `gorm:"foreignKey:LeaveTypeID" json:"leave_type,omitempty"`
+}
+
+// LeaveBalance tracks a user's allowance of one leave type for one year.
+type LeaveBalance struct {
+	ID           string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID       string    `gorm:"uniqueIndex:idx_leave_balance;not null;type:varchar(36)" json:"user_id"`
+	LeaveTypeID  string    `gorm:"uniqueIndex:idx_leave_balance;not null;type:varchar(36)" json:"leave_type_id"`
+	Year         int       `gorm:"uniqueIndex:idx_leave_balance;not null" json:"year"`
+	EntitledDays int       `gorm:"type:int;default:0" json:"entitled_days"`
+	UsedDays     int       `gorm:"type:int;default:0" json:"used_days"`
+	PendingDays  int       `gorm:"type:int;default:0" json:"pending_days"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	LeaveType LeaveType `gorm:"foreignKey:LeaveTypeID" json:"leave_type,omitempty"`
+}
+
+// RemainingDays returns the days still available to request.
+func (b *LeaveBalance) RemainingDays() int {
+	return b.EntitledDays - b.UsedDays - b.PendingDays
+}
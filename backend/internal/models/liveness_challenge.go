@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// Actions a liveness challenge may ask for.
+const (
+	LivenessActionBlink     = "blink"
+	LivenessActionTurnLeft  = "turn_left"
+	LivenessActionTurnRight = "turn_right"
+	LivenessActionNod       = "nod"
+)
+
+// Liveness results stored on the attendance.
+const (
+	LivenessResultPassed  = "passed"
+	LivenessResultFailed  = "failed"
+	LivenessResultMissing = "missing" // no challenge response was sent
+)
+
+// LivenessChallenge asks the person clocking in to perform a random sequence
+// of actions on camera, so a printed photo or replayed picture is rejected.
+// A challenge can be answered once, before it expires.
+type LivenessChallenge struct {
+	ID        string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Subject   string     `gorm:"index;not null;type:varchar(64)" json:"-"`  // user ID, or kiosk:<device ID>
+	Actions   string     `gorm:"not null;type:varchar(255)" json:"actions"` // comma separated, in order
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
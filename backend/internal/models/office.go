@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Office struct {
+	ID           string  `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Name         string  `gorm:"not null;type:varchar(255)" json:"name"`
+	Address      string  `gorm:"type:text" json:"address"`
+	Latitude     float64 `gorm:"not null" json:"latitude"`
+	Longitude    float64 `gorm:"not null" json:"longitude"`
+	RadiusMeters float64 `gorm:"not null" json:"radius_meters"`
+	// Overrides the default face match threshold, e.g. for poorly lit sites
+	FaceMatchThreshold *float64       `json:"face_match_threshold"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// UserOffice assigns a user to an office they are allowed to clock in at.
+type UserOffice struct {
+	UserID    string    `gorm:"primaryKey;type:varchar(36)" json:"user_id"`
+	OfficeID  string    `gorm:"primaryKey;type:varchar(36)" json:"office_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
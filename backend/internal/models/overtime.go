@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	OvertimeStatusPending  = "pending"
+	OvertimeStatusApproved = "approved"
+	OvertimeStatusRejected = "rejected"
+)
+
+// OvertimeRequest asks a manager to approve overtime on one workday. It can be
+// made ahead of the day (pre-approval) or afterwards for overtime already worked.
+type OvertimeRequest struct {
+	ID               string         `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID           string         `gorm:"index;not null;type:varchar(36)" json:"user_id"`
+	WorkDate         time.Time      `gorm:"type:date;index;not null" json:"work_date"`
+	RequestedMinutes int            `gorm:"type:int;not null" json:"requested_minutes"`
+	ApprovedMinutes  int            `gorm:"type:int;default:0" json:"approved_minutes"` // may be lowered by the reviewer
+	Reason           string         `gorm:"type:text" json:"reason"`
+	Status           string         `gorm:"type:varchar(20);default:'pending'" json:"status"` // pending, approved, rejected
+	ReviewerID       string         `gorm:"type:varchar(36)" json:"reviewer_id"`
+	ReviewNote       string         `gorm:"type:text" json:"review_note"`
+	ReviewedAt       *time.Time     `json:"reviewed_at"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+}
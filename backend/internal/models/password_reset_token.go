@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// PasswordResetToken lets a user who forgot their password set a new one. It
+// can be used once before it expires. Only a hash of the token is stored.
+type PasswordResetToken struct {
+	ID          string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID      string     `gorm:"index;not null;type:varchar(36)" json:"user_id"`
+	TokenHash   string     `gorm:"uniqueIndex;not null;type:varchar(64)" json:"-"` // hex SHA-256 of the token
+	ExpiresAt   time.Time  `json:"expires_at"`
+	UsedAt      *time.Time `json:"used_at"`
+	RequestedIP string     `gorm:"type:varchar(45)" json:"requested_ip"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// IsUsable reports whether the token may still be used at the given time.
+func (t *PasswordResetToken) IsUsable(at time.Time) bool {
+	return t.UsedAt == nil && at.Before(t.ExpiresAt)
+}
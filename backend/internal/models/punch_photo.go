@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// Reasons a punch photo is flagged for review.
+const (
+	PhotoFlagNearDuplicate   = "near_duplicate"    // looks like a photo submitted before
+	PhotoFlagCaptureTimeSkew = "capture_time_skew" // EXIF capture time far from the server time
+)
+
+// PunchPhoto is the fingerprint of a photo submitted with a clock-in or
+// clock-out, kept to detect the same picture being submitted again.
+type PunchPhoto struct {
+	ID             string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID         string     `gorm:"index:idx_punch_photos_user_hash;not null;type:varchar(36)" json:"user_id"`
+	AttendanceID   string     `gorm:"index;type:varchar(36)" json:"attendance_id"`
+	PunchType      string     `gorm:"type:varchar(20)" json:"punch_type"`
+	ContentHash    string     `gorm:"index:idx_punch_photos_user_hash;not null;type:varchar(64)" json:"content_hash"` // SHA-256 of the file
+	PerceptualHash string     `gorm:"type:varchar(16)" json:"perceptual_hash"`                                        // 64-bit difference hash in hex, empty when the image could not be decoded
+	CapturedAt     *time.Time `json:"captured_at"`                                                                    // EXIF capture time, when present
+	CreatedAt      time.Time  `gorm:"index" json:"created_at"`
+}
@@ -0,0 +1,92 @@
+package models
+
+import "strings"
+
+// Roles a user can have. Every user is an employee; managers review the leave,
+// overtime and correction requests of every employee, as there are no teams,
+// and admins configure the system.
+const (
+	RoleEmployee = "employee"
+	RoleManager  = "manager"
+	RoleAdmin    = "admin"
+)
+
+// Permissions checked by the admin routes.
+const (
+	PermissionShiftsManage     = "shifts:manage"
+	PermissionOfficesManage    = "offices:manage"
+	PermissionCalendarManage   = "calendar:manage"
+	PermissionLeaveManage      = "leave:manage"
+	PermissionLeaveApprove     = "leave:approve"
+	PermissionOvertimeApprove  = "overtime:approve"
+	PermissionAttendanceReview = "attendance:review"
+	PermissionFaceManage       = "face:manage"
+	PermissionUsersManage      = "users:manage"
+)
+
+// AllPermissions lists every permission.
+var AllPermissions = []string{
+	PermissionShiftsManage,
+	PermissionOfficesManage,
+	PermissionCalendarManage,
+	PermissionLeaveManage,
+	PermissionLeaveApprove,
+	PermissionOvertimeApprove,
+	PermissionAttendanceReview,
+	PermissionFaceManage,
+	PermissionUsersManage,
+}
+
+// RolePermissions are the permissions each role grants.
+var RolePermissions = map[string][]string{
+	RoleEmployee: {},
+	RoleManager: {
+		PermissionLeaveApprove,
+		PermissionOvertimeApprove,
+		PermissionAttendanceReview,
+	},
+	RoleAdmin: AllPermissions,
+}
+
+// IsValidRole reports whether role is one of the roles above.
+func IsValidRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+// IsValidPermission reports whether permission is one of the permissions above.
+func IsValidPermission(permission string) bool {
+	for _, p := range AllPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// GrantedPermissions returns the permissions of the user's role together with
+// the ones granted to the user individually.
+func (u *User) GrantedPermissions() []string {
+	role := u.Role
+	if role == "" {
+		role = RoleEmployee
+	}
+	permissions := append([]string{}, RolePermissions[role]...)
+	for _, extra := range strings.Split(u.Permissions, ",") {
+		extra = strings.TrimSpace(extra)
+		if extra == "" {
+			continue
+		}
+		granted := false
+		for _, p := range permissions {
+			if p == extra {
+				granted = true
+				break
+			}
+		}
+		if !granted {
+			permissions = append(permissions, extra)
+		}
+	}
+	return permissions
+}
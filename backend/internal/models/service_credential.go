@@ -0,0 +1,46 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Scopes granted to service credentials.
+const (
+	ScopeEmbeddingsRead = "embeddings:read"
+)
+
+// ServiceCredential is an API key used by internal services, such as the face
+// recognition service, to call the backend. Only a hash of the key is stored.
+type ServiceCredential struct {
+	ID         string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Name       string     `gorm:"not null;type:varchar(100)" json:"name"`
+	KeyPrefix  string     `gorm:"uniqueIndex;not null;type:varchar(20)" json:"key_prefix"` // identifies the key in logs and lookups
+	KeyHash    string     `gorm:"not null;type:varchar(64)" json:"-"`                      // hex SHA-256 of the key
+	Scopes     string     `gorm:"type:varchar(255)" json:"scopes"`                         // comma separated
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RotatedTo  string     `gorm:"type:varchar(36)" json:"rotated_to,omitempty"` // credential that replaced this one
+	CreatedBy  string     `gorm:"type:varchar(36)" json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// HasScope reports whether the credential was granted the scope.
+func (c *ServiceCredential) HasScope(scope string) bool {
+	for _, granted := range strings.Split(c.Scopes, ",") {
+		if strings.TrimSpace(granted) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive reports whether the credential may be used at the given time.
+func (c *ServiceCredential) IsActive(at time.Time) bool {
+	if c.RevokedAt != nil {
+		return false
+	}
+	return c.ExpiresAt == nil || at.Before(*c.ExpiresAt)
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Shift struct {
+	ID                 string         `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Name               string         `gorm:"not null;type:varchar(100)" json:"name"`
+	StartTime          string         `gorm:"not null;type:varchar(5)" json:"start_time"` // HH:MM, local time
+	EndTime            string         `gorm:"not null;type:varchar(5)" json:"end_time"`   // HH:MM, local time
+	GracePeriodMinutes int            `gorm:"type:int;default:0" json:"grace_period_minutes"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
+}
@@ -7,18 +7,23 @@ import (
 )
 
 type User struct {
-	ID             string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
-	EmployeeID     string    `gorm:"uniqueIndex;not null;type:varchar(50)" json:"employee_id"`
-	Name           string    `gorm:"not null;type:varchar(255)" json:"name"`
-	Email          string    `gorm:"uniqueIndex;not null;type:varchar(255)" json:"email"`
-	Password       string    `gorm:"not null;type:varchar(255)" json:"-"`
-	Position       string    `gorm:"type:varchar(100)" json:"position"`
-	ProfilePhotoURL string   `gorm:"type:varchar(500)" json:"profile_photo_url"`
-	CompanyID      string    `gorm:"type:varchar(36)" json:"company_id"`
-	CompanyName    string    `gorm:"type:varchar(255)" json:"company_name"`
-	FaceEmbeddingID string   `gorm:"type:varchar(36)" json:"face_embedding_id"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
-	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                       string         `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	EmployeeID               string         `gorm:"uniqueIndex;not null;type:varchar(50)" json:"employee_id"`
+	Name                     string         `gorm:"not null;type:varchar(255)" json:"name"`
+	Email                    string         `gorm:"uniqueIndex;not null;type:varchar(255)" json:"email"`
+	Password                 string         `gorm:"not null;type:varchar(255)" json:"-"`
+	Position                 string         `gorm:"type:varchar(100)" json:"position"`
+	ProfilePhotoURL          string         `gorm:"type:varchar(500)" json:"profile_photo_url"`
+	CompanyID                string         `gorm:"type:varchar(36)" json:"company_id"`
+	CompanyName              string         `gorm:"type:varchar(255)" json:"company_name"`
+	FaceEmbeddingID          string         `gorm:"type:varchar(36)" json:"face_embedding_id"`
+	FaceReenrollmentRequired bool           `gorm:"default:false" json:"face_reenrollment_required"` // no embedding from the active face model
+	ShiftID                  string         `gorm:"type:varchar(36);index" json:"shift_id"`
+	ShiftAssignedAt          *time.Time     `json:"shift_assigned_at"`    // first shift assignment, no absences are reported before it
+	FaceMatchThreshold       *float64       `json:"face_match_threshold"` // overrides the site and default threshold
+	Role                     string         `gorm:"type:varchar(20);not null;default:employee" json:"role"`
+	Permissions              string         `gorm:"type:varchar(255)" json:"permissions"` // comma separated, granted on top of the role
+	CreatedAt                time.Time      `json:"created_at"`
+	UpdatedAt                time.Time      `json:"updated_at"`
+	DeletedAt                gorm.DeletedAt `gorm:"index" json:"-"`
 }
-
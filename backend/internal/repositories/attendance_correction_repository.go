@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"face-verification-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AttendanceCorrectionRepository interface {
+	Create(correction *models.AttendanceCorrection) error
+	FindByID(id string) (*models.AttendanceCorrection, error)
+	FindByUserID(userID string) ([]*models.AttendanceCorrection, error)
+	FindByStatus(status string) ([]*models.AttendanceCorrection, error)
+	FindPendingByUserIDAndWorkDate(userID string, workDate time.Time) (*models.AttendanceCorrection, error)
+	Reject(correction *models.AttendanceCorrection) (bool, error)
+	Approve(correction *models.AttendanceCorrection, attendance *models.Attendance, isNew bool, punches []*models.AttendancePunch, history *models.AttendanceCorrectionHistory) (bool, error)
+	FindHistoryByAttendanceID(attendanceID string) ([]*models.AttendanceCorrectionHistory, error)
+}
+
+type attendanceCorrectionRepository struct {
+	db *gorm.DB
+}
+
+func NewAttendanceCorrectionRepository(db *gorm.DB) AttendanceCorrectionRepository {
+	return &attendanceCorrectionRepository{db: db}
+}
+
+func (r *attendanceCorrectionRepository) Create(correction *models.AttendanceCorrection) error {
+	return r.db.Create(correction).Error
+}
+
+func (r *attendanceCorrectionRepository) FindByID(id string) (*models.AttendanceCorrection, error) {
+	var correction models.AttendanceCorrection
+	if err := r.db.Where("id = ?", id).First(&correction).Error; err != nil {
+		return nil, err
+	}
+	return &correction, nil
+}
+
+func (r *attendanceCorrectionRepository) FindByUserID(userID string) ([]*models.AttendanceCorrection, error) {
+	var corrections []*models.AttendanceCorrection
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&corrections).Error; err != nil {
+		return nil, err
+	}
+	return corrections, nil
+}
+
+func (r *attendanceCorrectionRepository) FindByStatus(status string) ([]*models.AttendanceCorrection, error) {
+	var corrections []*models.AttendanceCorrection
+	query := r.db
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("created_at ASC").Find(&corrections).Error; err != nil {
+		return nil, err
+	}
+	return corrections, nil
+}
+
+func (r *attendanceCorrectionRepository) FindPendingByUserIDAndWorkDate(userID string, workDate time.Time) (*models.AttendanceCorrection, error) {
+	var correction models.AttendanceCorrection
+	if err := r.db.Where("user_id = ? AND work_date = ? AND status = ?", userID, workDate.Format("2006-01-02"), models.CorrectionStatusPending).First(&correction).Error; err != nil {
+		return nil, err
+	}
+	return &correction, nil
+}
+
+// Reject stores the review of a pending correction. It returns false, changing
+// nothing, when the correction is no longer pending.
+func (r *attendanceCorrectionRepository) Reject(correction *models.AttendanceCorrection) (bool, error) {
+	return closeCorrection(r.db, correction)
+}
+
+// Approve stores the approved correction, the corrected attendance and punches
+// and the history entry in one transaction, so a failure leaves none of them
+// applied. It returns false, changing nothing, when the correction is no longer
+// pending.
+func (r *attendanceCorrectionRepository) Approve(correction *models.AttendanceCorrection, attendance *models.Attendance, isNew bool, punches []*models.AttendancePunch, history *models.AttendanceCorrectionHistory) (bool, error) {
+	approved := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		closed, err := closeCorrection(tx, correction)
+		if err != nil || !closed {
+			return err
+		}
+		if isNew {
+			if err := tx.Omit(clause.Associations).Create(attendance).Error; err != nil {
+				return translateDuplicate(err)
+			}
+		} else if err := tx.Omit(clause.Associations).Save(attendance).Error; err != nil {
+			return err
+		}
+		for _, punch := range punches {
+			if err := tx.Save(punch).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+		approved = true
+		return nil
+	})
+	return approved, err
+}
+
+// closeCorrection moves a pending correction to its reviewed status, reporting
+// whether it was still pending.
+func closeCorrection(db *gorm.DB, correction *models.AttendanceCorrection) (bool, error) {
+	result := db.Model(&models.AttendanceCorrection{}).
+		Where("id = ? AND status = ?", correction.ID, models.CorrectionStatusPending).
+		Updates(map[string]interface{}{
+			"status":        correction.Status,
+			"attendance_id": correction.AttendanceID,
+			"reviewer_id":   correction.ReviewerID,
+			"review_note":   correction.ReviewNote,
+			"reviewed_at":   correction.ReviewedAt,
+			"updated_at":    correction.UpdatedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *attendanceCorrectionRepository) FindHistoryByAttendanceID(attendanceID string) ([]*models.AttendanceCorrectionHistory, error) {
+	var history []*models.AttendanceCorrectionHistory
+	if err := r.db.Where("attendance_id = ?", attendanceID).Order("created_at ASC").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
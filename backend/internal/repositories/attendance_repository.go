@@ -5,14 +5,18 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AttendanceRepository interface {
 	Create(attendance *models.Attendance) error
 	FindByID(id string) (*models.Attendance, error)
-	FindTodayByUserID(userID string) (*models.Attendance, error)
+	FindByUserIDAndWorkDate(userID string, workDate time.Time) (*models.Attendance, error)
 	FindByUserIDAndDateRange(userID string, startDate, endDate time.Time) ([]*models.Attendance, error)
+	FindOpen() ([]*models.Attendance, error)
+	FindWithOvertimeByDateRange(userID string, startDate, endDate time.Time) ([]*models.Attendance, error)
 	Update(attendance *models.Attendance) error
+	CreatePunch(punch *models.AttendancePunch) error
 }
 
 type attendanceRepository struct {
@@ -23,25 +27,23 @@ func NewAttendanceRepository(db *gorm.DB) AttendanceRepository {
 	return &attendanceRepository{db: db}
 }
 
+// Create fails with ErrDuplicate when the user already has an attendance on
+// the workday.
 func (r *attendanceRepository) Create(attendance *models.Attendance) error {
-	return r.db.Create(attendance).Error
+	return translateDuplicate(r.db.Create(attendance).Error)
 }
 
 func (r *attendanceRepository) FindByID(id string) (*models.Attendance, error) {
 	var attendance models.Attendance
-	if err := r.db.Where("id = ?", id).First(&attendance).Error; err != nil {
+	if err := r.withPunches().Where("id = ?", id).First(&attendance).Error; err != nil {
 		return nil, err
 	}
 	return &attendance, nil
 }
 
-func (r *attendanceRepository) FindTodayByUserID(userID string) (*models.Attendance, error) {
+func (r *attendanceRepository) FindByUserIDAndWorkDate(userID string, workDate time.Time) (*models.Attendance, error) {
 	var attendance models.Attendance
-	now := time.Now()
-	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
-	
-	if err := r.db.Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, startOfDay, endOfDay).First(&attendance).Error; err != nil {
+	if err := r.withPunches().Where("user_id = ? AND work_date = ?", userID, workDate.Format("2006-01-02")).First(&attendance).Error; err != nil {
 		return nil, err
 	}
 	return &attendance, nil
@@ -49,13 +51,45 @@ func (r *attendanceRepository) FindTodayByUserID(userID string) (*models.Attenda
 
 func (r *attendanceRepository) FindByUserIDAndDateRange(userID string, startDate, endDate time.Time) ([]*models.Attendance, error) {
 	var attendances []*models.Attendance
-	if err := r.db.Where("user_id = ? AND created_at >= ? AND created_at <= ?", userID, startDate, endDate).Find(&attendances).Error; err != nil {
+	if err := r.withPunches().Where("user_id = ? AND work_date >= ? AND work_date <= ?", userID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).Order("work_date ASC").Find(&attendances).Error; err != nil {
+		return nil, err
+	}
+	return attendances, nil
+}
+
+// FindOpen returns attendance with a session that has not been clocked out
+func (r *attendanceRepository) FindOpen() ([]*models.Attendance, error) {
+	var attendances []*models.Attendance
+	if err := r.withPunches().Where("clock_in IS NOT NULL AND clock_out IS NULL").Find(&attendances).Error; err != nil {
+		return nil, err
+	}
+	return attendances, nil
+}
+
+// FindWithOvertimeByDateRange returns attendance with overtime in the range, of
+// every user when userID is empty
+func (r *attendanceRepository) FindWithOvertimeByDateRange(userID string, startDate, endDate time.Time) ([]*models.Attendance, error) {
+	var attendances []*models.Attendance
+	query := r.db.Where("overtime_minutes > 0 AND work_date >= ? AND work_date <= ?", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if err := query.Order("work_date ASC").Find(&attendances).Error; err != nil {
 		return nil, err
 	}
 	return attendances, nil
 }
 
 func (r *attendanceRepository) Update(attendance *models.Attendance) error {
-	return r.db.Save(attendance).Error
+	return r.db.Omit(clause.Associations).Save(attendance).Error
+}
+
+func (r *attendanceRepository) CreatePunch(punch *models.AttendancePunch) error {
+	return r.db.Create(punch).Error
 }
 
+func (r *attendanceRepository) withPunches() *gorm.DB {
+	return r.db.Preload("Punches", func(db *gorm.DB) *gorm.DB {
+		return db.Order("punched_at ASC")
+	})
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"face-verification-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AuthSessionRepository interface {
+	Create(session *models.AuthSession) error
+	FindByID(id string) (*models.AuthSession, error)
+	FindByRefreshTokenHash(hash string) (*models.AuthSession, error)
+	FindByPreviousTokenHash(hash string) (*models.AuthSession, error)
+	// FindActiveByUserID returns the sessions of the user that are neither
+	// revoked nor expired at the given time, most recent first.
+	FindActiveByUserID(userID string, at time.Time) ([]*models.AuthSession, error)
+	Update(session *models.AuthSession) error
+	// Rotate stores the session's new refresh token if its current one is
+	// still previousHash and it is not revoked. It reports whether it did, so
+	// only one of several requests with the same token wins.
+	Rotate(session *models.AuthSession, previousHash string) (bool, error)
+	RevokeAllByUserID(userID string, at time.Time) (int64, error)
+	// RevokeOthersByUserID revokes every session of the user but keepID.
+	RevokeOthersByUserID(userID, keepID string, at time.Time) (int64, error)
+	// DeleteExpired removes sessions that expired or were revoked before the given time.
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type authSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthSessionRepository(db *gorm.DB) AuthSessionRepository {
+	return &authSessionRepository{db: db}
+}
+
+func (r *authSessionRepository) Create(session *models.AuthSession) error {
+	return r.db.Create(session).Error
+}
+
+func (r *authSessionRepository) FindByID(id string) (*models.AuthSession, error) {
+	var session models.AuthSession
+	if err := r.db.Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *authSessionRepository) FindByRefreshTokenHash(hash string) (*models.AuthSession, error) {
+	var session models.AuthSession
+	if err := r.db.Where("refresh_token_hash = ?", hash).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *authSessionRepository) FindByPreviousTokenHash(hash string) (*models.AuthSession, error) {
+	var session models.AuthSession
+	if err := r.db.Where("previous_token_hash = ?", hash).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *authSessionRepository) FindActiveByUserID(userID string, at time.Time) ([]*models.AuthSession, error) {
+	var sessions []*models.AuthSession
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, at).
+		Order("created_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *authSessionRepository) Update(session *models.AuthSession) error {
+	return r.db.Save(session).Error
+}
+
+func (r *authSessionRepository) Rotate(session *models.AuthSession, previousHash string) (bool, error) {
+	result := r.db.Model(&models.AuthSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, previousHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  session.RefreshTokenHash,
+			"previous_token_hash": session.PreviousTokenHash,
+			"expires_at":          session.ExpiresAt,
+			"last_refreshed_at":   session.LastRefreshedAt,
+			"user_agent":          session.UserAgent,
+			"client_ip":           session.ClientIP,
+			"updated_at":          session.UpdatedAt,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *authSessionRepository) RevokeAllByUserID(userID string, at time.Time) (int64, error) {
+	result := r.db.Model(&models.AuthSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{"revoked_at": at, "updated_at": at})
+	return result.RowsAffected, result.Error
+}
+
+func (r *authSessionRepository) RevokeOthersByUserID(userID, keepID string, at time.Time) (int64, error) {
+	result := r.db.Model(&models.AuthSession{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepID).
+		Updates(map[string]interface{}{"revoked_at": at, "updated_at": at})
+	return result.RowsAffected, result.Error
+}
+
+func (r *authSessionRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ? OR revoked_at < ?", before, before).Delete(&models.AuthSession{})
+	return result.RowsAffected, result.Error
+}
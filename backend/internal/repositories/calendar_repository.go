@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"face-verification-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CalendarRepository interface {
+	CreateHoliday(holiday *models.Holiday) error
+	FindHolidayByID(id string) (*models.Holiday, error)
+	FindHolidaysByDateRange(startDate, endDate time.Time) ([]*models.Holiday, error)
+	ExistsHolidayByExternalUID(externalUID string, date time.Time) (bool, error)
+	DeleteHoliday(id string) error
+	FindWorkingDayRules() ([]*models.WorkingDayRule, error)
+	ReplaceWorkingDayRules(rules []*models.WorkingDayRule) error
+}
+
+type calendarRepository struct {
+	db *gorm.DB
+}
+
+func NewCalendarRepository(db *gorm.DB) CalendarRepository {
+	return &calendarRepository{db: db}
+}
+
+func (r *calendarRepository) CreateHoliday(holiday *models.Holiday) error {
+	return r.db.Create(holiday).Error
+}
+
+func (r *calendarRepository) FindHolidayByID(id string) (*models.Holiday, error) {
+	var holiday models.Holiday
+	if err := r.db.Where("id = ?", id).First(&holiday).Error; err != nil {
+		return nil, err
+	}
+	return &holiday, nil
+}
+
+func (r *calendarRepository) FindHolidaysByDateRange(startDate, endDate time.Time) ([]*models.Holiday, error) {
+	var holidays []*models.Holiday
+	if err := r.db.Where("date >= ? AND date <= ?", startDate.Format("2006-01-02"), endDate.Format("2006-01-02")).Order("date ASC").Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+func (r *calendarRepository) ExistsHolidayByExternalUID(externalUID string, date time.Time) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.Holiday{}).Where("external_uid = ? AND date = ?", externalUID, date.Format("2006-01-02")).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *calendarRepository) DeleteHoliday(id string) error {
+	return r.db.Delete(&models.Holiday{}, "id = ?", id).Error
+}
+
+func (r *calendarRepository) FindWorkingDayRules() ([]*models.WorkingDayRule, error) {
+	var rules []*models.WorkingDayRule
+	if err := r.db.Order("weekday ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *calendarRepository) ReplaceWorkingDayRules(rules []*models.WorkingDayRule) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.WorkingDayRule{}).Error; err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			if err := tx.Create(rule).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"face-verification-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EmbeddingAuditFilter narrows an audit query. Zero fields are not filtered on.
+type EmbeddingAuditFilter struct {
+	UserID  string
+	ActorID string
+	Action  string
+	From    time.Time
+	To      time.Time
+	Limit   int
+}
+
+type EmbeddingAuditRepository interface {
+	Create(entry *models.EmbeddingAuditEntry) error
+	Find(filter EmbeddingAuditFilter) ([]*models.EmbeddingAuditEntry, error)
+}
+
+type embeddingAuditRepository struct {
+	db *gorm.DB
+}
+
+func NewEmbeddingAuditRepository(db *gorm.DB) EmbeddingAuditRepository {
+	return &embeddingAuditRepository{db: db}
+}
+
+func (r *embeddingAuditRepository) Create(entry *models.EmbeddingAuditEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *embeddingAuditRepository) Find(filter EmbeddingAuditFilter) ([]*models.EmbeddingAuditEntry, error) {
+	var entries []*models.EmbeddingAuditEntry
+	query := r.db
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if err := query.Order("created_at DESC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
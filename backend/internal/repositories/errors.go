@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ErrDuplicate is returned when a write violates a unique index.
+var ErrDuplicate = errors.New("duplicate record")
+
+// mysqlDuplicateEntry is the MySQL error number of a unique index violation.
+const mysqlDuplicateEntry = 1062
+
+// translateDuplicate turns a unique index violation into ErrDuplicate.
+func translateDuplicate(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return ErrDuplicate
+	}
+	return err
+}
@@ -1,7 +1,9 @@
 package repositories
 
 import (
+	"face-verification-backend/internal/encryption"
 	"face-verification-backend/internal/models"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,18 +13,33 @@ import (
 type FaceEmbeddingRepository interface {
 	Create(embedding *models.FaceEmbedding) error
 	FindByUserID(userID string) (*models.FaceEmbedding, error)
+	FindAllByUserID(userID string) ([]*models.FaceEmbedding, error)
+	FindAllByUserIDAndModel(userID, modelName, modelVersion string) ([]*models.FaceEmbedding, error)
+	FindAllByUserIDsAndModel(userIDs []string, modelName, modelVersion string) ([]*models.FaceEmbedding, error)
+	FindUnversioned() ([]*models.FaceEmbedding, error)
+	FindUserIDs() ([]string, error)
+	FindUserIDsByModel(modelName, modelVersion string) ([]string, error)
 	FindByID(id string) (*models.FaceEmbedding, error)
+	CountByUserID(userID string) (int64, error)
 	Update(embedding *models.FaceEmbedding) error
-	UpsertByUserID(userID string, embeddingData string) (*models.FaceEmbedding, error)
+	// Activate makes pending embeddings usable for verification
+	Activate(ids []string) error
+	Delete(id string) error
 	DeleteByUserID(userID string) error
+	// ReencryptAll rewrites every embedding not encrypted with the active key
+	// and returns how many were rewritten
+	ReencryptAll(batchSize int) (int, error)
 }
 
 type faceEmbeddingRepository struct {
-	db *gorm.DB
+	db      *gorm.DB
+	keyring *encryption.Keyring
 }
 
-func NewFaceEmbeddingRepository(db *gorm.DB) FaceEmbeddingRepository {
-	return &faceEmbeddingRepository{db: db}
+// NewFaceEmbeddingRepository stores embeddings encrypted with the keyring and
+// decrypts them when read. Without a keyring they are stored in plain text.
+func NewFaceEmbeddingRepository(db *gorm.DB, keyring *encryption.Keyring) FaceEmbeddingRepository {
+	return &faceEmbeddingRepository{db: db, keyring: keyring}
 }
 
 func (r *faceEmbeddingRepository) Create(embedding *models.FaceEmbedding) error {
@@ -35,61 +52,233 @@ func (r *faceEmbeddingRepository) Create(embedding *models.FaceEmbedding) error
 	if embedding.UpdatedAt.IsZero() {
 		embedding.UpdatedAt = time.Now()
 	}
-	return r.db.Create(embedding).Error
+	if embedding.CapturedAt.IsZero() {
+		embedding.CapturedAt = embedding.CreatedAt
+	}
+	row, err := r.seal(embedding)
+	if err != nil {
+		return err
+	}
+	return r.db.Create(row).Error
 }
 
+// FindByUserID returns the user's most recently captured embedding
 func (r *faceEmbeddingRepository) FindByUserID(userID string) (*models.FaceEmbedding, error) {
 	var embedding models.FaceEmbedding
-	if err := r.db.Where("user_id = ?", userID).First(&embedding).Error; err != nil {
+	if err := r.active().Where("user_id = ?", userID).Order("captured_at DESC").First(&embedding).Error; err != nil {
+		return nil, err
+	}
+	if err := r.open(&embedding); err != nil {
 		return nil, err
 	}
 	return &embedding, nil
 }
 
+func (r *faceEmbeddingRepository) FindAllByUserID(userID string) ([]*models.FaceEmbedding, error) {
+	var embeddings []*models.FaceEmbedding
+	if err := r.active().Where("user_id = ?", userID).Order("captured_at DESC").Find(&embeddings).Error; err != nil {
+		return nil, err
+	}
+	return r.openAll(embeddings)
+}
+
+func (r *faceEmbeddingRepository) FindAllByUserIDAndModel(userID, modelName, modelVersion string) ([]*models.FaceEmbedding, error) {
+	var embeddings []*models.FaceEmbedding
+	if err := r.active().Where("user_id = ? AND model_name = ? AND model_version = ?", userID, modelName, modelVersion).
+		Order("captured_at DESC").Find(&embeddings).Error; err != nil {
+		return nil, err
+	}
+	return r.openAll(embeddings)
+}
+
+func (r *faceEmbeddingRepository) FindAllByUserIDsAndModel(userIDs []string, modelName, modelVersion string) ([]*models.FaceEmbedding, error) {
+	var embeddings []*models.FaceEmbedding
+	if len(userIDs) == 0 {
+		return embeddings, nil
+	}
+	if err := r.active().Where("user_id IN ? AND model_name = ? AND model_version = ?", userIDs, modelName, modelVersion).
+		Find(&embeddings).Error; err != nil {
+		return nil, err
+	}
+	return r.openAll(embeddings)
+}
+
+// FindUnversioned returns embeddings enrolled before the model was recorded
+func (r *faceEmbeddingRepository) FindUnversioned() ([]*models.FaceEmbedding, error) {
+	var embeddings []*models.FaceEmbedding
+	if err := r.db.Where("model_name IS NULL OR model_name = ''").Find(&embeddings).Error; err != nil {
+		return nil, err
+	}
+	return r.openAll(embeddings)
+}
+
+// FindUserIDs returns the users with at least one embedding
+func (r *faceEmbeddingRepository) FindUserIDs() ([]string, error) {
+	var userIDs []string
+	if err := r.active().Model(&models.FaceEmbedding{}).Distinct().Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// FindUserIDsByModel returns the users with at least one embedding from the model
+func (r *faceEmbeddingRepository) FindUserIDsByModel(modelName, modelVersion string) ([]string, error) {
+	var userIDs []string
+	if err := r.active().Model(&models.FaceEmbedding{}).Where("model_name = ? AND model_version = ?", modelName, modelVersion).
+		Distinct().Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
 func (r *faceEmbeddingRepository) FindByID(id string) (*models.FaceEmbedding, error) {
 	var embedding models.FaceEmbedding
 	if err := r.db.Where("id = ?", id).First(&embedding).Error; err != nil {
 		return nil, err
 	}
+	if err := r.open(&embedding); err != nil {
+		return nil, err
+	}
 	return &embedding, nil
 }
 
+func (r *faceEmbeddingRepository) CountByUserID(userID string) (int64, error) {
+	var count int64
+	if err := r.active().Model(&models.FaceEmbedding{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 func (r *faceEmbeddingRepository) Update(embedding *models.FaceEmbedding) error {
 	embedding.UpdatedAt = time.Now()
-	return r.db.Save(embedding).Error
+	row, err := r.seal(embedding)
+	if err != nil {
+		return err
+	}
+	return r.db.Save(row).Error
 }
 
-// UpsertByUserID creates or updates embedding for a user
-func (r *faceEmbeddingRepository) UpsertByUserID(userID string, embeddingData string) (*models.FaceEmbedding, error) {
-	var embedding models.FaceEmbedding
-	err := r.db.Where("user_id = ?", userID).First(&embedding).Error
-
-	if err == gorm.ErrRecordNotFound {
-		// Create new
-		embedding = models.FaceEmbedding{
-			ID:        uuid.New().String(),
-			UserID:    userID,
-			Embedding: embeddingData,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+func (r *faceEmbeddingRepository) Activate(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.FaceEmbedding{}).Where("id IN ?", ids).
+		Updates(map[string]interface{}{"pending": false, "updated_at": time.Now()}).Error
+}
+
+func (r *faceEmbeddingRepository) Delete(id string) error {
+	return r.db.Unscoped().Where("id = ?", id).Delete(&models.FaceEmbedding{}).Error
+}
+
+func (r *faceEmbeddingRepository) DeleteByUserID(userID string) error {
+	return r.db.Unscoped().Where("user_id = ?", userID).Delete(&models.FaceEmbedding{}).Error
+}
+
+// active scopes a query to embeddings used for verification, leaving out the
+// pending ones of enrollments not approved yet
+func (r *faceEmbeddingRepository) active() *gorm.DB {
+	return r.db.Where("pending = ?", false)
+}
+
+func (r *faceEmbeddingRepository) ReencryptAll(batchSize int) (int, error) {
+	if r.keyring == nil {
+		return 0, fmt.Errorf("no embedding encryption key is configured")
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	rewritten := 0
+	lastID := ""
+	for {
+		var rows []*models.FaceEmbedding
+		err := r.db.
+			Where("id > ? AND (encryption_key_id IS NULL OR encryption_key_id <> ?)", lastID, r.keyring.ActiveKeyID()).
+			Order("id ASC").Limit(batchSize).Find(&rows).Error
+		if err != nil {
+			return rewritten, err
 		}
-		if err := r.db.Create(&embedding).Error; err != nil {
-			return nil, err
+		if len(rows) == 0 {
+			return rewritten, nil
+		}
+
+		for _, embedding := range rows {
+			lastID = embedding.ID
+			if err := r.open(embedding); err != nil {
+				return rewritten, err
+			}
+			row, err := r.seal(embedding)
+			if err != nil {
+				return rewritten, err
+			}
+			err = r.db.Model(&models.FaceEmbedding{}).Where("id = ?", embedding.ID).Updates(map[string]interface{}{
+				"embedding":          row.Embedding,
+				"encryption_key_id":  row.EncryptionKeyID,
+				"encrypted_data_key": row.EncryptedDataKey,
+			}).Error
+			if err != nil {
+				return rewritten, err
+			}
+			rewritten++
 		}
-		return &embedding, nil
-	} else if err != nil {
-		return nil, err
 	}
+}
 
-	// Update existing
-	embedding.Embedding = embeddingData
-	embedding.UpdatedAt = time.Now()
-	if err := r.db.Save(&embedding).Error; err != nil {
-		return nil, err
+// seal returns a copy of the embedding to store, with the vector encrypted
+// when a keyring is configured
+func (r *faceEmbeddingRepository) seal(embedding *models.FaceEmbedding) (*models.FaceEmbedding, error) {
+	row := *embedding
+	row.EncryptionKeyID = ""
+	row.EncryptedDataKey = ""
+	if r.keyring == nil {
+		return &row, nil
 	}
-	return &embedding, nil
+
+	envelope, err := r.keyring.Seal([]byte(embedding.Embedding), embeddingAdditionalData(embedding))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt embedding: %w", err)
+	}
+	row.Embedding = envelope.Ciphertext
+	row.EncryptionKeyID = envelope.KeyID
+	row.EncryptedDataKey = envelope.WrappedKey
+	return &row, nil
 }
 
-func (r *faceEmbeddingRepository) DeleteByUserID(userID string) error {
-	return r.db.Where("user_id = ?", userID).Delete(&models.FaceEmbedding{}).Error
+// open decrypts a stored embedding in place. Embeddings stored before
+// encryption was enabled are left as they are.
+func (r *faceEmbeddingRepository) open(embedding *models.FaceEmbedding) error {
+	if embedding.EncryptionKeyID == "" {
+		return nil
+	}
+	if r.keyring == nil {
+		return fmt.Errorf("embedding %s is encrypted but no encryption key is configured", embedding.ID)
+	}
+
+	plaintext, err := r.keyring.Open(encryption.Envelope{
+		KeyID:      embedding.EncryptionKeyID,
+		WrappedKey: embedding.EncryptedDataKey,
+		Ciphertext: embedding.Embedding,
+	}, embeddingAdditionalData(embedding))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt embedding %s: %w", embedding.ID, err)
+	}
+	embedding.Embedding = string(plaintext)
+	embedding.EncryptionKeyID = ""
+	embedding.EncryptedDataKey = ""
+	return nil
+}
+
+func (r *faceEmbeddingRepository) openAll(embeddings []*models.FaceEmbedding) ([]*models.FaceEmbedding, error) {
+	for _, embedding := range embeddings {
+		if err := r.open(embedding); err != nil {
+			return nil, err
+		}
+	}
+	return embeddings, nil
+}
+
+// embeddingAdditionalData binds the ciphertext to its row and owner
+func embeddingAdditionalData(embedding *models.FaceEmbedding) []byte {
+	return []byte(embedding.ID + "/" + embedding.UserID)
 }
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"face-verification-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FaceEnrollmentRepository interface {
+	Create(enrollment *models.FaceEnrollment) error
+	FindByID(id string) (*models.FaceEnrollment, error)
+	FindByUserID(userID string) ([]*models.FaceEnrollment, error)
+	// FindByStatus returns enrollments with the status, all of them when it is empty
+	FindByStatus(status string) ([]*models.FaceEnrollment, error)
+	// FindUnfinishedByUserID returns the user's open or pending enrollment
+	FindUnfinishedByUserID(userID string) (*models.FaceEnrollment, error)
+	Update(enrollment *models.FaceEnrollment) error
+	// UpdateReview saves the status and review of the enrollment if its stored
+	// status is still one of from, and reports whether it did
+	UpdateReview(enrollment *models.FaceEnrollment, from ...string) (bool, error)
+	CreateCapture(capture *models.FaceEnrollmentCapture) error
+}
+
+type faceEnrollmentRepository struct {
+	db *gorm.DB
+}
+
+func NewFaceEnrollmentRepository(db *gorm.DB) FaceEnrollmentRepository {
+	return &faceEnrollmentRepository{db: db}
+}
+
+func (r *faceEnrollmentRepository) Create(enrollment *models.FaceEnrollment) error {
+	return r.db.Omit(clause.Associations).Create(enrollment).Error
+}
+
+func (r *faceEnrollmentRepository) FindByID(id string) (*models.FaceEnrollment, error) {
+	var enrollment models.FaceEnrollment
+	if err := r.withCaptures().Where("id = ?", id).First(&enrollment).Error; err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+func (r *faceEnrollmentRepository) FindByUserID(userID string) ([]*models.FaceEnrollment, error) {
+	var enrollments []*models.FaceEnrollment
+	if err := r.withCaptures().Where("user_id = ?", userID).Order("created_at DESC").Find(&enrollments).Error; err != nil {
+		return nil, err
+	}
+	return enrollments, nil
+}
+
+func (r *faceEnrollmentRepository) FindByStatus(status string) ([]*models.FaceEnrollment, error) {
+	var enrollments []*models.FaceEnrollment
+	query := r.withCaptures()
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("created_at ASC").Find(&enrollments).Error; err != nil {
+		return nil, err
+	}
+	return enrollments, nil
+}
+
+func (r *faceEnrollmentRepository) FindUnfinishedByUserID(userID string) (*models.FaceEnrollment, error) {
+	var enrollment models.FaceEnrollment
+	err := r.withCaptures().
+		Where("user_id = ? AND status IN ?", userID, []string{models.EnrollmentStatusOpen, models.EnrollmentStatusPending}).
+		First(&enrollment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+func (r *faceEnrollmentRepository) Update(enrollment *models.FaceEnrollment) error {
+	return r.db.Omit(clause.Associations).Save(enrollment).Error
+}
+
+func (r *faceEnrollmentRepository) UpdateReview(enrollment *models.FaceEnrollment, from ...string) (bool, error) {
+	result := r.db.Model(&models.FaceEnrollment{}).
+		Where("id = ? AND status IN ?", enrollment.ID, from).
+		Updates(map[string]interface{}{
+			"status":      enrollment.Status,
+			"reviewed_by": enrollment.ReviewedBy,
+			"reviewed_at": enrollment.ReviewedAt,
+			"review_note": enrollment.ReviewNote,
+			"updated_at":  enrollment.UpdatedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *faceEnrollmentRepository) CreateCapture(capture *models.FaceEnrollmentCapture) error {
+	return r.db.Create(capture).Error
+}
+
+func (r *faceEnrollmentRepository) withCaptures() *gorm.DB {
+	return r.db.Preload("Captures", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	})
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"face-verification-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FaceVerificationAttemptFilter narrows an attempt query. Zero fields are not filtered on.
+type FaceVerificationAttemptFilter struct {
+	UserID  string
+	Outcome string
+	From    time.Time
+	To      time.Time
+	Limit   int
+}
+
+// ThresholdCount aggregates the compared attempts made at one threshold from
+// one threshold source.
+type ThresholdCount struct {
+	Threshold       float64
+	ThresholdSource string
+	Attempts        int
+	Verified        int
+	Mismatches      int
+	FalseRejects    int
+}
+
+type FaceVerificationAttemptRepository interface {
+	Create(attempt *models.FaceVerificationAttempt) error
+	UpdatePhoto(id, photo string) error
+	Find(filter FaceVerificationAttemptFilter) ([]*models.FaceVerificationAttempt, error)
+	// CountCompared aggregates the verified and mismatch attempts per threshold,
+	// rounded to 3 decimals, and source. A mismatch is a false reject when the
+	// same user was verified for the same punch within retryWindow after it.
+	CountCompared(from, to time.Time, retryWindow time.Duration) ([]ThresholdCount, error)
+}
+
+type faceVerificationAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewFaceVerificationAttemptRepository(db *gorm.DB) FaceVerificationAttemptRepository {
+	return &faceVerificationAttemptRepository{db: db}
+}
+
+func (r *faceVerificationAttemptRepository) Create(attempt *models.FaceVerificationAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+func (r *faceVerificationAttemptRepository) UpdatePhoto(id, photo string) error {
+	return r.db.Model(&models.FaceVerificationAttempt{}).Where("id = ?", id).Update("photo", photo).Error
+}
+
+func (r *faceVerificationAttemptRepository) Find(filter FaceVerificationAttemptFilter) ([]*models.FaceVerificationAttempt, error) {
+	var attempts []*models.FaceVerificationAttempt
+	query := r.db
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("attempted_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("attempted_at < ?", filter.To)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if err := query.Order("attempted_at DESC").Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (r *faceVerificationAttemptRepository) CountCompared(from, to time.Time, retryWindow time.Duration) ([]ThresholdCount, error) {
+	var counts []ThresholdCount
+	query := r.db.Table("face_verification_attempts AS a").
+		Select(`ROUND(a.threshold, 3) AS threshold, a.threshold_source AS threshold_source,
+			COUNT(*) AS attempts,
+			SUM(a.outcome = ?) AS verified,
+			SUM(a.outcome = ?) AS mismatches,
+			SUM(a.outcome = ? AND EXISTS (
+				SELECT 1 FROM face_verification_attempts AS r
+				WHERE r.user_id = a.user_id AND r.punch_type = a.punch_type AND r.outcome = ?
+					AND r.attempted_at > a.attempted_at
+					AND r.attempted_at <= a.attempted_at + INTERVAL ? SECOND
+			)) AS false_rejects`,
+			models.VerificationOutcomeVerified,
+			models.VerificationOutcomeMismatch,
+			models.VerificationOutcomeMismatch, models.VerificationOutcomeVerified,
+			int(retryWindow.Seconds())).
+		Where("a.outcome IN ? AND a.threshold IS NOT NULL", []string{models.VerificationOutcomeVerified, models.VerificationOutcomeMismatch})
+	if !from.IsZero() {
+		query = query.Where("a.attempted_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("a.attempted_at < ?", to)
+	}
+	if err := query.Group("ROUND(a.threshold, 3), a.threshold_source").Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
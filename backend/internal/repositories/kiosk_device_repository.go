@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"face-verification-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type KioskDeviceRepository interface {
+	Create(device *models.KioskDevice) error
+	FindByID(id string) (*models.KioskDevice, error)
+	FindByKeyPrefix(prefix string) (*models.KioskDevice, error)
+	FindAll() ([]*models.KioskDevice, error)
+	Update(device *models.KioskDevice) error
+	UpdateLastSeenAt(id string, at time.Time) error
+}
+
+type kioskDeviceRepository struct {
+	db *gorm.DB
+}
+
+func NewKioskDeviceRepository(db *gorm.DB) KioskDeviceRepository {
+	return &kioskDeviceRepository{db: db}
+}
+
+func (r *kioskDeviceRepository) Create(device *models.KioskDevice) error {
+	return r.db.Omit(clause.Associations).Create(device).Error
+}
+
+func (r *kioskDeviceRepository) FindByID(id string) (*models.KioskDevice, error) {
+	var device models.KioskDevice
+	if err := r.db.Preload("Office").Where("id = ?", id).First(&device).Error; err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+func (r *kioskDeviceRepository) FindByKeyPrefix(prefix string) (*models.KioskDevice, error) {
+	var device models.KioskDevice
+	if err := r.db.Preload("Office").Where("key_prefix = ?", prefix).First(&device).Error; err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+func (r *kioskDeviceRepository) FindAll() ([]*models.KioskDevice, error) {
+	var devices []*models.KioskDevice
+	if err := r.db.Preload("Office").Order("name ASC").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+func (r *kioskDeviceRepository) Update(device *models.KioskDevice) error {
+	return r.db.Omit(clause.Associations).Save(device).Error
+}
+
+func (r *kioskDeviceRepository) UpdateLastSeenAt(id string, at time.Time) error {
+	return r.db.Model(&models.KioskDevice{}).Where("id = ?", id).Update("last_seen_at", at).Error
+}
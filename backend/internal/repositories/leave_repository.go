@@ -0,0 +1,195 @@
+package repositories
+
+import (
+	"face-verification-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LeaveRepository interface {
+	CreateType(leaveType *models.LeaveType) error
+	FindAllTypes() ([]*models.LeaveType, error)
+	FindTypeByID(id string) (*models.LeaveType, error)
+	UpdateType(leaveType *models.LeaveType) error
+
+	CreateRequest(request *models.LeaveRequest) (bool, error)
+	FindRequestByID(id string) (*models.LeaveRequest, error)
+	FindRequestsByUserID(userID string) ([]*models.LeaveRequest, error)
+	FindRequestsByStatus(status string) ([]*models.LeaveRequest, error)
+	FindOverlappingRequests(userID string, startDate, endDate time.Time) ([]*models.LeaveRequest, error)
+	FindApprovedByUserIDAndDateRange(userID string, startDate, endDate time.Time) ([]*models.LeaveRequest, error)
+	CloseRequest(request *models.LeaveRequest) (bool, error)
+
+	FindBalance(userID, leaveTypeID string, year int) (*models.LeaveBalance, error)
+	FindBalancesByUserID(userID string, year int) ([]*models.LeaveBalance, error)
+	CreateBalance(balance *models.LeaveBalance) error
+}
+
+type leaveRepository struct {
+	db *gorm.DB
+}
+
+func NewLeaveRepository(db *gorm.DB) LeaveRepository {
+	return &leaveRepository{db: db}
+}
+
+func (r *leaveRepository) CreateType(leaveType *models.LeaveType) error {
+	return r.db.Create(leaveType).Error
+}
+
+func (r *leaveRepository) FindAllTypes() ([]*models.LeaveType, error) {
+	var leaveTypes []*models.LeaveType
+	if err := r.db.Order("name ASC").Find(&leaveTypes).Error; err != nil {
+		return nil, err
+	}
+	return leaveTypes, nil
+}
+
+func (r *leaveRepository) FindTypeByID(id string) (*models.LeaveType, error) {
+	var leaveType models.LeaveType
+	if err := r.db.Where("id = ?", id).First(&leaveType).Error; err != nil {
+		return nil, err
+	}
+	return &leaveType, nil
+}
+
+func (r *leaveRepository) UpdateType(leaveType *models.LeaveType) error {
+	return r.db.Save(leaveType).Error
+}
+
+// CreateRequest stores the request and reserves its days on its balance, if it
+// has one, in one transaction. It returns false, storing nothing, when the
+// balance no longer has the days remaining.
+func (r *leaveRepository) CreateRequest(request *models.LeaveRequest) (bool, error) {
+	reserved := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if request.BalanceID != "" {
+			result := tx.Model(&models.LeaveBalance{}).
+				Where("id = ? AND entitled_days - used_days - pending_days >= ?", request.BalanceID, request.Days).
+				Updates(map[string]interface{}{
+					"pending_days": gorm.Expr("pending_days + ?", request.Days),
+					"updated_at":   request.CreatedAt,
+				})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+		}
+		if err := tx.Omit(clause.Associations).Create(request).Error; err != nil {
+			return err
+		}
+		reserved = true
+		return nil
+	})
+	return reserved, err
+}
+
+func (r *leaveRepository) FindRequestByID(id string) (*models.LeaveRequest, error) {
+	var request models.LeaveRequest
+	if err := r.db.Preload("LeaveType").Where("id = ?", id).First(&request).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *leaveRepository) FindRequestsByUserID(userID string) ([]*models.LeaveRequest, error) {
+	var requests []*models.LeaveRequest
+	if err := r.db.Preload("LeaveType").Where("user_id = ?", userID).Order("start_date DESC").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+func (r *leaveRepository) FindRequestsByStatus(status string) ([]*models.LeaveRequest, error) {
+	var requests []*models.LeaveRequest
+	query := r.db.Preload("LeaveType")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("created_at ASC").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// FindOverlappingRequests returns pending or approved requests of the user that overlap the date range
+func (r *leaveRepository) FindOverlappingRequests(userID string, startDate, endDate time.Time) ([]*models.LeaveRequest, error) {
+	var requests []*models.LeaveRequest
+	if err := r.db.Where("user_id = ? AND status IN ? AND start_date <= ? AND end_date >= ?",
+		userID, []string{models.LeaveStatusPending, models.LeaveStatusApproved},
+		endDate.Format("2006-01-02"), startDate.Format("2006-01-02")).
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+func (r *leaveRepository) FindApprovedByUserIDAndDateRange(userID string, startDate, endDate time.Time) ([]*models.LeaveRequest, error) {
+	var requests []*models.LeaveRequest
+	if err := r.db.Preload("LeaveType").Where("user_id = ? AND status = ? AND start_date <= ? AND end_date >= ?",
+		userID, models.LeaveStatusApproved,
+		endDate.Format("2006-01-02"), startDate.Format("2006-01-02")).
+		Order("start_date ASC").
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// CloseRequest moves a pending request to its new status and releases the days
+// it reserved on its balance, if it has one, counting them as used if the
+// request was approved. Both happen in one transaction; false is returned,
+// changing nothing, when the request is no longer pending.
+func (r *leaveRepository) CloseRequest(request *models.LeaveRequest) (bool, error) {
+	closed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.LeaveRequest{}).
+			Where("id = ? AND status = ?", request.ID, models.LeaveStatusPending).
+			Updates(map[string]interface{}{
+				"status":      request.Status,
+				"reviewer_id": request.ReviewerID,
+				"review_note": request.ReviewNote,
+				"reviewed_at": request.ReviewedAt,
+				"updated_at":  request.UpdatedAt,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if request.BalanceID != "" {
+			updates := map[string]interface{}{
+				"pending_days": gorm.Expr("pending_days - ?", request.Days),
+				"updated_at":   request.UpdatedAt,
+			}
+			if request.Status == models.LeaveStatusApproved {
+				updates["used_days"] = gorm.Expr("used_days + ?", request.Days)
+			}
+			if err := tx.Model(&models.LeaveBalance{}).Where("id = ?", request.BalanceID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		closed = true
+		return nil
+	})
+	return closed, err
+}
+
+func (r *leaveRepository) FindBalance(userID, leaveTypeID string, year int) (*models.LeaveBalance, error) {
+	var balance models.LeaveBalance
+	if err := r.db.Preload("LeaveType").Where("user_id = ? AND leave_type_id = ? AND year = ?", userID, leaveTypeID, year).First(&balance).Error; err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
+
+func (r *leaveRepository) FindBalancesByUserID(userID string, year int) ([]*models.LeaveBalance, error) {
+	var balances []*models.LeaveBalance
+	if err := r.db.Preload("LeaveType").Where("user_id = ? AND year = ?", userID, year).Find(&balances).Error; err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+func (r *leaveRepository) CreateBalance(balance *models.LeaveBalance) error {
+	return r.db.Omit(clause.Associations).Create(balance).Error
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"face-verification-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LivenessChallengeRepository interface {
+	Create(challenge *models.LivenessChallenge) error
+	FindByID(id string) (*models.LivenessChallenge, error)
+	// MarkUsed consumes the challenge and reports whether it was still unused
+	MarkUsed(id string, at time.Time) (bool, error)
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type livenessChallengeRepository struct {
+	db *gorm.DB
+}
+
+func NewLivenessChallengeRepository(db *gorm.DB) LivenessChallengeRepository {
+	return &livenessChallengeRepository{db: db}
+}
+
+func (r *livenessChallengeRepository) Create(challenge *models.LivenessChallenge) error {
+	return r.db.Create(challenge).Error
+}
+
+func (r *livenessChallengeRepository) FindByID(id string) (*models.LivenessChallenge, error) {
+	var challenge models.LivenessChallenge
+	if err := r.db.Where("id = ?", id).First(&challenge).Error; err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func (r *livenessChallengeRepository) MarkUsed(id string, at time.Time) (bool, error) {
+	result := r.db.Model(&models.LivenessChallenge{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *livenessChallengeRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", before).Delete(&models.LivenessChallenge{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"face-verification-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OfficeRepository interface {
+	Create(office *models.Office) error
+	FindAll() ([]*models.Office, error)
+	FindByID(id string) (*models.Office, error)
+	FindByUserID(userID string) ([]*models.Office, error)
+	FindUserIDsByOfficeID(officeID string) ([]string, error)
+	Update(office *models.Office) error
+	Delete(id string) error
+	ReplaceUserOffices(userID string, officeIDs []string) error
+	UpdateFaceMatchThreshold(officeID string, threshold *float64) error
+}
+
+type officeRepository struct {
+	db *gorm.DB
+}
+
+func NewOfficeRepository(db *gorm.DB) OfficeRepository {
+	return &officeRepository{db: db}
+}
+
+func (r *officeRepository) Create(office *models.Office) error {
+	return r.db.Create(office).Error
+}
+
+func (r *officeRepository) FindAll() ([]*models.Office, error) {
+	var offices []*models.Office
+	if err := r.db.Order("name ASC").Find(&offices).Error; err != nil {
+		return nil, err
+	}
+	return offices, nil
+}
+
+func (r *officeRepository) FindByID(id string) (*models.Office, error) {
+	var office models.Office
+	if err := r.db.Where("id = ?", id).First(&office).Error; err != nil {
+		return nil, err
+	}
+	return &office, nil
+}
+
+func (r *officeRepository) FindByUserID(userID string) ([]*models.Office, error) {
+	var offices []*models.Office
+	if err := r.db.
+		Joins("JOIN user_offices ON user_offices.office_id = offices.id").
+		Where("user_offices.user_id = ?", userID).
+		Order("offices.name ASC").
+		Find(&offices).Error; err != nil {
+		return nil, err
+	}
+	return offices, nil
+}
+
+// FindUserIDsByOfficeID returns the users assigned to an office
+func (r *officeRepository) FindUserIDsByOfficeID(officeID string) ([]string, error) {
+	var userIDs []string
+	if err := r.db.Model(&models.UserOffice{}).Where("office_id = ?", officeID).Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+func (r *officeRepository) Update(office *models.Office) error {
+	return r.db.Save(office).Error
+}
+
+func (r *officeRepository) Delete(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("office_id = ?", id).Delete(&models.UserOffice{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Office{}, "id = ?", id).Error
+	})
+}
+
+// ReplaceUserOffices replaces all office assignments of a user with officeIDs
+func (r *officeRepository) ReplaceUserOffices(userID string, officeIDs []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserOffice{}).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, officeID := range officeIDs {
+			assignment := &models.UserOffice{UserID: userID, OfficeID: officeID, CreatedAt: now}
+			if err := tx.Create(assignment).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *officeRepository) UpdateFaceMatchThreshold(officeID string, threshold *float64) error {
+	return r.db.Model(&models.Office{}).Where("id = ?", officeID).Update("face_match_threshold", threshold).Error
+}
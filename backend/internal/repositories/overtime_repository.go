@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"face-verification-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OvertimeRepository interface {
+	Create(request *models.OvertimeRequest) error
+	FindByID(id string) (*models.OvertimeRequest, error)
+	FindByUserID(userID string) ([]*models.OvertimeRequest, error)
+	FindByStatus(status string) ([]*models.OvertimeRequest, error)
+	FindActiveByUserIDAndWorkDate(userID string, workDate time.Time) (*models.OvertimeRequest, error)
+	FindActiveByDateRange(userID string, startDate, endDate time.Time) ([]*models.OvertimeRequest, error)
+	Review(request *models.OvertimeRequest) (bool, error)
+}
+
+type overtimeRepository struct {
+	db *gorm.DB
+}
+
+func NewOvertimeRepository(db *gorm.DB) OvertimeRepository {
+	return &overtimeRepository{db: db}
+}
+
+func (r *overtimeRepository) Create(request *models.OvertimeRequest) error {
+	return r.db.Create(request).Error
+}
+
+func (r *overtimeRepository) FindByID(id string) (*models.OvertimeRequest, error) {
+	var request models.OvertimeRequest
+	if err := r.db.Where("id = ?", id).First(&request).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *overtimeRepository) FindByUserID(userID string) ([]*models.OvertimeRequest, error) {
+	var requests []*models.OvertimeRequest
+	if err := r.db.Where("user_id = ?", userID).Order("work_date DESC").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+func (r *overtimeRepository) FindByStatus(status string) ([]*models.OvertimeRequest, error) {
+	var requests []*models.OvertimeRequest
+	query := r.db
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("created_at ASC").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// FindActiveByUserIDAndWorkDate returns the user's pending or approved request for the workday
+func (r *overtimeRepository) FindActiveByUserIDAndWorkDate(userID string, workDate time.Time) (*models.OvertimeRequest, error) {
+	var request models.OvertimeRequest
+	if err := r.db.Where("user_id = ? AND work_date = ? AND status IN ?",
+		userID, workDate.Format("2006-01-02"), []string{models.OvertimeStatusPending, models.OvertimeStatusApproved}).
+		First(&request).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// FindActiveByDateRange returns pending and approved requests in the range, of
+// every user when userID is empty
+func (r *overtimeRepository) FindActiveByDateRange(userID string, startDate, endDate time.Time) ([]*models.OvertimeRequest, error) {
+	var requests []*models.OvertimeRequest
+	query := r.db.Where("work_date >= ? AND work_date <= ? AND status IN ?",
+		startDate.Format("2006-01-02"), endDate.Format("2006-01-02"),
+		[]string{models.OvertimeStatusPending, models.OvertimeStatusApproved})
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if err := query.Order("work_date ASC").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// Review stores the review of a pending request. It returns false, changing
+// nothing, when the request is no longer pending.
+func (r *overtimeRepository) Review(request *models.OvertimeRequest) (bool, error) {
+	result := r.db.Model(&models.OvertimeRequest{}).
+		Where("id = ? AND status = ?", request.ID, models.OvertimeStatusPending).
+		Updates(map[string]interface{}{
+			"status":           request.Status,
+			"approved_minutes": request.ApprovedMinutes,
+			"reviewer_id":      request.ReviewerID,
+			"review_note":      request.ReviewNote,
+			"reviewed_at":      request.ReviewedAt,
+			"updated_at":       request.UpdatedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
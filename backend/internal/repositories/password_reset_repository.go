@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"face-verification-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PasswordResetRepository interface {
+	Create(token *models.PasswordResetToken) error
+	FindByTokenHash(hash string) (*models.PasswordResetToken, error)
+	FindLatestByUserID(userID string) (*models.PasswordResetToken, error)
+	// MarkUsed uses the token, unless it was used already. It reports whether
+	// this call used it, so a token cannot be used twice concurrently.
+	MarkUsed(id string, at time.Time) (bool, error)
+	// InvalidateByUserID uses up every unused token of the user.
+	InvalidateByUserID(userID string, at time.Time) error
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+func (r *passwordResetRepository) Create(token *models.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *passwordResetRepository) FindByTokenHash(hash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *passwordResetRepository) FindLatestByUserID(userID string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *passwordResetRepository) MarkUsed(id string, at time.Time) (bool, error) {
+	result := r.db.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *passwordResetRepository) InvalidateByUserID(userID string, at time.Time) error {
+	return r.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", at).Error
+}
+
+func (r *passwordResetRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", before).Delete(&models.PasswordResetToken{})
+	return result.RowsAffected, result.Error
+}
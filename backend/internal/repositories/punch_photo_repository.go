@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"face-verification-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PunchPhotoRepository interface {
+	Create(photo *models.PunchPhoto) error
+	FindByUserIDAndContentHash(userID, contentHash string) (*models.PunchPhoto, error)
+	// FindByUserIDSince returns the user's photos with a perceptual hash submitted since the given time
+	FindByUserIDSince(userID string, since time.Time) ([]*models.PunchPhoto, error)
+}
+
+type punchPhotoRepository struct {
+	db *gorm.DB
+}
+
+func NewPunchPhotoRepository(db *gorm.DB) PunchPhotoRepository {
+	return &punchPhotoRepository{db: db}
+}
+
+func (r *punchPhotoRepository) Create(photo *models.PunchPhoto) error {
+	return r.db.Create(photo).Error
+}
+
+func (r *punchPhotoRepository) FindByUserIDAndContentHash(userID, contentHash string) (*models.PunchPhoto, error) {
+	var photo models.PunchPhoto
+	if err := r.db.Where("user_id = ? AND content_hash = ?", userID, contentHash).Order("created_at ASC").First(&photo).Error; err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+func (r *punchPhotoRepository) FindByUserIDSince(userID string, since time.Time) ([]*models.PunchPhoto, error) {
+	var photos []*models.PunchPhoto
+	err := r.db.Where("user_id = ? AND created_at >= ? AND perceptual_hash <> ''", userID, since).
+		Order("created_at DESC").
+		Find(&photos).Error
+	return photos, err
+}
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"face-verification-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ServiceCredentialRepository interface {
+	Create(credential *models.ServiceCredential) error
+	FindByID(id string) (*models.ServiceCredential, error)
+	FindByKeyPrefix(prefix string) (*models.ServiceCredential, error)
+	FindAll() ([]*models.ServiceCredential, error)
+	Update(credential *models.ServiceCredential) error
+	UpdateLastUsedAt(id string, at time.Time) error
+}
+
+type serviceCredentialRepository struct {
+	db *gorm.DB
+}
+
+func NewServiceCredentialRepository(db *gorm.DB) ServiceCredentialRepository {
+	return &serviceCredentialRepository{db: db}
+}
+
+func (r *serviceCredentialRepository) Create(credential *models.ServiceCredential) error {
+	return r.db.Create(credential).Error
+}
+
+func (r *serviceCredentialRepository) FindByID(id string) (*models.ServiceCredential, error) {
+	var credential models.ServiceCredential
+	if err := r.db.Where("id = ?", id).First(&credential).Error; err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *serviceCredentialRepository) FindByKeyPrefix(prefix string) (*models.ServiceCredential, error) {
+	var credential models.ServiceCredential
+	if err := r.db.Where("key_prefix = ?", prefix).First(&credential).Error; err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *serviceCredentialRepository) FindAll() ([]*models.ServiceCredential, error) {
+	var credentials []*models.ServiceCredential
+	if err := r.db.Order("created_at DESC").Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+func (r *serviceCredentialRepository) Update(credential *models.ServiceCredential) error {
+	return r.db.Save(credential).Error
+}
+
+func (r *serviceCredentialRepository) UpdateLastUsedAt(id string, at time.Time) error {
+	return r.db.Model(&models.ServiceCredential{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
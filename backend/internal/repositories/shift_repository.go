@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"face-verification-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ShiftRepository interface {
+	Create(shift *models.Shift) error
+	FindAll() ([]*models.Shift, error)
+	FindByID(id string) (*models.Shift, error)
+	Update(shift *models.Shift) error
+	// Delete deletes the shift unless users are assigned to it and returns
+	// how many are. The check and the delete run in one transaction.
+	Delete(id string) (int64, error)
+}
+
+type shiftRepository struct {
+	db *gorm.DB
+}
+
+func NewShiftRepository(db *gorm.DB) ShiftRepository {
+	return &shiftRepository{db: db}
+}
+
+func (r *shiftRepository) Create(shift *models.Shift) error {
+	return r.db.Create(shift).Error
+}
+
+func (r *shiftRepository) FindAll() ([]*models.Shift, error) {
+	var shifts []*models.Shift
+	if err := r.db.Order("start_time ASC").Find(&shifts).Error; err != nil {
+		return nil, err
+	}
+	return shifts, nil
+}
+
+func (r *shiftRepository) FindByID(id string) (*models.Shift, error) {
+	var shift models.Shift
+	if err := r.db.Where("id = ?", id).First(&shift).Error; err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+func (r *shiftRepository) Update(shift *models.Shift) error {
+	return r.db.Save(shift).Error
+}
+
+func (r *shiftRepository) Delete(id string) (int64, error) {
+	var assigned int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var shift models.Shift
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&shift).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("shift_id = ?", id).Count(&assigned).Error; err != nil {
+			return err
+		}
+		if assigned > 0 {
+			return nil
+		}
+		return tx.Delete(&shift).Error
+	})
+	return assigned, err
+}
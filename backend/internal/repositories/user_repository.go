@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"face-verification-backend/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -14,6 +15,13 @@ type UserRepository interface {
 	Update(user *models.User) error
 	UpdateProfilePhoto(userID string, photoURL string) error
 	UpdateFaceEmbeddingID(userID string, embeddingID string) error
+	UpdateShiftID(userID string, shiftID string) error
+	UpdateFaceReenrollmentRequired(userID string, required bool) error
+	FindFaceReenrollmentRequired() ([]*models.User, error)
+	UpdateFaceMatchThreshold(userID string, threshold *float64) error
+	UpdateRole(userID, role, permissions string) error
+	UpdateRoleByEmails(emails []string, role string) (int64, error)
+	CountByRole(role string) (int64, error)
 }
 
 type userRepository struct {
@@ -64,3 +72,45 @@ func (r *userRepository) UpdateFaceEmbeddingID(userID string, embeddingID string
 	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("face_embedding_id", embeddingID).Error
 }
 
+// UpdateShiftID assigns a shift, recording when the user was first given one.
+func (r *userRepository) UpdateShiftID(userID string, shiftID string) error {
+	updates := map[string]interface{}{"shift_id": shiftID}
+	if shiftID != "" {
+		updates["shift_assigned_at"] = gorm.Expr("COALESCE(shift_assigned_at, ?)", time.Now())
+	}
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error
+}
+
+func (r *userRepository) UpdateFaceReenrollmentRequired(userID string, required bool) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("face_reenrollment_required", required).Error
+}
+
+func (r *userRepository) FindFaceReenrollmentRequired() ([]*models.User, error) {
+	var users []*models.User
+	if err := r.db.Where("face_reenrollment_required = ?", true).Order("name ASC").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *userRepository) UpdateFaceMatchThreshold(userID string, threshold *float64) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("face_match_threshold", threshold).Error
+}
+
+func (r *userRepository) UpdateRole(userID, role, permissions string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"role":        role,
+		"permissions": permissions,
+	}).Error
+}
+
+func (r *userRepository) CountByRole(role string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).Where("role = ?", role).Count(&count).Error
+	return count, err
+}
+
+func (r *userRepository) UpdateRoleByEmails(emails []string, role string) (int64, error) {
+	result := r.db.Model(&models.User{}).Where("email IN ? AND role <> ?", emails, role).Update("role", role)
+	return result.RowsAffected, result.Error
+}
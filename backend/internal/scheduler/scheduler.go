@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Job is a task run periodically by the Scheduler.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func() error
+}
+
+// Scheduler runs background jobs on fixed intervals inside the backend process.
+type Scheduler struct {
+	jobs []Job
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New() *Scheduler {
+	return &Scheduler{stop: make(chan struct{})}
+}
+
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every job once immediately and then on its interval until Stop is called.
+func (s *Scheduler) Start() {
+	for _, job := range s.jobs {
+		if job.Interval <= 0 {
+			log.Printf("[SCHEDULER] Job %s has no interval, not starting", job.Name)
+			continue
+		}
+		s.wg.Add(1)
+		go s.loop(job)
+	}
+}
+
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(job Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.run(job)
+		select {
+		case <-ticker.C:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *Scheduler) run(job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[SCHEDULER] Job %s panicked: %v", job.Name, r)
+		}
+	}()
+
+	if err := job.Run(); err != nil {
+		log.Printf("[SCHEDULER] Job %s failed: %v", job.Name, err)
+	}
+}
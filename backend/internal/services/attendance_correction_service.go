@@ -0,0 +1,311 @@
+package services
+
+import (
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/repositories"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCorrectionNotFound     = errors.New("correction request not found")
+	ErrCorrectionNotPending   = errors.New("correction request is no longer pending")
+	ErrCorrectionExists       = errors.New("a correction request for this day is already pending")
+	ErrCorrectionSelfApproval = errors.New("correction requests cannot be approved by the requester")
+)
+
+type AttendanceCorrectionService interface {
+	RequestCorrection(userID string, workDate time.Time, clockIn, clockOut *time.Time, reason, evidencePath string) (*models.AttendanceCorrection, error)
+	GetUserCorrections(userID string) ([]*models.AttendanceCorrection, error)
+	GetCorrections(status string) ([]*models.AttendanceCorrection, error)
+	ApproveCorrection(correctionID, reviewerID, note string) (*models.AttendanceCorrection, error)
+	RejectCorrection(correctionID, reviewerID, note string) (*models.AttendanceCorrection, error)
+	GetCorrectionHistory(attendanceID string) ([]*models.AttendanceCorrectionHistory, error)
+}
+
+type attendanceCorrectionService struct {
+	correctionRepo    repositories.AttendanceCorrectionRepository
+	attendanceRepo    repositories.AttendanceRepository
+	shiftService      ShiftService
+	cloudinaryService CloudinaryService
+	dayBoundaryHour   int
+}
+
+func NewAttendanceCorrectionService(correctionRepo repositories.AttendanceCorrectionRepository, attendanceRepo repositories.AttendanceRepository, shiftService ShiftService, cloudinaryService CloudinaryService, dayBoundaryHour int) AttendanceCorrectionService {
+	return &attendanceCorrectionService{
+		correctionRepo:    correctionRepo,
+		attendanceRepo:    attendanceRepo,
+		shiftService:      shiftService,
+		cloudinaryService: cloudinaryService,
+		dayBoundaryHour:   dayBoundaryHour,
+	}
+}
+
+func (s *attendanceCorrectionService) RequestCorrection(userID string, workDate time.Time, clockIn, clockOut *time.Time, reason, evidencePath string) (*models.AttendanceCorrection, error) {
+	boundary := time.Duration(s.dayBoundaryHour) * time.Hour
+	if shift, err := s.shiftService.GetUserShift(userID); err == nil {
+		boundary = shiftDayBoundary(shift)
+	}
+	if err := checkCorrectionTimes(workDate, boundary, clockIn, clockOut, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.correctionRepo.FindPendingByUserIDAndWorkDate(userID, workDate); err == nil {
+		return nil, ErrCorrectionExists
+	}
+
+	var evidenceURL string
+	if evidencePath != "" {
+		url, err := storePhoto(s.cloudinaryService, evidencePath, "corrections")
+		if err != nil {
+			return nil, err
+		}
+		evidenceURL = url
+	}
+
+	correction := &models.AttendanceCorrection{
+		ID:                uuid.New().String(),
+		UserID:            userID,
+		WorkDate:          workDate,
+		RequestedClockIn:  clockIn,
+		RequestedClockOut: clockOut,
+		Reason:            reason,
+		EvidencePhoto:     evidenceURL,
+		Status:            models.CorrectionStatusPending,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+
+	if err := s.correctionRepo.Create(correction); err != nil {
+		return nil, err
+	}
+
+	return correction, nil
+}
+
+func (s *attendanceCorrectionService) GetUserCorrections(userID string) ([]*models.AttendanceCorrection, error) {
+	return s.correctionRepo.FindByUserID(userID)
+}
+
+func (s *attendanceCorrectionService) GetCorrections(status string) ([]*models.AttendanceCorrection, error) {
+	return s.correctionRepo.FindByStatus(status)
+}
+
+// ApproveCorrection applies the requested times to the workday's attendance,
+// creating it if the employee never clocked in, and records the replaced
+// values in the correction history.
+func (s *attendanceCorrectionService) ApproveCorrection(correctionID, reviewerID, note string) (*models.AttendanceCorrection, error) {
+	correction, err := s.findPending(correctionID)
+	if err != nil {
+		return nil, err
+	}
+	if correction.UserID == reviewerID {
+		return nil, ErrCorrectionSelfApproval
+	}
+
+	now := time.Now()
+	attendance, err := s.attendanceRepo.FindByUserIDAndWorkDate(correction.UserID, correction.WorkDate)
+	isNew := false
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		attendance = &models.Attendance{
+			ID:        uuid.New().String(),
+			UserID:    correction.UserID,
+			WorkDate:  correction.WorkDate,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		isNew = true
+	} else if err != nil {
+		return nil, err
+	}
+
+	history := &models.AttendanceCorrectionHistory{
+		ID:               uuid.New().String(),
+		AttendanceID:     attendance.ID,
+		CorrectionID:     correction.ID,
+		PreviousClockIn:  attendance.ClockIn,
+		PreviousClockOut: attendance.ClockOut,
+		ApprovedBy:       reviewerID,
+		CreatedAt:        now,
+	}
+
+	clockIn := attendance.ClockIn
+	if correction.RequestedClockIn != nil {
+		clockIn = correction.RequestedClockIn
+	}
+	clockOut := attendance.ClockOut
+	if correction.RequestedClockOut != nil {
+		clockOut = correction.RequestedClockOut
+	}
+	if clockIn == nil {
+		return nil, fmt.Errorf("cannot correct clock-out of a day without clock-in")
+	}
+	if clockOut != nil && !clockOut.After(*clockIn) {
+		return nil, fmt.Errorf("clock_out must be after clock_in")
+	}
+
+	attendance.ClockIn = clockIn
+	attendance.ClockOut = clockOut
+	attendance.Corrected = true
+	attendance.UpdatedAt = now
+	s.classify(attendance)
+	punches := correctedPunches(attendance, correction, now)
+
+	history.NewClockIn = attendance.ClockIn
+	history.NewClockOut = attendance.ClockOut
+
+	correction.Status = models.CorrectionStatusApproved
+	correction.AttendanceID = attendance.ID
+	correction.ReviewerID = reviewerID
+	correction.ReviewNote = note
+	correction.ReviewedAt = &now
+	correction.UpdatedAt = now
+
+	// Only one of concurrent reviews gets to close the correction
+	approved, err := s.correctionRepo.Approve(correction, attendance, isNew, punches, history)
+	if err != nil {
+		return nil, err
+	}
+	if !approved {
+		return nil, ErrCorrectionNotPending
+	}
+
+	return correction, nil
+}
+
+func (s *attendanceCorrectionService) RejectCorrection(correctionID, reviewerID, note string) (*models.AttendanceCorrection, error) {
+	correction, err := s.findPending(correctionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	correction.Status = models.CorrectionStatusRejected
+	correction.ReviewerID = reviewerID
+	correction.ReviewNote = note
+	correction.ReviewedAt = &now
+	correction.UpdatedAt = now
+
+	rejected, err := s.correctionRepo.Reject(correction)
+	if err != nil {
+		return nil, err
+	}
+	if !rejected {
+		return nil, ErrCorrectionNotPending
+	}
+
+	return correction, nil
+}
+
+func (s *attendanceCorrectionService) GetCorrectionHistory(attendanceID string) ([]*models.AttendanceCorrectionHistory, error) {
+	return s.correctionRepo.FindHistoryByAttendanceID(attendanceID)
+}
+
+func (s *attendanceCorrectionService) findPending(correctionID string) (*models.AttendanceCorrection, error) {
+	correction, err := s.correctionRepo.FindByID(correctionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCorrectionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if correction.Status != models.CorrectionStatusPending {
+		return nil, ErrCorrectionNotPending
+	}
+	return correction, nil
+}
+
+// classify re-evaluates the corrected times against the shift of the day.
+func (s *attendanceCorrectionService) classify(attendance *models.Attendance) {
+	var shift *models.Shift
+	if attendance.ShiftID != "" {
+		shift, _ = s.shiftService.GetShift(attendance.ShiftID)
+	} else {
+		shift, _ = s.shiftService.GetUserShift(attendance.UserID)
+	}
+
+	applyClockInStatus(attendance, shift, *attendance.ClockIn)
+	attendance.ClockOutStatus, attendance.EarlyLeaveMinutes, attendance.OvertimeMinutes = "", 0, 0
+	if shift != nil && attendance.ClockOut != nil {
+		attendance.ClockOutStatus, attendance.EarlyLeaveMinutes, attendance.OvertimeMinutes = classifyClockOut(shift, attendance.WorkDate, *attendance.ClockOut)
+	}
+}
+
+// correctedPunches moves the first clock-in and last clock-out punches to the
+// corrected times, adding them when the day has no such punch. It returns the
+// punches to store.
+func correctedPunches(attendance *models.Attendance, correction *models.AttendanceCorrection, now time.Time) []*models.AttendancePunch {
+	var firstIn, lastOut *models.AttendancePunch
+	for i := range attendance.Punches {
+		punch := &attendance.Punches[i]
+		if punch.Type == models.PunchTypeClockIn && firstIn == nil {
+			firstIn = punch
+		}
+		if punch.Type == models.PunchTypeClockOut {
+			lastOut = punch
+		}
+	}
+	if n := len(attendance.Punches); lastOut != nil && &attendance.Punches[n-1] != lastOut {
+		// A later session is still open, the correction does not close it
+		lastOut = nil
+	}
+
+	var punches []*models.AttendancePunch
+	if correction.RequestedClockIn != nil || firstIn == nil {
+		punches = append(punches, movePunch(attendance, firstIn, models.PunchTypeClockIn, *attendance.ClockIn, now))
+	}
+	if attendance.ClockOut != nil && (correction.RequestedClockOut != nil || lastOut == nil) {
+		punches = append(punches, movePunch(attendance, lastOut, models.PunchTypeClockOut, *attendance.ClockOut, now))
+	}
+	return punches
+}
+
+func movePunch(attendance *models.Attendance, punch *models.AttendancePunch, punchType string, at, now time.Time) *models.AttendancePunch {
+	if punch != nil {
+		punch.PunchedAt = at
+		return punch
+	}
+	return &models.AttendancePunch{
+		ID:           uuid.New().String(),
+		AttendanceID: attendance.ID,
+		UserID:       attendance.UserID,
+		Type:         punchType,
+		PunchedAt:    at,
+		CreatedAt:    now,
+	}
+}
+
+// checkCorrectionTimes validates requested times against the workday, which
+// starts boundary past midnight of workDate, or before it when negative. The
+// clock-in must fall within the workday; the clock-out may run into the
+// following day, as shifts can cross the boundary.
+func checkCorrectionTimes(workDate time.Time, boundary time.Duration, clockIn, clockOut *time.Time, now time.Time) error {
+	if clockIn == nil && clockOut == nil {
+		return fmt.Errorf("clock_in or clock_out is required")
+	}
+	if workDate.After(now) {
+		return fmt.Errorf("work_date must not be in the future")
+	}
+
+	start := workDate.Add(boundary)
+	end := start.AddDate(0, 0, 1)
+	if clockIn != nil && (clockIn.Before(start) || !clockIn.Before(end)) {
+		return fmt.Errorf("clock_in must fall within work_date")
+	}
+	if clockOut != nil && (!clockOut.After(start) || !clockOut.Before(end.AddDate(0, 0, 1))) {
+		return fmt.Errorf("clock_out must fall within work_date or the day after")
+	}
+	if clockIn != nil && clockOut != nil && !clockOut.After(*clockIn) {
+		return fmt.Errorf("clock_out must be after clock_in")
+	}
+	for _, t := range []*time.Time{clockIn, clockOut} {
+		if t != nil && t.After(now) {
+			return fmt.Errorf("corrected times must not be in the future")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckCorrectionTimes(t *testing.T) {
+	workDate := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	at := func(day, hour, minute int) *time.Time {
+		t := time.Date(2024, 3, day, hour, minute, 0, 0, time.UTC)
+		return &t
+	}
+
+	tests := []struct {
+		name     string
+		boundary time.Duration
+		clockIn  *time.Time
+		clockOut *time.Time
+		wantErr  bool
+	}{
+		{"both times", 0, at(4, 8, 0), at(4, 17, 0), false},
+		{"clock-in only", 0, at(4, 8, 0), nil, false},
+		{"clock-out only", 0, nil, at(4, 17, 0), false},
+		{"clock-out after midnight", 0, at(4, 18, 0), at(5, 2, 0), false},
+		{"overnight workday", 14 * time.Hour, at(4, 22, 0), at(5, 6, 0), false},
+		{"nothing requested", 0, nil, nil, true},
+		{"clock-in on the previous day", 0, at(3, 23, 0), at(4, 8, 0), true},
+		{"clock-in on the next day", 0, at(5, 8, 0), nil, true},
+		{"clock-in before an overnight workday", 14 * time.Hour, at(4, 8, 0), nil, true},
+		{"clock-out before the workday", 0, nil, at(3, 17, 0), true},
+		{"clock-out two days later", 0, nil, at(6, 1, 0), true},
+		{"clock-out before clock-in", 0, at(4, 17, 0), at(4, 8, 0), true},
+		{"clock-out equal to clock-in", 0, at(4, 8, 0), at(4, 8, 0), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkCorrectionTimes(workDate, tt.boundary, tt.clockIn, tt.clockOut, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkCorrectionTimes() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	today := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	if err := checkCorrectionTimes(today, 0, at(10, 8, 0), at(10, 17, 0), now); err == nil {
+		t.Error("checkCorrectionTimes() accepted a clock-out in the future")
+	}
+}
@@ -1,77 +1,167 @@
 package services
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"face-verification-backend/internal/models"
 	"face-verification-backend/internal/repositories"
 	"fmt"
-	"io"
-	"mime/multipart"
-	"net/http"
-	"os"
+	"log"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrAlreadyClockedIn  = errors.New("already clocked in")
+	ErrAlreadyClockedOut = errors.New("already clocked out")
+	ErrNotClockedIn      = errors.New("not clocked in")
+	ErrAlreadyOnBreak    = errors.New("already on break")
+	ErrNotOnBreak        = errors.New("not on break")
+	ErrFaceMismatch      = errors.New("face does not match")
+	ErrOutsideGeofence   = errors.New("outside the allowed clock-in area")
+)
+
+// attendanceTransitions lists the punches allowed in each attendance state and
+// the state they lead to.
+var attendanceTransitions = map[string]map[string]string{
+	models.AttendanceStateNotStarted: {
+		models.PunchTypeClockIn: models.AttendanceStateClockedIn,
+	},
+	models.AttendanceStateClockedIn: {
+		models.PunchTypeBreakStart: models.AttendanceStateOnBreak,
+		models.PunchTypeClockOut:   models.AttendanceStateClockedOut,
+	},
+	models.AttendanceStateOnBreak: {
+		models.PunchTypeBreakEnd: models.AttendanceStateClockedIn,
+		models.PunchTypeClockOut: models.AttendanceStateClockedOut,
+	},
+	models.AttendanceStateClockedOut: {
+		models.PunchTypeClockIn: models.AttendanceStateClockedIn,
+	},
+}
+
 type AttendanceService interface {
-	ClockIn(userID, photoPath, location string) (*models.Attendance, error)
-	ClockOut(userID, photoPath, location string) (*models.Attendance, error)
+	ClockIn(userID string, face FaceSample, location string, coords *Coordinates) (*models.Attendance, error)
+	ClockOut(userID string, face FaceSample, location string) (*models.Attendance, error)
+	StartBreak(userID, location string) (*models.Attendance, error)
+	EndBreak(userID, location string) (*models.Attendance, error)
 	GetTodayAttendance(userID string) (*models.Attendance, error)
+	AutoClockOut(now time.Time, delay, maxSession time.Duration) (int, error)
 	GetHistory(userID string, startDate, endDate time.Time) ([]*models.Attendance, error)
 }
 
 type attendanceService struct {
-	attendanceRepo     repositories.AttendanceRepository
-	faceRecognitionURL string
-	cloudinaryService  CloudinaryService
+	attendanceRepo    repositories.AttendanceRepository
+	shiftService      ShiftService
+	officeService     OfficeService
+	leaveService      LeaveService
+	calendarService   CalendarService
+	dayBoundaryHour   int
+	geofenceMode      string
+	faceVerifier      FaceVerifier
+	attemptService    FaceVerificationAttemptService
+	thresholdService  FaceThresholdService
+	livenessService   LivenessService
+	photoService      PhotoFingerprintService
+	cloudinaryService CloudinaryService
 }
 
-func NewAttendanceService(attendanceRepo repositories.AttendanceRepository, faceRecognitionURL string, cloudinaryService CloudinaryService) AttendanceService {
+func NewAttendanceService(attendanceRepo repositories.AttendanceRepository, shiftService ShiftService, officeService OfficeService, leaveService LeaveService, calendarService CalendarService, dayBoundaryHour int, geofenceMode string, faceVerifier FaceVerifier, attemptService FaceVerificationAttemptService, thresholdService FaceThresholdService, livenessService LivenessService, photoService PhotoFingerprintService, cloudinaryService CloudinaryService) AttendanceService {
 	return &attendanceService{
-		attendanceRepo:     attendanceRepo,
-		faceRecognitionURL: faceRecognitionURL,
-		cloudinaryService:  cloudinaryService,
+		attendanceRepo:    attendanceRepo,
+		shiftService:      shiftService,
+		officeService:     officeService,
+		leaveService:      leaveService,
+		calendarService:   calendarService,
+		dayBoundaryHour:   dayBoundaryHour,
+		geofenceMode:      geofenceMode,
+		faceVerifier:      faceVerifier,
+		attemptService:    attemptService,
+		thresholdService:  thresholdService,
+		livenessService:   livenessService,
+		photoService:      photoService,
+		cloudinaryService: cloudinaryService,
 	}
 }
 
-func (s *attendanceService) ClockIn(userID, photoPath, location string) (*models.Attendance, error) {
-	fmt.Printf("[CLOCK_IN] Starting clock in for user: %s\n", userID)
+func (s *attendanceService) ClockIn(userID string, face FaceSample, location string, coords *Coordinates) (*models.Attendance, error) {
+	// A session left open on the current or previous workday must be closed first
+	if _, err := s.findOpenAttendance(userID, time.Now()); err == nil {
+		return nil, ErrAlreadyClockedIn
+	}
+
+	face.PunchType = models.PunchTypeClockIn
 
-	// Verify face with Python service
-	verified, err := s.verifyFace(photoPath, userID)
+	// Check the position against the user's offices before verifying the face
+	geofence, outside, err := s.checkGeofence(userID, coords)
 	if err != nil {
-		fmt.Printf("[CLOCK_IN] Face verification error: %v\n", err)
-		return nil, fmt.Errorf("face verification failed: %w", err)
+		return nil, s.rejectPunch(userID, face, err)
 	}
-	if !verified {
-		fmt.Printf("[CLOCK_IN] Face verification failed: face does not match\n")
-		return nil, fmt.Errorf("face verification failed: face does not match. Please ensure you're using the correct profile photo and good lighting")
+	if geofence != nil {
+		face.OfficeID = geofence.Office.ID
 	}
 
-	fmt.Printf("[CLOCK_IN] Face verification successful\n")
+	// Reject a photo that was already submitted before doing any other work
+	photoCheck, err := s.photoService.Check(userID, face.PhotoPath, time.Now())
+	if err != nil {
+		return nil, s.rejectPunch(userID, face, err)
+	}
 
-	// Save photo
-	photoURL, err := s.savePhoto(photoPath)
+	// A still photo is not enough, check the answer to the liveness challenge
+	liveness, err := s.livenessService.Evaluate(face.Liveness, face.PhotoPath)
 	if err != nil {
-		return nil, err
+		return nil, s.rejectPunch(userID, face, err)
+	}
+
+	verified, attemptID, err := s.verifyFace(face, userID)
+	if errors.Is(err, ErrFaceMismatch) {
+		return nil, fmt.Errorf("face verification failed: %w. Please ensure you're using the correct profile photo and good lighting", ErrFaceMismatch)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("face verification failed: %w", err)
 	}
 
-	// Check if today's attendance exists
-	todayAttendance, _ := s.attendanceRepo.FindTodayByUserID(userID)
+	// Only accepted punches are uploaded, rejected photos are not kept
+	photoURL, err := s.savePhoto(face.PhotoPath, attemptID)
+	if err != nil {
+		return nil, err
+	}
 
 	now := time.Now()
+	workDate, shift := s.resolveWorkDate(userID, now)
+
+	// Check if attendance for this workday exists
+	todayAttendance, _ := s.attendanceRepo.FindByUserIDAndWorkDate(userID, workDate)
+
 	if todayAttendance != nil {
-		// Update existing attendance
-		todayAttendance.ClockIn = &now
-		todayAttendance.ClockInPhoto = photoURL
-		todayAttendance.ClockInLocation = location
-		todayAttendance.IsVerified = true
+		if _, err := nextAttendanceState(todayAttendance, models.PunchTypeClockIn); err != nil {
+			return nil, err
+		}
+
+		// Start another work session. The first clock-in of the day is kept,
+		// the clock-out is reopened until the session ends.
+		if err := s.backfillPunches(todayAttendance); err != nil {
+			return nil, err
+		}
+		if err := s.addPunch(todayAttendance, models.PunchTypeClockIn, now, photoURL, location); err != nil {
+			return nil, err
+		}
+		todayAttendance.ClockOut = nil
+		todayAttendance.ClockOutStatus = ""
+		todayAttendance.EarlyLeaveMinutes = 0
+		todayAttendance.OvertimeMinutes = 0
+		todayAttendance.OutsideGeofence = todayAttendance.OutsideGeofence || outside
+		todayAttendance.IsVerified = todayAttendance.IsVerified && verified
+		applyLiveness(todayAttendance, liveness)
+		applyPhotoCheck(todayAttendance, photoCheck)
 		if err := s.attendanceRepo.Update(todayAttendance); err != nil {
 			return nil, err
 		}
+		s.recordPhoto(todayAttendance, models.PunchTypeClockIn, photoCheck)
+		computeTotals(todayAttendance, now)
 		return todayAttendance, nil
 	}
 
@@ -79,182 +169,692 @@ func (s *attendanceService) ClockIn(userID, photoPath, location string) (*models
 	attendance := &models.Attendance{
 		ID:              uuid.New().String(),
 		UserID:          userID,
+		WorkDate:        workDate,
 		ClockIn:         &now,
 		ClockInPhoto:    photoURL,
 		ClockInLocation: location,
-		IsVerified:      true,
+		IsVerified:      verified,
 		CreatedAt:       now,
 		UpdatedAt:       now,
 	}
+	applyClockInPosition(attendance, coords, geofence, outside)
+	applyClockInStatus(attendance, shift, now)
+	applyLiveness(attendance, liveness)
+	applyPhotoCheck(attendance, photoCheck)
 
 	if err := s.attendanceRepo.Create(attendance); err != nil {
+		// A concurrent clock-in created the workday first
+		if errors.Is(err, repositories.ErrDuplicate) {
+			return nil, ErrAlreadyClockedIn
+		}
 		return nil, err
 	}
+	if err := s.addPunch(attendance, models.PunchTypeClockIn, now, photoURL, location); err != nil {
+		return nil, err
+	}
+	s.recordPhoto(attendance, models.PunchTypeClockIn, photoCheck)
 
+	computeTotals(attendance, now)
 	return attendance, nil
 }
 
-func (s *attendanceService) ClockOut(userID, photoPath, location string) (*models.Attendance, error) {
-	// Get today's attendance
-	todayAttendance, err := s.attendanceRepo.FindTodayByUserID(userID)
+func (s *attendanceService) ClockOut(userID string, face FaceSample, location string) (*models.Attendance, error) {
+	// Get the open session of the current workday, or one still open from the
+	// previous workday (e.g. a night shift clocked out after the day boundary)
+	todayAttendance, err := s.findOpenAttendance(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := nextAttendanceState(todayAttendance, models.PunchTypeClockOut); err != nil {
+		return nil, err
+	}
+
+	face.PunchType = models.PunchTypeClockOut
+	face.OfficeID = todayAttendance.ClockInOfficeID
+
+	photoCheck, err := s.photoService.Check(userID, face.PhotoPath, time.Now())
 	if err != nil {
-		return nil, fmt.Errorf("no clock in found for today")
+		return nil, s.rejectPunch(userID, face, err)
 	}
 
 	// Verify face
-	verified, err := s.verifyFace(photoPath, userID)
+	verified, attemptID, err := s.verifyFace(face, userID)
 	if err != nil {
 		return nil, fmt.Errorf("face verification failed: %w", err)
 	}
-	if !verified {
-		return nil, fmt.Errorf("face verification failed: face does not match")
-	}
 
-	// Save photo
-	photoURL, err := s.savePhoto(photoPath)
+	photoURL, err := s.savePhoto(face.PhotoPath, attemptID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Update attendance
 	now := time.Now()
+	if err := s.backfillPunches(todayAttendance); err != nil {
+		return nil, err
+	}
+	if err := s.addPunch(todayAttendance, models.PunchTypeClockOut, now, photoURL, location); err != nil {
+		return nil, err
+	}
 	todayAttendance.ClockOut = &now
 	todayAttendance.ClockOutPhoto = photoURL
 	todayAttendance.ClockOutLocation = location
-	todayAttendance.IsVerified = true
+	todayAttendance.IsVerified = todayAttendance.IsVerified && verified
+	applyPhotoCheck(todayAttendance, photoCheck)
+	s.applyClockOutStatus(todayAttendance, now)
 
 	if err := s.attendanceRepo.Update(todayAttendance); err != nil {
 		return nil, err
 	}
+	s.recordPhoto(todayAttendance, models.PunchTypeClockOut, photoCheck)
 
+	computeTotals(todayAttendance, now)
 	return todayAttendance, nil
 }
 
-func (s *attendanceService) GetTodayAttendance(userID string) (*models.Attendance, error) {
-	return s.attendanceRepo.FindTodayByUserID(userID)
-}
+func (s *attendanceService) StartBreak(userID, location string) (*models.Attendance, error) {
+	now := time.Now()
+	attendance, err := s.findOpenAttendance(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := nextAttendanceState(attendance, models.PunchTypeBreakStart); err != nil {
+		return nil, err
+	}
 
-func (s *attendanceService) GetHistory(userID string, startDate, endDate time.Time) ([]*models.Attendance, error) {
-	return s.attendanceRepo.FindByUserIDAndDateRange(userID, startDate, endDate)
-}
+	if err := s.backfillPunches(attendance); err != nil {
+		return nil, err
+	}
+	if err := s.addPunch(attendance, models.PunchTypeBreakStart, now, "", location); err != nil {
+		return nil, err
+	}
 
-func (s *attendanceService) verifyFace(photoPath, userID string) (bool, error) {
-	fmt.Printf("[VERIFY] Starting face verification for user: %s\n", userID)
-	fmt.Printf("[VERIFY] Photo path: %s\n", photoPath)
-	fmt.Printf("[VERIFY] Face recognition URL: %s\n", s.faceRecognitionURL)
+	computeTotals(attendance, now)
+	return attendance, nil
+}
 
-	file, err := os.Open(photoPath)
+func (s *attendanceService) EndBreak(userID, location string) (*models.Attendance, error) {
+	now := time.Now()
+	attendance, err := s.findOpenAttendance(userID, now)
 	if err != nil {
-		fmt.Printf("[VERIFY] Error opening file: %v\n", err)
-		return false, fmt.Errorf("failed to open photo file: %w", err)
+		return nil, err
+	}
+	if _, err := nextAttendanceState(attendance, models.PunchTypeBreakEnd); err != nil {
+		return nil, err
+	}
+
+	if err := s.backfillPunches(attendance); err != nil {
+		return nil, err
+	}
+	if err := s.addPunch(attendance, models.PunchTypeBreakEnd, now, "", location); err != nil {
+		return nil, err
 	}
-	defer file.Close()
 
-	var requestBody bytes.Buffer
-	writer := multipart.NewWriter(&requestBody)
+	computeTotals(attendance, now)
+	return attendance, nil
+}
 
-	part, err := writer.CreateFormFile("photo", filepath.Base(photoPath))
+// AutoClockOut closes sessions left open past their scheduled end. A session is
+// closed once delay has passed since the end of the user's shift, or since
+// maxSession after the session started when no shift applies or the session
+// started after the shift ended. The clock-out is recorded at the scheduled
+// end, without a photo, and flagged as auto-closed. Time past the shift end is
+// not counted as overtime, since nobody confirmed it was worked.
+// It returns the number of sessions closed.
+func (s *attendanceService) AutoClockOut(now time.Time, delay, maxSession time.Duration) (int, error) {
+	attendances, err := s.attendanceRepo.FindOpen()
 	if err != nil {
-		fmt.Printf("[VERIFY] Error creating form file: %v\n", err)
-		return false, fmt.Errorf("failed to create form file: %w", err)
+		return 0, err
+	}
+
+	closed := 0
+	for _, attendance := range attendances {
+		var shift *models.Shift
+		if attendance.ShiftID != "" {
+			shift, _ = s.shiftService.GetShift(attendance.ShiftID)
+		}
+
+		closeAt := scheduledClockOut(attendance, shift, maxSession)
+		if now.Before(closeAt.Add(delay)) {
+			continue
+		}
+		if n := len(attendance.Punches); n > 0 && closeAt.Before(attendance.Punches[n-1].PunchedAt) {
+			closeAt = attendance.Punches[n-1].PunchedAt
+		}
+
+		if err := s.backfillPunches(attendance); err != nil {
+			return closed, err
+		}
+		if err := s.addPunch(attendance, models.PunchTypeClockOut, closeAt, "", ""); err != nil {
+			return closed, err
+		}
+		attendance.ClockOut = &closeAt
+		attendance.ClockOutPhoto = ""
+		attendance.ClockOutLocation = ""
+		attendance.AutoClosed = true
+		if shift != nil {
+			attendance.ClockOutStatus, attendance.EarlyLeaveMinutes = classifyAutoClockOut(shift, attendance.WorkDate, closeAt)
+			attendance.OvertimeMinutes = 0
+		}
+
+		if err := s.attendanceRepo.Update(attendance); err != nil {
+			return closed, err
+		}
+		closed++
+	}
+
+	return closed, nil
+}
+
+// scheduledClockOut returns when an open session should have ended: the end of
+// the shift recorded at clock-in, or maxSession after the session started when
+// there is no shift or the session started after the shift ended.
+func scheduledClockOut(attendance *models.Attendance, shift *models.Shift, maxSession time.Duration) time.Time {
+	sessionStart := *attendance.ClockIn
+	for _, punch := range attendance.Punches {
+		if punch.Type == models.PunchTypeClockIn {
+			sessionStart = punch.PunchedAt
+		}
+	}
+
+	if shift != nil {
+		if _, end := shiftBounds(shift, attendance.WorkDate); sessionStart.Before(end) {
+			return end
+		}
+	}
+	return sessionStart.Add(maxSession)
+}
+
+// classifyAutoClockOut classifies a clock-out made by the system like
+// classifyClockOut, but reports it on time rather than as overtime.
+func classifyAutoClockOut(shift *models.Shift, workDate, clockOut time.Time) (string, int) {
+	status, earlyLeave, _ := classifyClockOut(shift, workDate, clockOut)
+	if status == models.AttendanceStatusOvertime {
+		status = models.AttendanceStatusOnTime
 	}
+	return status, earlyLeave
+}
 
-	_, err = io.Copy(part, file)
+func (s *attendanceService) GetTodayAttendance(userID string) (*models.Attendance, error) {
+	now := time.Now()
+	workDate, _ := s.resolveWorkDate(userID, now)
+	attendance, err := s.attendanceRepo.FindByUserIDAndWorkDate(userID, workDate)
 	if err != nil {
-		fmt.Printf("[VERIFY] Error copying file: %v\n", err)
-		return false, fmt.Errorf("failed to copy file: %w", err)
+		return nil, err
 	}
 
-	writer.WriteField("user_id", userID)
-	writer.Close()
+	computeTotals(attendance, now)
+	return attendance, nil
+}
+
+func (s *attendanceService) GetHistory(userID string, startDate, endDate time.Time) ([]*models.Attendance, error) {
+	attendances, err := s.attendanceRepo.FindByUserIDAndDateRange(userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
 
-	url := s.faceRecognitionURL + "/verify"
-	fmt.Printf("[VERIFY] Making request to: %s\n", url)
+	now := time.Now()
+	for _, attendance := range attendances {
+		computeTotals(attendance, now)
+	}
 
-	req, err := http.NewRequest("POST", url, &requestBody)
+	// Mark days covered by approved leave as excused, adding an entry for
+	// leave days without attendance
+	leaveDays, err := s.leaveService.GetApprovedLeaveDays(userID, startDate, endDate)
 	if err != nil {
-		fmt.Printf("[VERIFY] Error creating request: %v\n", err)
-		return false, fmt.Errorf("failed to create HTTP request: %w", err)
+		return nil, err
+	}
+	nonWorkingDays, err := s.calendarService.NonWorkingDays(startDate, endDate)
+	if err != nil {
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	recorded := make(map[string]bool)
+	for _, attendance := range attendances {
+		day := attendance.WorkDate.Format("2006-01-02")
+		recorded[day] = true
+		attendance.DayStatus = models.DayStatusPresent
+		attendance.NonWorkingReason = nonWorkingDays[day]
+		if leave, ok := leaveDays[day]; ok {
+			attendance.Excused = true
+			attendance.Leave = leave
+			if attendance.ClockIn == nil {
+				attendance.DayStatus = models.DayStatusExcused
+			}
+		}
+	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	// Add an entry for every other day up to yesterday, so a missing record
+	// on a working day shows up as an absence rather than a gap. Days before
+	// the user joined or got a shift are left out.
+	today, _ := s.resolveWorkDate(userID, now)
+	scheduleStart, err := s.shiftService.GetScheduleStart(userID)
 	if err != nil {
-		fmt.Printf("[VERIFY] Error making HTTP request: %v\n", err)
-		return false, fmt.Errorf("failed to connect to face recognition service: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	firstDay := absencesStart(startDate, scheduleStart, now.Location())
+	for day := firstDay; !day.After(endDate) && day.Before(today); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		if recorded[key] {
+			continue
+		}
+
+		entry := &models.Attendance{
+			UserID:           userID,
+			WorkDate:         day,
+			DayStatus:        models.DayStatusAbsent,
+			NonWorkingReason: nonWorkingDays[key],
+		}
+		if leave, ok := leaveDays[key]; ok {
+			entry.Excused = true
+			entry.Leave = leave
+			entry.DayStatus = models.DayStatusExcused
+		} else if entry.NonWorkingReason != "" {
+			entry.DayStatus = models.DayStatusNonWorkingDay
+		}
+		computeTotals(entry, now)
+		attendances = append(attendances, entry)
+	}
+	sort.Slice(attendances, func(i, j int) bool {
+		return attendances[i].WorkDate.Before(attendances[j].WorkDate)
+	})
+
+	return attendances, nil
+}
 
-	fmt.Printf("[VERIFY] Response status: %d\n", resp.StatusCode)
+// absencesStart returns the first day to report absences on: the start of the
+// requested range, or the day the user's schedule started if that is later.
+func absencesStart(startDate, scheduleStart time.Time, loc *time.Location) time.Time {
+	first := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, loc)
+	scheduleStart = scheduleStart.In(loc)
+	scheduled := time.Date(scheduleStart.Year(), scheduleStart.Month(), scheduleStart.Day(), 0, 0, 0, 0, loc)
+	if scheduled.After(first) {
+		return scheduled
+	}
+	return first
+}
 
-	// Read response body
-	bodyBytes, err := io.ReadAll(resp.Body)
+// resolveWorkDate returns the logical workday that t belongs to for the user,
+// together with the user's shift if one is assigned. The workday boundary is
+// derived from the shift, falling back to the configured day boundary hour.
+func (s *attendanceService) resolveWorkDate(userID string, t time.Time) (time.Time, *models.Shift) {
+	shift, err := s.shiftService.GetUserShift(userID)
 	if err != nil {
-		fmt.Printf("[VERIFY] Error reading response body: %v\n", err)
-		return false, fmt.Errorf("failed to read response: %w", err)
+		return workDateOf(t, time.Duration(s.dayBoundaryHour)*time.Hour), nil
+	}
+	return workDateOf(t, shiftDayBoundary(shift)), shift
+}
+
+// findOpenAttendance returns the attendance with an open work session on the
+// current workday, or on the previous one when a session crosses the boundary.
+func (s *attendanceService) findOpenAttendance(userID string, now time.Time) (*models.Attendance, error) {
+	workDate, _ := s.resolveWorkDate(userID, now)
+	attendance, err := s.attendanceRepo.FindByUserIDAndWorkDate(userID, workDate)
+	if err == nil && isSessionOpen(attendance) {
+		return attendance, nil
+	}
+
+	previous, prevErr := s.attendanceRepo.FindByUserIDAndWorkDate(userID, workDate.AddDate(0, 0, -1))
+	if prevErr == nil && isSessionOpen(previous) {
+		return previous, nil
+	}
+
+	if err == nil && attendanceState(attendance) == models.AttendanceStateClockedOut {
+		return nil, ErrAlreadyClockedOut
+	}
+	return nil, ErrNotClockedIn
+}
+
+func (s *attendanceService) addPunch(attendance *models.Attendance, punchType string, at time.Time, photo, location string) error {
+	punch := &models.AttendancePunch{
+		ID:           uuid.New().String(),
+		AttendanceID: attendance.ID,
+		UserID:       attendance.UserID,
+		Type:         punchType,
+		PunchedAt:    at,
+		Photo:        photo,
+		Location:     location,
+		CreatedAt:    time.Now(),
+	}
+	if err := s.attendanceRepo.CreatePunch(punch); err != nil {
+		return err
+	}
+
+	attendance.Punches = append(attendance.Punches, *punch)
+	return nil
+}
+
+// backfillPunches stores punches for attendance recorded before punches
+// existed, so that later sessions are added on top of the original one.
+func (s *attendanceService) backfillPunches(attendance *models.Attendance) error {
+	if len(attendance.Punches) > 0 || attendance.ClockIn == nil {
+		return nil
+	}
+
+	if err := s.addPunch(attendance, models.PunchTypeClockIn, *attendance.ClockIn, attendance.ClockInPhoto, attendance.ClockInLocation); err != nil {
+		return err
+	}
+	if attendance.ClockOut != nil {
+		return s.addPunch(attendance, models.PunchTypeClockOut, *attendance.ClockOut, attendance.ClockOutPhoto, attendance.ClockOutLocation)
 	}
+	return nil
+}
+
+// lastPunchType returns the type of the latest punch, derived from the
+// clock-in/out columns for attendance recorded before punches existed.
+func lastPunchType(attendance *models.Attendance) string {
+	if n := len(attendance.Punches); n > 0 {
+		return attendance.Punches[n-1].Type
+	}
+	if attendance.ClockOut != nil {
+		return models.PunchTypeClockOut
+	}
+	if attendance.ClockIn != nil {
+		return models.PunchTypeClockIn
+	}
+	return ""
+}
+
+func isSessionOpen(attendance *models.Attendance) bool {
+	state := attendanceState(attendance)
+	return state == models.AttendanceStateClockedIn || state == models.AttendanceStateOnBreak
+}
+
+// attendanceState derives the state of the day from its latest punch.
+func attendanceState(attendance *models.Attendance) string {
+	if attendance == nil {
+		return models.AttendanceStateNotStarted
+	}
+
+	switch lastPunchType(attendance) {
+	case models.PunchTypeClockIn, models.PunchTypeBreakEnd:
+		return models.AttendanceStateClockedIn
+	case models.PunchTypeBreakStart:
+		return models.AttendanceStateOnBreak
+	case models.PunchTypeClockOut:
+		return models.AttendanceStateClockedOut
+	default:
+		return models.AttendanceStateNotStarted
+	}
+}
+
+// nextAttendanceState validates a punch against the current state of the day
+// and returns the state it leads to.
+func nextAttendanceState(attendance *models.Attendance, punchType string) (string, error) {
+	state := attendanceState(attendance)
+	if next, ok := attendanceTransitions[state][punchType]; ok {
+		return next, nil
+	}
+
+	switch punchType {
+	case models.PunchTypeClockIn:
+		return "", ErrAlreadyClockedIn
+	case models.PunchTypeClockOut:
+		if state == models.AttendanceStateClockedOut {
+			return "", ErrAlreadyClockedOut
+		}
+		return "", ErrNotClockedIn
+	case models.PunchTypeBreakStart:
+		if state == models.AttendanceStateOnBreak {
+			return "", ErrAlreadyOnBreak
+		}
+		return "", ErrNotClockedIn
+	case models.PunchTypeBreakEnd:
+		if state == models.AttendanceStateClockedIn {
+			return "", ErrNotOnBreak
+		}
+		return "", ErrNotClockedIn
+	default:
+		return "", fmt.Errorf("unknown punch type: %s", punchType)
+	}
+}
 
-	fmt.Printf("[VERIFY] Response body: %s\n", string(bodyBytes))
+// computeTotals sums the worked and break time of the day from its punches and
+// sets its current state. A session or break that is still open is counted up
+// to now.
+func computeTotals(attendance *models.Attendance, now time.Time) {
+	attendance.State = attendanceState(attendance)
+
+	punches := attendance.Punches
+	if len(punches) == 0 && attendance.ClockIn != nil {
+		punches = []models.AttendancePunch{{Type: models.PunchTypeClockIn, PunchedAt: *attendance.ClockIn}}
+		if attendance.ClockOut != nil {
+			punches = append(punches, models.AttendancePunch{Type: models.PunchTypeClockOut, PunchedAt: *attendance.ClockOut})
+		}
+	}
 
-	// Check if response is error (non-200 status)
-	if resp.StatusCode != http.StatusOK {
-		var errorResp map[string]interface{}
-		if err := json.Unmarshal(bodyBytes, &errorResp); err == nil {
-			if errorMsg, ok := errorResp["error"].(string); ok {
-				fmt.Printf("[VERIFY] Error from face recognition service: %s\n", errorMsg)
-				return false, fmt.Errorf("face recognition service error: %s", errorMsg)
+	var worked, onBreak time.Duration
+	var workStart, breakStart *time.Time
+	for _, punch := range punches {
+		at := punch.PunchedAt
+		if workStart != nil && punch.Type != models.PunchTypeClockIn {
+			worked += at.Sub(*workStart)
+			workStart = nil
+		}
+		if breakStart != nil && punch.Type != models.PunchTypeBreakStart {
+			onBreak += at.Sub(*breakStart)
+			breakStart = nil
+		}
+
+		switch punch.Type {
+		case models.PunchTypeClockIn, models.PunchTypeBreakEnd:
+			if workStart == nil {
+				workStart = &at
 			}
-			if message, ok := errorResp["message"].(string); ok {
-				fmt.Printf("[VERIFY] Message from face recognition service: %s\n", message)
-				return false, fmt.Errorf("face recognition service error: %s", message)
+		case models.PunchTypeBreakStart:
+			if breakStart == nil {
+				breakStart = &at
 			}
 		}
-		errorBody := string(bodyBytes)
-		if errorBody == "" {
-			errorBody = "(empty response body)"
+	}
+	if workStart != nil {
+		worked += now.Sub(*workStart)
+	}
+	if breakStart != nil {
+		onBreak += now.Sub(*breakStart)
+	}
+
+	attendance.WorkedMinutes = int(worked.Minutes())
+	attendance.BreakMinutes = int(onBreak.Minutes())
+}
+
+// checkGeofence applies the geofence policy to a clock-in position. It reports
+// whether the punch lies outside every office assigned to the user, and fails
+// when the policy rejects such punches. Users without offices are not fenced.
+func (s *attendanceService) checkGeofence(userID string, coords *Coordinates) (*GeofenceResult, bool, error) {
+	if s.geofenceMode == GeofenceModeOff {
+		return nil, false, nil
+	}
+
+	var result *GeofenceResult
+	if coords != nil {
+		nearest, err := s.officeService.CheckGeofence(userID, *coords)
+		if err != nil {
+			return nil, false, err
 		}
-		fmt.Printf("[VERIFY] Face recognition service returned status %d with body: %s\n", resp.StatusCode, errorBody)
+		if nearest == nil {
+			return nil, false, nil
+		}
+		result = nearest
+	} else {
+		offices, err := s.officeService.GetUserOffices(userID)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(offices) == 0 {
+			return nil, false, nil
+		}
+	}
 
-		// Provide more helpful error message based on status code
-		switch resp.StatusCode {
-		case http.StatusForbidden:
-			return false, fmt.Errorf("access forbidden (403). Check CORS configuration and ensure face recognition service is running on the correct port")
-		case http.StatusNotFound:
-			return false, fmt.Errorf("endpoint not found (404). Check if face recognition service URL is correct: %s", url)
-		case http.StatusInternalServerError:
-			return false, fmt.Errorf("internal server error (500) from face recognition service")
-		default:
-			return false, fmt.Errorf("face recognition service returned status %d: %s", resp.StatusCode, errorBody)
+	if result != nil && result.Inside {
+		return result, false, nil
+	}
+	if s.geofenceMode == GeofenceModeReject {
+		if result == nil {
+			return nil, false, fmt.Errorf("%w: location coordinates are required to clock in", ErrOutsideGeofence)
 		}
+		return nil, false, fmt.Errorf("%w: you are %.0f meters from %s", ErrOutsideGeofence, result.DistanceMeters, result.Office.Name)
 	}
+	return result, true, nil
+}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(bodyBytes, &result); err != nil {
-		fmt.Printf("[VERIFY] Error decoding JSON: %v\n", err)
-		return false, fmt.Errorf("failed to decode response: %w", err)
+// livenessRank orders liveness results from strongest to weakest.
+var livenessRank = map[string]int{
+	models.LivenessResultPassed:  1,
+	models.LivenessResultMissing: 2,
+	models.LivenessResultFailed:  3,
+}
+
+// applyLiveness records the liveness result of a clock-in. A day with several
+// sessions keeps its weakest result, so one failed check stays visible.
+func applyLiveness(attendance *models.Attendance, liveness *LivenessResult) {
+	if liveness == nil || livenessRank[liveness.Result] < livenessRank[attendance.LivenessResult] {
+		return
 	}
+	attendance.LivenessResult = liveness.Result
+	attendance.LivenessScore = liveness.Score
+	attendance.LivenessChallengeID = liveness.ChallengeID
+}
 
-	verified, ok := result["verified"].(bool)
-	if !ok {
-		fmt.Printf("[VERIFY] Invalid response format - 'verified' field missing or not bool\n")
-		fmt.Printf("[VERIFY] Response keys: %v\n", result)
-		return false, fmt.Errorf("invalid response from face recognition service: 'verified' field missing or not boolean")
+// applyPhotoCheck flags the attendance when a punch photo needs review. The
+// flags of every photo of the day are kept.
+func applyPhotoCheck(attendance *models.Attendance, check *PhotoCheck) {
+	if !check.Flagged() {
+		return
+	}
+	flags := []string{}
+	if attendance.PhotoFlags != "" {
+		flags = strings.Split(attendance.PhotoFlags, ",")
+	}
+	for _, flag := range check.Flags {
+		known := false
+		for _, existing := range flags {
+			if existing == flag {
+				known = true
+				break
+			}
+		}
+		if !known {
+			flags = append(flags, flag)
+		}
 	}
+	attendance.PhotoFlagged = true
+	attendance.PhotoFlags = strings.Join(flags, ",")
+}
 
-	similarity, _ := result["similarity"].(float64)
-	threshold, _ := result["threshold"].(float64)
+// recordPhoto keeps the fingerprint of an accepted punch photo. The punch is
+// already saved, so a failure is only logged.
+func (s *attendanceService) recordPhoto(attendance *models.Attendance, punchType string, check *PhotoCheck) {
+	if err := s.photoService.Record(attendance.UserID, attendance.ID, punchType, check); err != nil {
+		log.Printf("Failed to record %s photo of attendance %s: %v", punchType, attendance.ID, err)
+	}
+}
 
-	fmt.Printf("[VERIFY] Verification result: verified=%v, similarity=%.4f, threshold=%.4f\n", verified, similarity, threshold)
+// applyClockInPosition records the clock-in coordinates and the distance to the nearest office.
+func applyClockInPosition(attendance *models.Attendance, coords *Coordinates, geofence *GeofenceResult, outside bool) {
+	attendance.ClockInLatitude = nil
+	attendance.ClockInLongitude = nil
+	attendance.ClockInOfficeID = ""
+	attendance.ClockInDistanceMeters = nil
+	attendance.OutsideGeofence = outside
+
+	if coords != nil {
+		latitude, longitude := coords.Latitude, coords.Longitude
+		attendance.ClockInLatitude = &latitude
+		attendance.ClockInLongitude = &longitude
+	}
+	if geofence != nil {
+		distance := geofence.DistanceMeters
+		attendance.ClockInOfficeID = geofence.Office.ID
+		attendance.ClockInDistanceMeters = &distance
+	}
+}
 
-	return verified, nil
+// workDateOf returns midnight of the day t belongs to when days start at the
+// given offset past midnight, or before it when the offset is negative.
+func workDateOf(t time.Time, boundary time.Duration) time.Time {
+	shifted := t.Add(-boundary)
+	return time.Date(shifted.Year(), shifted.Month(), shifted.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// applyClockInStatus classifies the clock-in against the user's shift.
+// Users without a shift are left unclassified.
+func applyClockInStatus(attendance *models.Attendance, shift *models.Shift, clockIn time.Time) {
+	if shift == nil {
+		attendance.ShiftID = ""
+		attendance.ClockInStatus = ""
+		attendance.LateMinutes = 0
+		return
+	}
+
+	attendance.ShiftID = shift.ID
+	attendance.ClockInStatus, attendance.LateMinutes = classifyClockIn(shift, attendance.WorkDate, clockIn)
+}
+
+// applyClockOutStatus classifies the clock-out against the shift recorded at clock-in.
+func (s *attendanceService) applyClockOutStatus(attendance *models.Attendance, clockOut time.Time) {
+	if attendance.ShiftID == "" || attendance.ClockIn == nil {
+		return
+	}
+	shift, err := s.shiftService.GetShift(attendance.ShiftID)
+	if err != nil {
+		return
+	}
+
+	attendance.ClockOutStatus, attendance.EarlyLeaveMinutes, attendance.OvertimeMinutes = classifyClockOut(shift, attendance.WorkDate, clockOut)
+}
+
+// verifyFace checks the face sample against the user's enrolled face. It fails
+// with ErrFaceMismatch when the face does not match, and reports false without
+// an error when the punch was accepted unverified in degraded mode. The
+// recorded attempt, if any, is returned to attach the stored photo to.
+func (s *attendanceService) verifyFace(face FaceSample, userID string) (bool, string, error) {
+	threshold, err := s.thresholdService.Resolve(userID, face.OfficeID)
+	if err != nil {
+		return false, "", err
+	}
+	face.Threshold = threshold
+
+	result, err := s.faceVerifier.Verify(userID, face)
+	if err != nil {
+		return false, "", err
+	}
+	if result.Degraded {
+		return false, result.AttemptID, nil
+	}
+
+	if !result.Verified {
+		return false, result.AttemptID, ErrFaceMismatch
+	}
+	return true, result.AttemptID, nil
+}
+
+// rejectPunch records a punch refused before the face was compared, so the
+// attempt log also shows geofence, replay and liveness rejections.
+func (s *attendanceService) rejectPunch(userID string, face FaceSample, reason error) error {
+	s.attemptService.RecordRejected(userID, face, reason)
+	return reason
+}
+
+// savePhoto stores the photo of an accepted punch and links it to the
+// verification attempt.
+func (s *attendanceService) savePhoto(photoPath, attemptID string) (string, error) {
+	photoURL, err := storePhoto(s.cloudinaryService, photoPath, "attendance")
+	if err != nil {
+		return "", err
+	}
+	if attemptID != "" {
+		s.attemptService.AttachPhoto(attemptID, photoURL)
+	}
+	return photoURL, nil
 }
 
-func (s *attendanceService) savePhoto(photoPath string) (string, error) {
+// storePhoto uploads a photo to the given Cloudinary folder, falling back to a
+// local path when Cloudinary is not configured.
+func storePhoto(cloudinaryService CloudinaryService, photoPath, folder string) (string, error) {
 	// Upload to Cloudinary if available
-	if s.cloudinaryService != nil {
-		photoURL, err := s.cloudinaryService.UploadImage(photoPath, "attendance")
+	if cloudinaryService != nil {
+		photoURL, err := cloudinaryService.UploadImage(photoPath, folder)
 		if err != nil {
 			return "", fmt.Errorf("failed to upload to Cloudinary: %w", err)
 		}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"errors"
+	"face-verification-backend/internal/models"
+	"testing"
+	"time"
+)
+
+// attendanceWithPunches builds an attendance from punch types made one hour
+// apart starting at start.
+func attendanceWithPunches(start time.Time, punchTypes ...string) *models.Attendance {
+	attendance := &models.Attendance{}
+	for i, punchType := range punchTypes {
+		attendance.Punches = append(attendance.Punches, models.AttendancePunch{
+			Type:      punchType,
+			PunchedAt: start.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	return attendance
+}
+
+func TestNextAttendanceState(t *testing.T) {
+	start := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		punches   []string
+		punchType string
+		want      string
+		wantErr   error
+	}{
+		{"clock in to start the day", nil, models.PunchTypeClockIn, models.AttendanceStateClockedIn, nil},
+		{"clock out before clocking in", nil, models.PunchTypeClockOut, "", ErrNotClockedIn},
+		{"break before clocking in", nil, models.PunchTypeBreakStart, "", ErrNotClockedIn},
+		{"end break before clocking in", nil, models.PunchTypeBreakEnd, "", ErrNotClockedIn},
+		{"clock in twice", []string{models.PunchTypeClockIn}, models.PunchTypeClockIn, "", ErrAlreadyClockedIn},
+		{"start a break", []string{models.PunchTypeClockIn}, models.PunchTypeBreakStart, models.AttendanceStateOnBreak, nil},
+		{"end a break that was not started", []string{models.PunchTypeClockIn}, models.PunchTypeBreakEnd, "", ErrNotOnBreak},
+		{"clock out", []string{models.PunchTypeClockIn}, models.PunchTypeClockOut, models.AttendanceStateClockedOut, nil},
+		{"start a break twice", []string{models.PunchTypeClockIn, models.PunchTypeBreakStart}, models.PunchTypeBreakStart, "", ErrAlreadyOnBreak},
+		{"end a break", []string{models.PunchTypeClockIn, models.PunchTypeBreakStart}, models.PunchTypeBreakEnd, models.AttendanceStateClockedIn, nil},
+		{"clock out on break", []string{models.PunchTypeClockIn, models.PunchTypeBreakStart}, models.PunchTypeClockOut, models.AttendanceStateClockedOut, nil},
+		{"clock out twice", []string{models.PunchTypeClockIn, models.PunchTypeClockOut}, models.PunchTypeClockOut, "", ErrAlreadyClockedOut},
+		{"break after clocking out", []string{models.PunchTypeClockIn, models.PunchTypeClockOut}, models.PunchTypeBreakStart, "", ErrNotClockedIn},
+		{"start another session", []string{models.PunchTypeClockIn, models.PunchTypeClockOut}, models.PunchTypeClockIn, models.AttendanceStateClockedIn, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attendance *models.Attendance
+			if tt.punches != nil {
+				attendance = attendanceWithPunches(start, tt.punches...)
+			}
+			got, err := nextAttendanceState(attendance, tt.punchType)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("state = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextAttendanceStateUnknownPunch(t *testing.T) {
+	if _, err := nextAttendanceState(nil, "lunch"); err == nil {
+		t.Fatal("expected an error for an unknown punch type")
+	}
+}
+
+func TestComputeTotals(t *testing.T) {
+	start := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	clockIn := start
+	clockOut := start.Add(9 * time.Hour)
+
+	tests := []struct {
+		name        string
+		attendance  *models.Attendance
+		now         time.Time
+		wantState   string
+		wantWorked  int
+		wantOnBreak int
+	}{
+		{
+			name:       "open session counts up to now",
+			attendance: attendanceWithPunches(start, models.PunchTypeClockIn),
+			now:        start.Add(90 * time.Minute),
+			wantState:  models.AttendanceStateClockedIn,
+			wantWorked: 90,
+		},
+		{
+			name:       "closed session",
+			attendance: attendanceWithPunches(start, models.PunchTypeClockIn, models.PunchTypeClockOut),
+			now:        start.Add(5 * time.Hour),
+			wantState:  models.AttendanceStateClockedOut,
+			wantWorked: 60,
+		},
+		{
+			name:        "break is not worked time",
+			attendance:  attendanceWithPunches(start, models.PunchTypeClockIn, models.PunchTypeBreakStart, models.PunchTypeBreakEnd, models.PunchTypeClockOut),
+			now:         start.Add(5 * time.Hour),
+			wantState:   models.AttendanceStateClockedOut,
+			wantWorked:  120,
+			wantOnBreak: 60,
+		},
+		{
+			name:        "open break counts up to now",
+			attendance:  attendanceWithPunches(start, models.PunchTypeClockIn, models.PunchTypeBreakStart),
+			now:         start.Add(90 * time.Minute),
+			wantState:   models.AttendanceStateOnBreak,
+			wantWorked:  60,
+			wantOnBreak: 30,
+		},
+		{
+			name:        "clock out during a break closes it",
+			attendance:  attendanceWithPunches(start, models.PunchTypeClockIn, models.PunchTypeBreakStart, models.PunchTypeClockOut),
+			now:         start.Add(5 * time.Hour),
+			wantState:   models.AttendanceStateClockedOut,
+			wantWorked:  60,
+			wantOnBreak: 60,
+		},
+		{
+			name:       "several sessions",
+			attendance: attendanceWithPunches(start, models.PunchTypeClockIn, models.PunchTypeClockOut, models.PunchTypeClockIn, models.PunchTypeClockOut),
+			now:        start.Add(5 * time.Hour),
+			wantState:  models.AttendanceStateClockedOut,
+			wantWorked: 120,
+		},
+		{
+			name:       "legacy attendance without punches",
+			attendance: &models.Attendance{ClockIn: &clockIn, ClockOut: &clockOut},
+			now:        start.Add(10 * time.Hour),
+			wantState:  models.AttendanceStateClockedOut,
+			wantWorked: 540,
+		},
+		{
+			name:       "not started",
+			attendance: &models.Attendance{},
+			now:        start,
+			wantState:  models.AttendanceStateNotStarted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			computeTotals(tt.attendance, tt.now)
+			if tt.attendance.State != tt.wantState {
+				t.Errorf("State = %q, want %q", tt.attendance.State, tt.wantState)
+			}
+			if tt.attendance.WorkedMinutes != tt.wantWorked {
+				t.Errorf("WorkedMinutes = %d, want %d", tt.attendance.WorkedMinutes, tt.wantWorked)
+			}
+			if tt.attendance.BreakMinutes != tt.wantOnBreak {
+				t.Errorf("BreakMinutes = %d, want %d", tt.attendance.BreakMinutes, tt.wantOnBreak)
+			}
+		})
+	}
+}
+
+func TestScheduledClockOut(t *testing.T) {
+	workDate := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	day := &models.Shift{StartTime: "08:00", EndTime: "17:00"}
+	night := &models.Shift{StartTime: "22:00", EndTime: "06:00"}
+	at := func(day, hour int) time.Time {
+		return time.Date(2024, 3, day, hour, 0, 0, 0, time.UTC)
+	}
+	maxSession := 12 * time.Hour
+
+	tests := []struct {
+		name       string
+		shift      *models.Shift
+		attendance *models.Attendance
+		want       time.Time
+	}{
+		{"no shift", nil, attendanceWithPunches(at(4, 8), models.PunchTypeClockIn), at(4, 20)},
+		{"shift end", day, attendanceWithPunches(at(4, 8), models.PunchTypeClockIn), at(4, 17)},
+		{"overnight shift end", night, attendanceWithPunches(at(4, 22), models.PunchTypeClockIn), at(5, 6)},
+		{"session started after the shift ended", day, attendanceWithPunches(at(4, 20), models.PunchTypeClockIn), at(5, 8)},
+		{"session started exactly at the shift end", day, attendanceWithPunches(at(4, 17), models.PunchTypeClockIn), at(5, 5)},
+		{
+			name:       "second session after the shift ended",
+			shift:      day,
+			attendance: attendanceWithPunches(at(4, 16), models.PunchTypeClockIn, models.PunchTypeClockOut, models.PunchTypeClockIn, models.PunchTypeBreakStart),
+			want:       at(5, 6),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.attendance.WorkDate = workDate
+			clockIn := tt.attendance.Punches[0].PunchedAt
+			tt.attendance.ClockIn = &clockIn
+			if got := scheduledClockOut(tt.attendance, tt.shift, maxSession); !got.Equal(tt.want) {
+				t.Errorf("scheduledClockOut() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyAutoClockOut(t *testing.T) {
+	workDate := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	shift := &models.Shift{StartTime: "08:00", EndTime: "17:00", GracePeriodMinutes: 10}
+
+	tests := []struct {
+		name           string
+		clockOut       time.Time
+		wantStatus     string
+		wantEarlyLeave int
+	}{
+		{"at the shift end", time.Date(2024, 3, 4, 17, 0, 0, 0, time.UTC), models.AttendanceStatusOnTime, 0},
+		{"long after the shift end", time.Date(2024, 3, 5, 8, 0, 0, 0, time.UTC), models.AttendanceStatusOnTime, 0},
+		{"at the last punch before the shift end", time.Date(2024, 3, 4, 16, 0, 0, 0, time.UTC), models.AttendanceStatusEarlyLeave, 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, earlyLeave := classifyAutoClockOut(shift, workDate, tt.clockOut)
+			if status != tt.wantStatus || earlyLeave != tt.wantEarlyLeave {
+				t.Errorf("classifyAutoClockOut() = %q, %d, want %q, %d", status, earlyLeave, tt.wantStatus, tt.wantEarlyLeave)
+			}
+		})
+	}
+}
+
+func TestAbsencesStart(t *testing.T) {
+	day := func(d, hour int) time.Time {
+		return time.Date(2024, 3, d, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name          string
+		startDate     time.Time
+		scheduleStart time.Time
+		want          time.Time
+	}{
+		{"scheduled before the range", day(4, 0), day(1, 9), day(4, 0)},
+		{"scheduled within the range", day(1, 0), day(4, 15), day(4, 0)},
+		{"scheduled on the first day", day(4, 0), day(4, 9), day(4, 0)},
+		{"scheduled after the range", day(1, 0), day(20, 9), day(20, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := absencesStart(tt.startDate, tt.scheduleStart, time.UTC); !got.Equal(tt.want) {
+				t.Errorf("absencesStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,49 +4,274 @@ import (
 	"errors"
 	"face-verification-backend/internal/models"
 	"face-verification-backend/internal/repositories"
+	"log"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+// Defaults for the lifetime of access and refresh tokens.
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// refreshTokenPrefix starts every refresh token.
+const refreshTokenPrefix = "fvr_"
+
+var (
+	ErrInvalidToken        = errors.New("Token tidak valid")
+	ErrInvalidRefreshToken = errors.New("Refresh token tidak valid")
+	ErrSessionRevoked      = errors.New("Sesi telah berakhir, silakan login kembali")
+	ErrSessionNotFound     = errors.New("Sesi tidak ditemukan")
+)
+
+// AccessClaims is what an access token says about its holder. The role and
+// permissions are those of the user when the token was issued, so changes
+// apply from the next refresh.
+type AccessClaims struct {
+	UserID      string
+	SessionID   string
+	Role        string
+	Permissions []string
+}
+
+// HasPermission reports whether the permission was granted.
+func (c *AccessClaims) HasPermission(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenPair is issued on login and on every refresh. The refresh token can be
+// used once; the next one comes with the new access token.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int // seconds until the access token expires
+	SessionID    string
+}
+
 type AuthService interface {
-	Login(email, password string) (*models.User, string, error)
+	Login(email, password, userAgent, clientIP string) (*models.User, *TokenPair, error)
+	// Refresh exchanges a refresh token for a new token pair. Presenting a
+	// refresh token that was already exchanged revokes the session, since
+	// either the client or whoever copied the token is not its owner.
+	Refresh(refreshToken, userAgent, clientIP string) (*TokenPair, error)
+	Logout(sessionID string) error
 	Register(name, email, password, employeeID string) error
-	ForgotPassword(email string) error
-	ValidateToken(tokenString string) (string, error)
+	// ValidateToken returns the claims of an access token. Tokens of revoked
+	// sessions are rejected with ErrSessionRevoked.
+	ValidateToken(tokenString string) (*AccessClaims, error)
+	GetSessions(userID string) ([]*models.AuthSession, error)
+	RevokeSession(userID, sessionID string) error
+	// RevokeAllSessions signs the user out on every device.
+	RevokeAllSessions(userID string) (int64, error)
+	// RevokeOtherSessions signs the user out on every device but the one
+	// holding the given session.
+	RevokeOtherSessions(userID, sessionID string) (int64, error)
+	DeleteExpiredSessions(before time.Time) (int64, error)
 }
 
 type authService struct {
-	userRepo repositories.UserRepository
-	jwtSecret string
+	userRepo        repositories.UserRepository
+	sessionRepo     repositories.AuthSessionRepository
+	jwtSecret       string
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
 }
 
-func NewAuthService(userRepo repositories.UserRepository, jwtSecret string) AuthService {
+func NewAuthService(userRepo repositories.UserRepository, sessionRepo repositories.AuthSessionRepository, jwtSecret string, accessTokenTTL, refreshTokenTTL time.Duration) AuthService {
+	if accessTokenTTL <= 0 {
+		accessTokenTTL = DefaultAccessTokenTTL
+	}
+	if refreshTokenTTL <= 0 {
+		refreshTokenTTL = DefaultRefreshTokenTTL
+	}
 	return &authService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
+		userRepo:        userRepo,
+		sessionRepo:     sessionRepo,
+		jwtSecret:       jwtSecret,
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
 	}
 }
 
-func (s *authService) Login(email, password string) (*models.User, string, error) {
+func (s *authService) Login(email, password, userAgent, clientIP string) (*models.User, *TokenPair, error) {
 	user, err := s.userRepo.FindByEmail(email)
 	if err != nil {
-		return nil, "", errors.New("Email atau password salah")
+		return nil, nil, errors.New("Email atau password salah")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, "", errors.New("Email atau password salah")
+		return nil, nil, errors.New("Email atau password salah")
+	}
+
+	refreshToken, _, err := generateAPIKey(refreshTokenPrefix)
+	if err != nil {
+		return nil, nil, errors.New("Gagal membuat token")
+	}
+
+	now := time.Now()
+	session := &models.AuthSession{
+		ID:               uuid.New().String(),
+		UserID:           user.ID,
+		RefreshTokenHash: hashAPIKey(refreshToken),
+		UserAgent:        truncate(userAgent, 255),
+		ClientIP:         clientIP,
+		ExpiresAt:        now.Add(s.refreshTokenTTL),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, nil, errors.New("Gagal membuat sesi")
+	}
+
+	tokens, err := s.issueTokens(user, session, refreshToken, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+func (s *authService) Refresh(refreshToken, userAgent, clientIP string) (*TokenPair, error) {
+	if _, ok := apiKeyPrefix(refreshToken, refreshTokenPrefix); !ok {
+		return nil, ErrInvalidRefreshToken
+	}
+	hash := hashAPIKey(refreshToken)
+	now := time.Now()
+
+	session, err := s.sessionRepo.FindByRefreshTokenHash(hash)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, s.detectReuse(hash, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !session.IsActive(now) {
+		return nil, ErrSessionRevoked
+	}
+	user, err := s.userRepo.FindByID(session.UserID)
+	if err != nil {
+		return nil, ErrSessionRevoked
+	}
+
+	next, _, err := generateAPIKey(refreshTokenPrefix)
+	if err != nil {
+		return nil, errors.New("Gagal membuat token")
+	}
+	session.PreviousTokenHash = hash
+	session.RefreshTokenHash = hashAPIKey(next)
+	session.ExpiresAt = now.Add(s.refreshTokenTTL)
+	session.LastRefreshedAt = &now
+	if userAgent != "" {
+		session.UserAgent = truncate(userAgent, 255)
+	}
+	if clientIP != "" {
+		session.ClientIP = clientIP
+	}
+	session.UpdatedAt = now
+	rotated, err := s.sessionRepo.Rotate(session, hash)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Another request exchanged the same token first
+		return nil, s.detectReuse(hash, now)
+	}
+
+	return s.issueTokens(user, session, next, now)
+}
+
+// detectReuse revokes the session a rotated refresh token belonged to.
+func (s *authService) detectReuse(hash string, now time.Time) error {
+	session, err := s.sessionRepo.FindByPreviousTokenHash(hash)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidRefreshToken
 	}
+	if err != nil {
+		return err
+	}
+	if session.RevokedAt == nil {
+		log.Printf("Refresh token of session %s was reused, revoking the session of user %s", session.ID, session.UserID)
+		if err := s.revoke(session, now); err != nil {
+			return err
+		}
+	}
+	return ErrSessionRevoked
+}
 
-	token, err := s.generateToken(user.ID)
+func (s *authService) Logout(sessionID string) error {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrSessionNotFound
+	}
 	if err != nil {
-		return nil, "", errors.New("Gagal membuat token")
+		return err
+	}
+	if session.RevokedAt != nil {
+		return nil
+	}
+	return s.revoke(session, time.Now())
+}
+
+func (s *authService) GetSessions(userID string) ([]*models.AuthSession, error) {
+	return s.sessionRepo.FindActiveByUserID(userID, time.Now())
+}
+
+func (s *authService) RevokeSession(userID, sessionID string) error {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && session.UserID != userID) {
+		return ErrSessionNotFound
 	}
+	if err != nil {
+		return err
+	}
+	if session.RevokedAt != nil {
+		return nil
+	}
+	return s.revoke(session, time.Now())
+}
+
+func (s *authService) RevokeAllSessions(userID string) (int64, error) {
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		return 0, errors.New("User tidak ditemukan")
+	}
+	return s.sessionRepo.RevokeAllByUserID(userID, time.Now())
+}
+
+func (s *authService) RevokeOtherSessions(userID, sessionID string) (int64, error) {
+	return s.sessionRepo.RevokeOthersByUserID(userID, sessionID, time.Now())
+}
+
+func (s *authService) DeleteExpiredSessions(before time.Time) (int64, error) {
+	return s.sessionRepo.DeleteExpired(before)
+}
 
-	return user, token, nil
+func (s *authService) revoke(session *models.AuthSession, now time.Time) error {
+	session.RevokedAt = &now
+	session.UpdatedAt = now
+	return s.sessionRepo.Update(session)
+}
+
+func (s *authService) issueTokens(user *models.User, session *models.AuthSession, refreshToken string, now time.Time) (*TokenPair, error) {
+	accessToken, err := s.generateToken(user, session.ID, now)
+	if err != nil {
+		return nil, errors.New("Gagal membuat token")
+	}
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.accessTokenTTL.Seconds()),
+		SessionID:    session.ID,
+	}, nil
 }
 
 func (s *authService) Register(name, email, password, employeeID string) error {
@@ -75,6 +300,7 @@ func (s *authService) Register(name, email, password, employeeID string) error {
 		Name:       name,
 		Email:      email,
 		Password:   string(hashedPassword),
+		Role:       models.RoleEmployee,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 	}
@@ -96,45 +322,73 @@ func (s *authService) Register(name, email, password, employeeID string) error {
 	return nil
 }
 
-func (s *authService) ForgotPassword(email string) error {
-	// Check if user exists
-	_, err := s.userRepo.FindByEmail(email)
-	if err != nil {
-		return errors.New("Email tidak ditemukan")
-	}
-
-	// TODO: Send password reset email
-	// For now, just return success
-	return nil
-}
-
-func (s *authService) ValidateToken(tokenString string) (string, error) {
+func (s *authService) ValidateToken(tokenString string) (*AccessClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid token")
+			return nil, ErrInvalidToken
 		}
 		return []byte(s.jwtSecret), nil
 	})
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
 
-	if err != nil {
-		return "", err
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	result := &AccessClaims{}
+	result.UserID, _ = claims["user_id"].(string)
+	result.SessionID, _ = claims["sid"].(string)
+	result.Role, _ = claims["role"].(string)
+	if permissions, ok := claims["permissions"].([]interface{}); ok {
+		for _, p := range permissions {
+			if permission, ok := p.(string); ok {
+				result.Permissions = append(result.Permissions, permission)
+			}
+		}
+	}
+	// Tokens issued before sessions existed cannot be revoked, so they are refused
+	if result.UserID == "" || result.SessionID == "" {
+		return nil, ErrInvalidToken
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID := claims["user_id"].(string)
-		return userID, nil
+	session, err := s.sessionRepo.FindByID(result.SessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSessionRevoked
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != result.UserID || session.RevokedAt != nil {
+		return nil, ErrSessionRevoked
 	}
 
-	return "", errors.New("invalid token")
+	return result, nil
 }
 
-func (s *authService) generateToken(userID string) (string, error) {
+func (s *authService) generateToken(user *models.User, sessionID string, now time.Time) (string, error) {
+	role := user.Role
+	if role == "" {
+		role = models.RoleEmployee
+	}
 	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
+		"user_id":     user.ID,
+		"sid":         sessionID,
+		"role":        role,
+		"permissions": user.GrantedPermissions(),
+		"iat":         now.Unix(),
+		"exp":         now.Add(s.accessTokenTTL).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.jwtSecret))
 }
 
+// truncate cuts s to at most n bytes to fit a column.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
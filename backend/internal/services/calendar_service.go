@@ -0,0 +1,317 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/repositories"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reasons a day is not a working day, as returned by NonWorkingDays.
+const (
+	NonWorkingReasonWeekend = "weekend"
+	NonWorkingReasonHoliday = "holiday"
+)
+
+// defaultWorkingWeekdays applies until the working days are configured.
+var defaultWorkingWeekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// ImportResult summarizes an iCalendar import.
+type ImportResult struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+type CalendarService interface {
+	CreateHoliday(date time.Time, name string) (*models.Holiday, error)
+	GetHolidays(startDate, endDate time.Time) ([]*models.Holiday, error)
+	DeleteHoliday(id string) error
+	ImportICS(reader io.Reader) (*ImportResult, error)
+	GetWorkingWeekdays() ([]time.Weekday, error)
+	SetWorkingWeekdays(weekdays []time.Weekday) error
+	NonWorkingDays(startDate, endDate time.Time) (map[string]string, error)
+}
+
+type calendarService struct {
+	calendarRepo repositories.CalendarRepository
+}
+
+func NewCalendarService(calendarRepo repositories.CalendarRepository) CalendarService {
+	return &calendarService{calendarRepo: calendarRepo}
+}
+
+func (s *calendarService) CreateHoliday(date time.Time, name string) (*models.Holiday, error) {
+	holiday := &models.Holiday{
+		ID:        uuid.New().String(),
+		Date:      date,
+		Name:      name,
+		Source:    models.HolidaySourceManual,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := s.calendarRepo.CreateHoliday(holiday); err != nil {
+		return nil, err
+	}
+
+	return holiday, nil
+}
+
+func (s *calendarService) GetHolidays(startDate, endDate time.Time) ([]*models.Holiday, error) {
+	return s.calendarRepo.FindHolidaysByDateRange(startDate, endDate)
+}
+
+func (s *calendarService) DeleteHoliday(id string) error {
+	if _, err := s.calendarRepo.FindHolidayByID(id); err != nil {
+		return fmt.Errorf("holiday not found")
+	}
+	return s.calendarRepo.DeleteHoliday(id)
+}
+
+// ImportICS creates a holiday for every day covered by the events of an
+// iCalendar file. Events already imported are skipped, so the same file can be
+// imported again after it is updated.
+func (s *calendarService) ImportICS(reader io.Reader) (*ImportResult, error) {
+	events, err := parseICSEvents(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{}
+	for _, event := range events {
+		for day := event.start; day.Before(event.end); day = day.AddDate(0, 0, 1) {
+			if event.uid != "" {
+				exists, err := s.calendarRepo.ExistsHolidayByExternalUID(event.uid, day)
+				if err != nil {
+					return nil, err
+				}
+				if exists {
+					result.Skipped++
+					continue
+				}
+			}
+
+			holiday := &models.Holiday{
+				ID:          uuid.New().String(),
+				Date:        day,
+				Name:        event.summary,
+				Source:      models.HolidaySourceICS,
+				ExternalUID: event.uid,
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			}
+			if err := s.calendarRepo.CreateHoliday(holiday); err != nil {
+				return nil, err
+			}
+			result.Imported++
+		}
+	}
+
+	return result, nil
+}
+
+func (s *calendarService) GetWorkingWeekdays() ([]time.Weekday, error) {
+	rules, err := s.calendarRepo.FindWorkingDayRules()
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return defaultWorkingWeekdays, nil
+	}
+
+	weekdays := make([]time.Weekday, 0, len(rules))
+	for _, rule := range rules {
+		if rule.IsWorkingDay {
+			weekdays = append(weekdays, time.Weekday(rule.Weekday))
+		}
+	}
+	return weekdays, nil
+}
+
+func (s *calendarService) SetWorkingWeekdays(weekdays []time.Weekday) error {
+	working := make(map[time.Weekday]bool)
+	for _, weekday := range weekdays {
+		if weekday < time.Sunday || weekday > time.Saturday {
+			return fmt.Errorf("invalid weekday: %d", weekday)
+		}
+		working[weekday] = true
+	}
+
+	rules := make([]*models.WorkingDayRule, 0, 7)
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		rules = append(rules, &models.WorkingDayRule{
+			Weekday:      int(weekday),
+			IsWorkingDay: working[weekday],
+			UpdatedAt:    time.Now(),
+		})
+	}
+	return s.calendarRepo.ReplaceWorkingDayRules(rules)
+}
+
+// NonWorkingDays returns the days in the inclusive range that are not working
+// days, keyed by date (YYYY-MM-DD), with the reason (weekend or holiday).
+func (s *calendarService) NonWorkingDays(startDate, endDate time.Time) (map[string]string, error) {
+	weekdays, err := s.GetWorkingWeekdays()
+	if err != nil {
+		return nil, err
+	}
+	working := make(map[time.Weekday]bool)
+	for _, weekday := range weekdays {
+		working[weekday] = true
+	}
+
+	days := make(map[string]string)
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		if !working[day.Weekday()] {
+			days[day.Format("2006-01-02")] = NonWorkingReasonWeekend
+		}
+	}
+
+	holidays, err := s.calendarRepo.FindHolidaysByDateRange(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	for _, holiday := range holidays {
+		days[holiday.Date.Format("2006-01-02")] = NonWorkingReasonHoliday
+	}
+
+	return days, nil
+}
+
+type icsEvent struct {
+	uid     string
+	summary string
+	start   time.Time
+	end     time.Time // exclusive
+}
+
+// parseICSEvents reads the VEVENTs of an iCalendar file as whole days.
+func parseICSEvents(reader io.Reader) ([]icsEvent, error) {
+	lines, err := unfoldICSLines(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []icsEvent
+	var current *icsEvent
+	for _, line := range lines {
+		name, params, value := splitICSProperty(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &icsEvent{}
+		case name == "END" && value == "VEVENT":
+			if current == nil {
+				continue
+			}
+			if current.start.IsZero() {
+				return nil, fmt.Errorf("calendar event %q has no DTSTART", current.summary)
+			}
+			if !current.end.After(current.start) {
+				current.end = current.start.AddDate(0, 0, 1)
+			}
+			if current.summary == "" {
+				current.summary = "Holiday"
+			}
+			events = append(events, *current)
+			current = nil
+		case current == nil:
+			continue
+		case name == "UID":
+			current.uid = value
+		case name == "SUMMARY":
+			current.summary = unescapeICSText(value)
+		case name == "DTSTART", name == "DTEND":
+			day, err := parseICSDate(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", name, value, err)
+			}
+			if name == "DTSTART" {
+				current.start = day
+			} else {
+				current.end = day
+			}
+		}
+	}
+
+	if len(events) == 0 {
+		return nil, errors.New("no calendar events found")
+	}
+	return events, nil
+}
+
+// unfoldICSLines joins continuation lines, which start with a space or tab.
+func unfoldICSLines(reader io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// splitICSProperty splits "NAME;PARAM=X:VALUE" into its name, parameters and value.
+func splitICSProperty(line string) (string, map[string]string, string) {
+	head, value, found := strings.Cut(line, ":")
+	if !found {
+		return "", nil, ""
+	}
+
+	parts := strings.Split(head, ";")
+	params := make(map[string]string)
+	for _, param := range parts[1:] {
+		if key, val, ok := strings.Cut(param, "="); ok {
+			params[strings.ToUpper(key)] = val
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value
+}
+
+// parseICSDate reads a DATE or DATE-TIME value as a local calendar day.
+func parseICSDate(value string, params map[string]string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, errors.New("too short")
+	}
+
+	loc := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			loc = tz
+		}
+	}
+
+	var t time.Time
+	var err error
+	switch {
+	case len(value) == 8:
+		t, err = time.ParseInLocation("20060102", value, time.Local)
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse("20060102T150405Z", value)
+	default:
+		t, err = time.ParseInLocation("20060102T150405", value, loc)
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local), nil
+}
+
+func unescapeICSText(value string) string {
+	replacer := strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return replacer.Replace(value)
+}
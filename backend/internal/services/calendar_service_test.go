@@ -0,0 +1,107 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseICSEvents(t *testing.T) {
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name    string
+		ics     string
+		want    []icsEvent
+		wantErr bool
+	}{
+		{
+			name: "all-day event",
+			ics: "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:new-year\r\nSUMMARY:New Year\r\n" +
+				"DTSTART;VALUE=DATE:20240101\r\nDTEND;VALUE=DATE:20240102\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+			want: []icsEvent{{uid: "new-year", summary: "New Year", start: day(2024, 1, 1), end: day(2024, 1, 2)}},
+		},
+		{
+			name: "multi-day event",
+			ics: "BEGIN:VEVENT\nSUMMARY:Eid al-Fitr\nDTSTART;VALUE=DATE:20240410\n" +
+				"DTEND;VALUE=DATE:20240412\nEND:VEVENT\n",
+			want: []icsEvent{{summary: "Eid al-Fitr", start: day(2024, 4, 10), end: day(2024, 4, 12)}},
+		},
+		{
+			name: "missing end lasts one day",
+			ics:  "BEGIN:VEVENT\nSUMMARY:Labour Day\nDTSTART;VALUE=DATE:20240501\nEND:VEVENT\n",
+			want: []icsEvent{{summary: "Labour Day", start: day(2024, 5, 1), end: day(2024, 5, 2)}},
+		},
+		{
+			name: "folded and escaped summary",
+			ics:  "BEGIN:VEVENT\nSUMMARY:Independence\n  Day\\, national holiday\nDTSTART:20240817\nEND:VEVENT\n",
+			want: []icsEvent{{summary: "Independence Day, national holiday", start: day(2024, 8, 17), end: day(2024, 8, 18)}},
+		},
+		{
+			name: "missing summary",
+			ics:  "BEGIN:VEVENT\nDTSTART:20241225\nEND:VEVENT\n",
+			want: []icsEvent{{summary: "Holiday", start: day(2024, 12, 25), end: day(2024, 12, 26)}},
+		},
+		{
+			name: "date-time in a time zone",
+			ics:  "BEGIN:VEVENT\nSUMMARY:Company Day\nDTSTART;TZID=UTC:20240603T120000\nEND:VEVENT\n",
+			want: []icsEvent{{summary: "Company Day", start: day(2024, 6, 3), end: day(2024, 6, 4)}},
+		},
+		{
+			name: "properties outside events are ignored",
+			ics: "BEGIN:VCALENDAR\nSUMMARY:Calendar\nDTSTART:notadate\nBEGIN:VEVENT\nSUMMARY:Christmas\n" +
+				"DTSTART:20241225\nEND:VEVENT\nEND:VCALENDAR\n",
+			want: []icsEvent{{summary: "Christmas", start: day(2024, 12, 25), end: day(2024, 12, 26)}},
+		},
+		{
+			name: "several events",
+			ics: "BEGIN:VEVENT\nSUMMARY:A\nDTSTART:20240101\nEND:VEVENT\n" +
+				"BEGIN:VEVENT\nSUMMARY:B\nDTSTART:20240102\nEND:VEVENT\n",
+			want: []icsEvent{
+				{summary: "A", start: day(2024, 1, 1), end: day(2024, 1, 2)},
+				{summary: "B", start: day(2024, 1, 2), end: day(2024, 1, 3)},
+			},
+		},
+		{
+			name:    "no events",
+			ics:     "BEGIN:VCALENDAR\nEND:VCALENDAR\n",
+			wantErr: true,
+		},
+		{
+			name:    "event without start",
+			ics:     "BEGIN:VEVENT\nSUMMARY:Nothing\nEND:VEVENT\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid start",
+			ics:     "BEGIN:VEVENT\nSUMMARY:Broken\nDTSTART:2024\nEND:VEVENT\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseICSEvents(strings.NewReader(tt.ics))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d events, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i].uid != tt.want[i].uid || got[i].summary != tt.want[i].summary ||
+					!got[i].start.Equal(tt.want[i].start) || !got[i].end.Equal(tt.want[i].end) {
+					t.Errorf("event %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// circuitBreaker stops calls to a failing dependency. After threshold
+// consecutive failures it opens and rejects calls for the cooldown, then lets a
+// single trial call through: success closes it again, failure reopens it.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Allow reports whether a call may be made now.
+func (b *circuitBreaker) Allow() bool {
+	if b == nil || b.threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.trial {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+func (b *circuitBreaker) Success() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.trial = false
+}
+
+func (b *circuitBreaker) Failure() {
+	if b == nil || b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trial = false
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	// Each step is "allow" (checked against want), "success" or "failure".
+	type step struct {
+		op   string
+		want bool
+	}
+	allowed := step{"allow", true}
+	rejected := step{"allow", false}
+	success := step{op: "success"}
+	failure := step{op: "failure"}
+
+	tests := []struct {
+		name      string
+		threshold int
+		cooldown  time.Duration
+		steps     []step
+	}{
+		{
+			name:      "closed below the threshold",
+			threshold: 3,
+			cooldown:  time.Hour,
+			steps:     []step{allowed, failure, allowed, failure, allowed},
+		},
+		{
+			name:      "opens at the threshold",
+			threshold: 2,
+			cooldown:  time.Hour,
+			steps:     []step{failure, failure, rejected, rejected},
+		},
+		{
+			name:      "success resets the failure count",
+			threshold: 2,
+			cooldown:  time.Hour,
+			steps:     []step{failure, success, failure, allowed},
+		},
+		{
+			name:      "one trial call after the cooldown",
+			threshold: 1,
+			cooldown:  0,
+			steps:     []step{failure, allowed, rejected},
+		},
+		{
+			name:      "successful trial closes it",
+			threshold: 1,
+			cooldown:  0,
+			steps:     []step{failure, allowed, success, allowed, allowed},
+		},
+		{
+			name:      "disabled with a zero threshold",
+			threshold: 0,
+			cooldown:  time.Hour,
+			steps:     []step{failure, failure, failure, allowed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := newCircuitBreaker(tt.threshold, tt.cooldown)
+			for i, s := range tt.steps {
+				switch s.op {
+				case "allow":
+					if got := breaker.Allow(); got != s.want {
+						t.Fatalf("step %d: Allow() = %v, want %v", i, got, s.want)
+					}
+				case "success":
+					breaker.Success()
+				case "failure":
+					breaker.Failure()
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerReopensAfterFailedTrial(t *testing.T) {
+	breaker := newCircuitBreaker(1, 20*time.Millisecond)
+	breaker.Failure()
+	if breaker.Allow() {
+		t.Fatal("Allow() = true while open")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if !breaker.Allow() {
+		t.Fatal("Allow() = false after the cooldown, want a trial call")
+	}
+	breaker.Failure()
+	if breaker.Allow() {
+		t.Fatal("Allow() = true after a failed trial, want it open again")
+	}
+}
+
+func TestNilCircuitBreakerAllows(t *testing.T) {
+	var breaker *circuitBreaker
+	breaker.Failure()
+	breaker.Success()
+	if !breaker.Allow() {
+		t.Fatal("a nil breaker must always allow calls")
+	}
+}
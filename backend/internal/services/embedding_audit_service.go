@@ -0,0 +1,47 @@
+package services
+
+import (
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/repositories"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxAuditEntriesPerQuery bounds the audit entries returned by one query.
+const maxAuditEntriesPerQuery = 500
+
+type EmbeddingAuditService interface {
+	Record(entry *models.EmbeddingAuditEntry)
+	GetEntries(filter repositories.EmbeddingAuditFilter) ([]*models.EmbeddingAuditEntry, error)
+}
+
+type embeddingAuditService struct {
+	auditRepo repositories.EmbeddingAuditRepository
+}
+
+func NewEmbeddingAuditService(auditRepo repositories.EmbeddingAuditRepository) EmbeddingAuditService {
+	return &embeddingAuditService{auditRepo: auditRepo}
+}
+
+// Record saves the entry. The access it describes already happened, so a
+// failed write is only logged.
+func (s *embeddingAuditService) Record(entry *models.EmbeddingAuditEntry) {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	if err := s.auditRepo.Create(entry); err != nil {
+		log.Printf("Failed to record embedding %s audit entry: %v", entry.Action, err)
+	}
+}
+
+func (s *embeddingAuditService) GetEntries(filter repositories.EmbeddingAuditFilter) ([]*models.EmbeddingAuditEntry, error) {
+	if filter.Limit <= 0 || filter.Limit > maxAuditEntriesPerQuery {
+		filter.Limit = maxAuditEntriesPerQuery
+	}
+	return s.auditRepo.Find(filter)
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"face-verification-backend/internal/models"
+	"image"
+)
+
+// EnrollmentQuality is the quality an enrollment capture must reach.
+type EnrollmentQuality struct {
+	MinFacePixels int     // shorter side of the face
+	MinSharpness  float64 // variance of the Laplacian of the face
+	MinBrightness float64 // mean luminance of the face, 0-255
+	MaxBrightness float64
+}
+
+// CaptureQuality is what was measured on a capture and the checks it failed.
+type CaptureQuality struct {
+	FaceCount  int
+	FaceSize   int
+	Sharpness  float64
+	Brightness float64
+	Rejections []string
+}
+
+// qualitySampleSize bounds the side of the grid the face is sampled on, so
+// large photos cost the same as small ones and sharpness is comparable
+// between them.
+const qualitySampleSize = 256
+
+// assessCapture checks that the photo shows exactly one face, large, sharp and
+// well exposed enough to enrol.
+func assessCapture(img image.Image, faces []FaceBox, quality EnrollmentQuality) *CaptureQuality {
+	result := &CaptureQuality{FaceCount: len(faces)}
+	switch {
+	case len(faces) == 0:
+		result.Rejections = append(result.Rejections, models.CaptureRejectionNoFace)
+		return result
+	case len(faces) > 1:
+		result.Rejections = append(result.Rejections, models.CaptureRejectionMultipleFaces)
+	}
+
+	largest := faces[0]
+	for _, face := range faces[1:] {
+		if face.Width*face.Height > largest.Width*largest.Height {
+			largest = face
+		}
+	}
+
+	bounds := img.Bounds()
+	region := image.Rect(
+		bounds.Min.X+int(largest.X*float64(bounds.Dx())),
+		bounds.Min.Y+int(largest.Y*float64(bounds.Dy())),
+		bounds.Min.X+int((largest.X+largest.Width)*float64(bounds.Dx())),
+		bounds.Min.Y+int((largest.Y+largest.Height)*float64(bounds.Dy())),
+	).Intersect(bounds)
+	if region.Empty() {
+		region = bounds
+	}
+
+	result.FaceSize = min(region.Dx(), region.Dy())
+	result.Brightness, result.Sharpness = measureRegion(img, region)
+
+	if result.FaceSize < quality.MinFacePixels {
+		result.Rejections = append(result.Rejections, models.CaptureRejectionFaceTooSmall)
+	}
+	if result.Sharpness < quality.MinSharpness {
+		result.Rejections = append(result.Rejections, models.CaptureRejectionBlurry)
+	}
+	if result.Brightness < quality.MinBrightness {
+		result.Rejections = append(result.Rejections, models.CaptureRejectionTooDark)
+	}
+	if quality.MaxBrightness > 0 && result.Brightness > quality.MaxBrightness {
+		result.Rejections = append(result.Rejections, models.CaptureRejectionTooBright)
+	}
+	return result
+}
+
+// measureRegion returns the mean luminance (0-255) of a region of the image
+// and the variance of its Laplacian, which drops as the image gets blurrier.
+func measureRegion(img image.Image, region image.Rectangle) (float64, float64) {
+	width, height := region.Dx(), region.Dy()
+	if longer := max(width, height); longer > qualitySampleSize {
+		width = width * qualitySampleSize / longer
+		height = height * qualitySampleSize / longer
+	}
+	if width < 3 || height < 3 {
+		return 0, 0
+	}
+
+	gray := make([][]float64, height)
+	var sum float64
+	for y := 0; y < height; y++ {
+		gray[y] = make([]float64, width)
+		sourceY := region.Min.Y + (y*2+1)*region.Dy()/(height*2)
+		for x := 0; x < width; x++ {
+			sourceX := region.Min.X + (x*2+1)*region.Dx()/(width*2)
+			r, g, b, _ := img.At(sourceX, sourceY).RGBA()
+			luminance := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257
+			gray[y][x] = luminance
+			sum += luminance
+		}
+	}
+	brightness := sum / float64(width*height)
+
+	var lapSum, lapSquares float64
+	count := float64((width - 2) * (height - 2))
+	for y := 1; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			laplacian := gray[y-1][x] + gray[y+1][x] + gray[y][x-1] + gray[y][x+1] - 4*gray[y][x]
+			lapSum += laplacian
+			lapSquares += laplacian * laplacian
+		}
+	}
+	mean := lapSum / count
+	return brightness, lapSquares/count - mean*mean
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// FaceBox is a detected face, relative to the image size (0-1).
+type FaceBox struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	Score  float64 `json:"score"`
+}
+
+// FaceAnalysis lists the faces found in a photo and the embedding of the
+// largest one, when there is a face.
+type FaceAnalysis struct {
+	Faces     []FaceBox
+	Embedding []float64
+	Model     FaceModel
+}
+
+// FaceAnalyzer detects the faces in a photo.
+type FaceAnalyzer interface {
+	Analyze(photoPath string) (*FaceAnalysis, error)
+}
+
+type httpFaceAnalyzer struct {
+	client *faceServiceClient
+}
+
+// NewHTTPFaceAnalyzer analyzes photos with the face recognition service.
+func NewHTTPFaceAnalyzer(config FaceServiceConfig) FaceAnalyzer {
+	return &httpFaceAnalyzer{client: newFaceServiceClient(config)}
+}
+
+func (a *httpFaceAnalyzer) Analyze(photoPath string) (*FaceAnalysis, error) {
+	_, statusCode, bodyBytes, err := a.client.postPhoto("/analyze", photoPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Faces        []FaceBox `json:"faces"`
+		Embedding    []float64 `json:"embedding"`
+		ModelName    string    `json:"model_name"`
+		ModelVersion string    `json:"model_version"`
+		Error        string    `json:"error"`
+	}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return nil, fmt.Errorf("face recognition service returned status %d: %s", statusCode, string(bodyBytes))
+	}
+	if statusCode != http.StatusOK {
+		if result.Error != "" {
+			return nil, fmt.Errorf("face recognition service error: %s", result.Error)
+		}
+		return nil, fmt.Errorf("face recognition service returned status %d", statusCode)
+	}
+
+	return &FaceAnalysis{
+		Faces:     result.Faces,
+		Embedding: result.Embedding,
+		Model:     FaceModel{Name: result.ModelName, Version: result.ModelVersion},
+	}, nil
+}
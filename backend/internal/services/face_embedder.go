@@ -0,0 +1,50 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// FaceEmbedder extracts the embedding of the face in a photo, for clients that
+// cannot compute it on the device.
+type FaceEmbedder interface {
+	Embed(photoPath string) ([]float64, FaceModel, error)
+}
+
+type httpFaceEmbedder struct {
+	client *faceServiceClient
+}
+
+// NewHTTPFaceEmbedder extracts embeddings with the face recognition service.
+func NewHTTPFaceEmbedder(config FaceServiceConfig) FaceEmbedder {
+	return &httpFaceEmbedder{client: newFaceServiceClient(config)}
+}
+
+func (e *httpFaceEmbedder) Embed(photoPath string) ([]float64, FaceModel, error) {
+	_, statusCode, bodyBytes, err := e.client.postPhoto("/embed", photoPath, nil)
+	if err != nil {
+		return nil, FaceModel{}, err
+	}
+
+	var result struct {
+		Embedding    []float64 `json:"embedding"`
+		ModelName    string    `json:"model_name"`
+		ModelVersion string    `json:"model_version"`
+		Error        string    `json:"error"`
+	}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return nil, FaceModel{}, fmt.Errorf("face recognition service returned status %d: %s", statusCode, string(bodyBytes))
+	}
+	if statusCode != http.StatusOK {
+		if result.Error != "" {
+			return nil, FaceModel{}, fmt.Errorf("face recognition service error: %s", result.Error)
+		}
+		return nil, FaceModel{}, fmt.Errorf("face recognition service returned status %d", statusCode)
+	}
+	if len(result.Embedding) == 0 {
+		return nil, FaceModel{}, fmt.Errorf("invalid response from face recognition service: embedding missing")
+	}
+
+	return result.Embedding, FaceModel{Name: result.ModelName, Version: result.ModelVersion}, nil
+}
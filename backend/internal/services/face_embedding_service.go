@@ -1,34 +1,284 @@
 package services
 
 import (
+	"encoding/json"
+	"errors"
 	"face-verification-backend/internal/models"
 	"face-verification-backend/internal/repositories"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
 )
 
+// MaxEmbeddingsPerUser bounds the reference faces kept per user. Enrolling
+// another one removes the oldest.
+const MaxEmbeddingsPerUser = 10
+
+var (
+	ErrEmbeddingNotFound    = errors.New("embedding not found")
+	ErrReenrollmentRequired = errors.New("face must be enrolled again for the current face model")
+	ErrEmbeddingModel       = errors.New("embedding was produced by a different face model")
+)
+
+// FaceModel identifies the model that produced an embedding. Embeddings of
+// different models are not comparable.
+type FaceModel struct {
+	Name    string
+	Version string
+}
+
+func (m FaceModel) String() string {
+	return m.Name + "@" + m.Version
+}
+
+// IsZero reports whether the model was not specified.
+func (m FaceModel) IsZero() bool {
+	return m.Name == "" && m.Version == ""
+}
+
+// UnknownFaceModel is recorded on legacy embeddings when the model that
+// produced them is not configured.
+var UnknownFaceModel = FaceModel{Name: "unknown"}
+
+// FaceModelMigration summarizes a run of MigrateModel.
+type FaceModelMigration struct {
+	Stamped   int // legacy embeddings attributed to the legacy model
+	Flagged   int // users without an embedding from the active model
+	Unflagged int
+}
+
 type FaceEmbeddingService interface {
-	SaveEmbedding(userID string, embeddingData string) (*models.FaceEmbedding, error)
+	// AddPendingEmbedding stores an embedding of the active model that is not
+	// used for verification until it is activated.
+	AddPendingEmbedding(userID, embeddingData, label string, capturedAt time.Time, model FaceModel) (*models.FaceEmbedding, error)
+	ActivateEmbeddings(userID string, embeddingIDs []string) error
 	GetEmbeddingByUserID(userID string) (*models.FaceEmbedding, error)
-	DeleteEmbedding(userID string) error
+	GetEmbeddings(userID string) ([]*models.FaceEmbedding, error)
+	GetActiveEmbeddings(userID string) ([]*models.FaceEmbedding, error)
+	GetActiveEmbeddingsForUsers(userIDs []string) ([]*models.FaceEmbedding, error)
+	DeleteEmbedding(userID, embeddingID string) error
+	ActiveModel() FaceModel
+	MigrateModel() (*FaceModelMigration, error)
+	GetReenrollmentRequired() ([]*models.User, error)
 }
 
 type faceEmbeddingService struct {
 	embeddingRepo repositories.FaceEmbeddingRepository
+	userRepo      repositories.UserRepository
+	activeModel   FaceModel
+	legacyModel   FaceModel
 }
 
-func NewFaceEmbeddingService(embeddingRepo repositories.FaceEmbeddingRepository) FaceEmbeddingService {
+// NewFaceEmbeddingService returns the embedding service. legacyModel is the
+// model of the embeddings enrolled before models were recorded, when known.
+func NewFaceEmbeddingService(embeddingRepo repositories.FaceEmbeddingRepository, userRepo repositories.UserRepository, activeModel, legacyModel FaceModel) FaceEmbeddingService {
+	if legacyModel.IsZero() {
+		legacyModel = UnknownFaceModel
+	}
 	return &faceEmbeddingService{
 		embeddingRepo: embeddingRepo,
+		userRepo:      userRepo,
+		activeModel:   activeModel,
+		legacyModel:   legacyModel,
 	}
 }
 
-func (s *faceEmbeddingService) SaveEmbedding(userID string, embeddingData string) (*models.FaceEmbedding, error) {
-	return s.embeddingRepo.UpsertByUserID(userID, embeddingData)
+func (s *faceEmbeddingService) AddPendingEmbedding(userID, embeddingData, label string, capturedAt time.Time, model FaceModel) (*models.FaceEmbedding, error) {
+	if model.IsZero() {
+		model = s.activeModel
+	}
+	if model != s.activeModel {
+		return nil, fmt.Errorf("%w: got %s, expected %s", ErrEmbeddingModel, model, s.activeModel)
+	}
+	embedding, err := newFaceEmbedding(userID, embeddingData, label, capturedAt, model)
+	if err != nil {
+		return nil, err
+	}
+	embedding.Pending = true
+	if err := s.embeddingRepo.Create(embedding); err != nil {
+		return nil, err
+	}
+	return embedding, nil
+}
+
+// ActivateEmbeddings makes pending embeddings of the user active, removing
+// the oldest active ones beyond MaxEmbeddingsPerUser.
+func (s *faceEmbeddingService) ActivateEmbeddings(userID string, embeddingIDs []string) error {
+	if len(embeddingIDs) == 0 {
+		return nil
+	}
+	for _, id := range embeddingIDs {
+		embedding, err := s.embeddingRepo.FindByID(id)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && embedding.UserID != userID) {
+			return ErrEmbeddingNotFound
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := s.trimEmbeddings(userID, len(embeddingIDs)); err != nil {
+		return err
+	}
+	if err := s.embeddingRepo.Activate(embeddingIDs); err != nil {
+		return err
+	}
+	return s.userRepo.UpdateFaceReenrollmentRequired(userID, false)
+}
+
+// trimEmbeddings deletes the user's oldest active embeddings to leave room for
+// more without exceeding MaxEmbeddingsPerUser.
+func (s *faceEmbeddingService) trimEmbeddings(userID string, room int) error {
+	existing, err := s.embeddingRepo.FindAllByUserID(userID)
+	if err != nil {
+		return err
+	}
+	for i := max(MaxEmbeddingsPerUser-room, 0); i < len(existing); i++ {
+		if err := s.embeddingRepo.Delete(existing[i].ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newFaceEmbedding builds an embedding after checking its data is a vector.
+func newFaceEmbedding(userID, embeddingData, label string, capturedAt time.Time, model FaceModel) (*models.FaceEmbedding, error) {
+	var vector []float64
+	if err := json.Unmarshal([]byte(embeddingData), &vector); err != nil || len(vector) == 0 {
+		return nil, fmt.Errorf("embedding must be a JSON array of numbers")
+	}
+	if capturedAt.IsZero() {
+		capturedAt = time.Now()
+	}
+	return &models.FaceEmbedding{
+		UserID:       userID,
+		Embedding:    embeddingData,
+		Label:        label,
+		CapturedAt:   capturedAt,
+		ModelName:    model.Name,
+		ModelVersion: model.Version,
+		Dimension:    len(vector),
+	}, nil
 }
 
 func (s *faceEmbeddingService) GetEmbeddingByUserID(userID string) (*models.FaceEmbedding, error) {
 	return s.embeddingRepo.FindByUserID(userID)
 }
 
-func (s *faceEmbeddingService) DeleteEmbedding(userID string) error {
-	return s.embeddingRepo.DeleteByUserID(userID)
+func (s *faceEmbeddingService) GetEmbeddings(userID string) ([]*models.FaceEmbedding, error) {
+	return s.embeddingRepo.FindAllByUserID(userID)
+}
+
+// GetActiveEmbeddings returns the user's embeddings produced by the active
+// model. It fails with ErrReenrollmentRequired when the user only has
+// embeddings from other models.
+func (s *faceEmbeddingService) GetActiveEmbeddings(userID string) ([]*models.FaceEmbedding, error) {
+	embeddings, err := s.embeddingRepo.FindAllByUserIDAndModel(userID, s.activeModel.Name, s.activeModel.Version)
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) > 0 {
+		return embeddings, nil
+	}
+
+	count, err := s.embeddingRepo.CountByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrReenrollmentRequired
+	}
+	return embeddings, nil
+}
+
+// GetActiveEmbeddingsForUsers returns the embeddings of the active model of
+// several users at once. Users without one are left out.
+func (s *faceEmbeddingService) GetActiveEmbeddingsForUsers(userIDs []string) ([]*models.FaceEmbedding, error) {
+	return s.embeddingRepo.FindAllByUserIDsAndModel(userIDs, s.activeModel.Name, s.activeModel.Version)
+}
+
+// DeleteEmbedding removes one of the user's embeddings.
+func (s *faceEmbeddingService) DeleteEmbedding(userID, embeddingID string) error {
+	embedding, err := s.embeddingRepo.FindByID(embeddingID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && embedding.UserID != userID) {
+		return ErrEmbeddingNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.embeddingRepo.Delete(embeddingID)
+}
+
+func (s *faceEmbeddingService) ActiveModel() FaceModel {
+	return s.activeModel
+}
+
+// MigrateModel brings embeddings in line with the active model. Embeddings
+// enrolled before models were recorded are attributed to the legacy model,
+// and every user without an embedding from the active model is flagged for
+// re-enrollment.
+func (s *faceEmbeddingService) MigrateModel() (*FaceModelMigration, error) {
+	result := &FaceModelMigration{}
+
+	unversioned, err := s.embeddingRepo.FindUnversioned()
+	if err != nil {
+		return nil, err
+	}
+	for _, embedding := range unversioned {
+		var vector []float64
+		if err := json.Unmarshal([]byte(embedding.Embedding), &vector); err != nil {
+			continue
+		}
+		embedding.ModelName = s.legacyModel.Name
+		embedding.ModelVersion = s.legacyModel.Version
+		embedding.Dimension = len(vector)
+		if err := s.embeddingRepo.Update(embedding); err != nil {
+			return nil, err
+		}
+		result.Stamped++
+	}
+
+	enrolled, err := s.embeddingRepo.FindUserIDs()
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.embeddingRepo.FindUserIDsByModel(s.activeModel.Name, s.activeModel.Version)
+	if err != nil {
+		return nil, err
+	}
+	upToDate := make(map[string]bool, len(current))
+	for _, userID := range current {
+		upToDate[userID] = true
+	}
+
+	flagged, err := s.userRepo.FindFaceReenrollmentRequired()
+	if err != nil {
+		return nil, err
+	}
+	wasFlagged := make(map[string]bool, len(flagged))
+	for _, user := range flagged {
+		wasFlagged[user.ID] = true
+	}
+
+	for _, userID := range enrolled {
+		required := !upToDate[userID]
+		if required == wasFlagged[userID] {
+			continue
+		}
+		if err := s.userRepo.UpdateFaceReenrollmentRequired(userID, required); err != nil {
+			return nil, err
+		}
+		if required {
+			result.Flagged++
+		} else {
+			result.Unflagged++
+		}
+	}
+
+	return result, nil
+}
+
+func (s *faceEmbeddingService) GetReenrollmentRequired() ([]*models.User, error) {
+	return s.userRepo.FindFaceReenrollmentRequired()
 }
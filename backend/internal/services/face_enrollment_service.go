@@ -0,0 +1,333 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/repositories"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultEnrollmentMinCaptures is the number of captures that must pass the
+// quality checks before an enrollment can be submitted.
+const DefaultEnrollmentMinCaptures = 3
+
+var (
+	ErrEnrollmentNotFound     = errors.New("enrollment not found")
+	ErrEnrollmentInProgress   = errors.New("an enrollment is already open or waiting for approval")
+	ErrEnrollmentNotOpen      = errors.New("enrollment is not open for captures")
+	ErrEnrollmentNotPending   = errors.New("enrollment is not waiting for approval")
+	ErrEnrollmentSelfApproval = errors.New("enrollments cannot be approved by the enrolled user")
+	ErrNotEnoughCaptures      = errors.New("not enough captures passed the quality checks")
+	ErrTooManyCaptures        = errors.New("enrollment already has the maximum number of captures")
+)
+
+type FaceEnrollmentService interface {
+	StartEnrollment(userID string) (*models.FaceEnrollment, error)
+	// AddCapture checks the quality of a photo and, when it passes, stores the
+	// embedding of its face as pending. A capture that fails is kept with the
+	// reasons.
+	AddCapture(userID, enrollmentID, photoPath, label string) (*models.FaceEnrollmentCapture, error)
+	SubmitEnrollment(userID, enrollmentID string) (*models.FaceEnrollment, error)
+	CancelEnrollment(userID, enrollmentID string) (*models.FaceEnrollment, error)
+	GetUserEnrollments(userID string) ([]*models.FaceEnrollment, error)
+	GetEnrollments(status string) ([]*models.FaceEnrollment, error)
+	GetEnrollment(id string) (*models.FaceEnrollment, error)
+	// ApproveEnrollment activates the embeddings of the enrollment for verification.
+	ApproveEnrollment(id, reviewerID, note string) (*models.FaceEnrollment, error)
+	RejectEnrollment(id, reviewerID, note string) (*models.FaceEnrollment, error)
+}
+
+type faceEnrollmentService struct {
+	enrollmentRepo    repositories.FaceEnrollmentRepository
+	embeddingService  FaceEmbeddingService
+	analyzer          FaceAnalyzer
+	cloudinaryService CloudinaryService
+	quality           EnrollmentQuality
+	minCaptures       int
+}
+
+func NewFaceEnrollmentService(enrollmentRepo repositories.FaceEnrollmentRepository, embeddingService FaceEmbeddingService, analyzer FaceAnalyzer, cloudinaryService CloudinaryService, quality EnrollmentQuality, minCaptures int) FaceEnrollmentService {
+	if minCaptures <= 0 || minCaptures > MaxEmbeddingsPerUser {
+		minCaptures = DefaultEnrollmentMinCaptures
+	}
+	return &faceEnrollmentService{
+		enrollmentRepo:    enrollmentRepo,
+		embeddingService:  embeddingService,
+		analyzer:          analyzer,
+		cloudinaryService: cloudinaryService,
+		quality:           quality,
+		minCaptures:       minCaptures,
+	}
+}
+
+func (s *faceEnrollmentService) StartEnrollment(userID string) (*models.FaceEnrollment, error) {
+	if _, err := s.enrollmentRepo.FindUnfinishedByUserID(userID); err == nil {
+		return nil, ErrEnrollmentInProgress
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	enrollment := &models.FaceEnrollment{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Status:    models.EnrollmentStatusOpen,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Captures:  []models.FaceEnrollmentCapture{},
+	}
+	if err := s.enrollmentRepo.Create(enrollment); err != nil {
+		return nil, err
+	}
+	return enrollment, nil
+}
+
+func (s *faceEnrollmentService) AddCapture(userID, enrollmentID, photoPath, label string) (*models.FaceEnrollmentCapture, error) {
+	enrollment, err := s.findUserEnrollment(userID, enrollmentID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.Status != models.EnrollmentStatusOpen {
+		return nil, ErrEnrollmentNotOpen
+	}
+	if passedCaptures(enrollment) >= MaxEmbeddingsPerUser {
+		return nil, ErrTooManyCaptures
+	}
+
+	img, err := decodeImage(photoPath)
+	if err != nil {
+		return nil, err
+	}
+	analysis, err := s.analyzer.Analyze(photoPath)
+	if err != nil {
+		return nil, err
+	}
+	quality := assessCapture(img, analysis.Faces, s.quality)
+
+	photoURL, err := storePhoto(s.cloudinaryService, photoPath, "enrollment")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	capture := &models.FaceEnrollmentCapture{
+		ID:           uuid.New().String(),
+		EnrollmentID: enrollment.ID,
+		Photo:        photoURL,
+		Label:        label,
+		FaceCount:    quality.FaceCount,
+		FaceSize:     quality.FaceSize,
+		Sharpness:    quality.Sharpness,
+		Brightness:   quality.Brightness,
+		Rejections:   strings.Join(quality.Rejections, ","),
+		CreatedAt:    now,
+	}
+
+	if len(quality.Rejections) == 0 && len(analysis.Embedding) > 0 {
+		data, err := json.Marshal(analysis.Embedding)
+		if err != nil {
+			return nil, err
+		}
+		embedding, err := s.embeddingService.AddPendingEmbedding(userID, string(data), label, now, analysis.Model)
+		if err != nil {
+			return nil, err
+		}
+		capture.Passed = true
+		capture.EmbeddingID = embedding.ID
+	}
+
+	if err := s.enrollmentRepo.CreateCapture(capture); err != nil {
+		return nil, err
+	}
+	return capture, nil
+}
+
+func (s *faceEnrollmentService) SubmitEnrollment(userID, enrollmentID string) (*models.FaceEnrollment, error) {
+	enrollment, err := s.findUserEnrollment(userID, enrollmentID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.Status != models.EnrollmentStatusOpen {
+		return nil, ErrEnrollmentNotOpen
+	}
+	if passed := passedCaptures(enrollment); passed < s.minCaptures {
+		return nil, fmt.Errorf("%w: %d of %d", ErrNotEnoughCaptures, passed, s.minCaptures)
+	}
+
+	now := time.Now()
+	enrollment.Status = models.EnrollmentStatusPending
+	enrollment.SubmittedAt = &now
+	enrollment.UpdatedAt = now
+	if err := s.enrollmentRepo.Update(enrollment); err != nil {
+		return nil, err
+	}
+	return enrollment, nil
+}
+
+func (s *faceEnrollmentService) CancelEnrollment(userID, enrollmentID string) (*models.FaceEnrollment, error) {
+	enrollment, err := s.findUserEnrollment(userID, enrollmentID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.Status != models.EnrollmentStatusOpen && enrollment.Status != models.EnrollmentStatusPending {
+		return nil, ErrEnrollmentNotOpen
+	}
+	return s.close(enrollment, models.EnrollmentStatusCancelled, "", "", models.EnrollmentStatusOpen, models.EnrollmentStatusPending)
+}
+
+func (s *faceEnrollmentService) GetUserEnrollments(userID string) ([]*models.FaceEnrollment, error) {
+	return s.enrollmentRepo.FindByUserID(userID)
+}
+
+func (s *faceEnrollmentService) GetEnrollments(status string) ([]*models.FaceEnrollment, error) {
+	return s.enrollmentRepo.FindByStatus(status)
+}
+
+func (s *faceEnrollmentService) GetEnrollment(id string) (*models.FaceEnrollment, error) {
+	enrollment, err := s.enrollmentRepo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrEnrollmentNotFound
+	}
+	return enrollment, err
+}
+
+// ApproveEnrollment activates the embeddings of a pending enrollment. The
+// enrollment is marked approved first, so a concurrent review fails, and put
+// back up for review if its embeddings cannot be activated.
+func (s *faceEnrollmentService) ApproveEnrollment(id, reviewerID, note string) (*models.FaceEnrollment, error) {
+	enrollment, err := s.GetEnrollment(id)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.Status != models.EnrollmentStatusPending {
+		return nil, ErrEnrollmentNotPending
+	}
+	if enrollment.UserID == reviewerID {
+		return nil, ErrEnrollmentSelfApproval
+	}
+
+	var embeddingIDs []string
+	for _, capture := range enrollment.Captures {
+		if capture.Passed && capture.EmbeddingID != "" {
+			embeddingIDs = append(embeddingIDs, capture.EmbeddingID)
+		}
+	}
+
+	now := time.Now()
+	enrollment.Status = models.EnrollmentStatusApproved
+	enrollment.ReviewedBy = reviewerID
+	enrollment.ReviewedAt = &now
+	enrollment.ReviewNote = note
+	enrollment.UpdatedAt = now
+	approved, err := s.enrollmentRepo.UpdateReview(enrollment, models.EnrollmentStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	if !approved {
+		return nil, ErrEnrollmentNotPending
+	}
+
+	if err := s.embeddingService.ActivateEmbeddings(enrollment.UserID, embeddingIDs); err != nil {
+		enrollment.Status = models.EnrollmentStatusPending
+		enrollment.ReviewedBy = ""
+		enrollment.ReviewedAt = nil
+		enrollment.ReviewNote = ""
+		enrollment.UpdatedAt = time.Now()
+		if _, reopenErr := s.enrollmentRepo.UpdateReview(enrollment, models.EnrollmentStatusApproved); reopenErr != nil {
+			log.Printf("failed to reopen enrollment %s for review: %v", enrollment.ID, reopenErr)
+		}
+		return nil, err
+	}
+	return enrollment, nil
+}
+
+func (s *faceEnrollmentService) RejectEnrollment(id, reviewerID, note string) (*models.FaceEnrollment, error) {
+	enrollment, err := s.GetEnrollment(id)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.Status != models.EnrollmentStatusPending {
+		return nil, ErrEnrollmentNotPending
+	}
+	return s.close(enrollment, models.EnrollmentStatusRejected, reviewerID, note, models.EnrollmentStatusPending)
+}
+
+// close ends an enrollment still in one of the from statuses without approving
+// it, and discards its pending embeddings.
+func (s *faceEnrollmentService) close(enrollment *models.FaceEnrollment, status, reviewerID, note string, from ...string) (*models.FaceEnrollment, error) {
+	previous := enrollment.Status
+	now := time.Now()
+	enrollment.Status = status
+	if reviewerID != "" {
+		enrollment.ReviewedBy = reviewerID
+		enrollment.ReviewedAt = &now
+		enrollment.ReviewNote = note
+	}
+	enrollment.UpdatedAt = now
+	closed, err := s.enrollmentRepo.UpdateReview(enrollment, from...)
+	if err != nil {
+		return nil, err
+	}
+	if !closed {
+		if previous == models.EnrollmentStatusOpen {
+			return nil, ErrEnrollmentNotOpen
+		}
+		return nil, ErrEnrollmentNotPending
+	}
+
+	for _, capture := range enrollment.Captures {
+		if capture.EmbeddingID == "" {
+			continue
+		}
+		err := s.embeddingService.DeleteEmbedding(enrollment.UserID, capture.EmbeddingID)
+		if err != nil && !errors.Is(err, ErrEmbeddingNotFound) {
+			return nil, err
+		}
+	}
+	return enrollment, nil
+}
+
+// findUserEnrollment returns an enrollment of the user, hiding those of others.
+func (s *faceEnrollmentService) findUserEnrollment(userID, enrollmentID string) (*models.FaceEnrollment, error) {
+	enrollment, err := s.GetEnrollment(enrollmentID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.UserID != userID {
+		return nil, ErrEnrollmentNotFound
+	}
+	return enrollment, nil
+}
+
+func passedCaptures(enrollment *models.FaceEnrollment) int {
+	passed := 0
+	for _, capture := range enrollment.Captures {
+		if capture.Passed {
+			passed++
+		}
+	}
+	return passed
+}
+
+func decodeImage(photoPath string) (image.Image, error) {
+	file, err := os.Open(photoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read photo: %w", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported image, use a JPEG or PNG photo: %w", err)
+	}
+	return img, nil
+}
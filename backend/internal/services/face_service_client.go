@@ -0,0 +1,138 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// faceServiceClient calls the face recognition service. It retries transient
+// failures and stops calling the service while it keeps failing.
+type faceServiceClient struct {
+	config  FaceServiceConfig
+	client  *http.Client
+	breaker *circuitBreaker
+}
+
+func newFaceServiceClient(config FaceServiceConfig) *faceServiceClient {
+	return &faceServiceClient{
+		config:  config,
+		client:  &http.Client{Timeout: config.Timeout},
+		breaker: newCircuitBreaker(config.BreakerThreshold, config.BreakerCooldown),
+	}
+}
+
+// formFile is a file sent in a multipart form field.
+type formFile struct {
+	Field string
+	Path  string
+}
+
+// postPhoto sends the photo with the form fields to the endpoint and returns
+// the URL called with the response status and body. It fails with
+// ErrFaceServiceUnavailable when the service could not answer.
+func (c *faceServiceClient) postPhoto(endpoint, photoPath string, fields map[string]string) (string, int, []byte, error) {
+	return c.postFiles(endpoint, []formFile{{Field: "photo", Path: photoPath}}, fields)
+}
+
+// postFiles is postPhoto for several files.
+func (c *faceServiceClient) postFiles(endpoint string, files []formFile, fields map[string]string) (string, int, []byte, error) {
+	url := c.config.BaseURL + endpoint
+
+	var requestBody bytes.Buffer
+	writer := multipart.NewWriter(&requestBody)
+
+	for _, f := range files {
+		if err := writeFormFile(writer, f); err != nil {
+			return url, 0, nil, err
+		}
+	}
+
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	writer.Close()
+
+	var lastErr error
+	for attempt := 0; attempt <= c.config.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * c.config.RetryDelay)
+		}
+		if !c.breaker.Allow() {
+			return url, 0, nil, fmt.Errorf("%w: too many recent failures", ErrFaceServiceUnavailable)
+		}
+
+		statusCode, bodyBytes, err := c.post(url, writer.FormDataContentType(), requestBody.Bytes())
+		if err != nil || isTransientStatus(statusCode) {
+			c.breaker.Failure()
+			if err == nil {
+				err = fmt.Errorf("face recognition service returned status %d", statusCode)
+			}
+			log.Printf("Face recognition service transient failure (attempt %d): %v", attempt+1, err)
+			lastErr = err
+			continue
+		}
+		c.breaker.Success()
+
+		return url, statusCode, bodyBytes, nil
+	}
+
+	return url, 0, nil, fmt.Errorf("%w: %v", ErrFaceServiceUnavailable, lastErr)
+}
+
+func writeFormFile(writer *multipart.Writer, f formFile) error {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open photo file: %w", err)
+	}
+	defer file.Close()
+
+	part, err := writer.CreateFormFile(f.Field, filepath.Base(f.Path))
+	if err != nil {
+		return fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	return nil
+}
+
+func (c *faceServiceClient) post(url, contentType string, body []byte) (int, []byte, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if c.config.Key != "" {
+		req.Header.Set("X-Face-Service-Key", c.config.Key)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to connect to face recognition service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.StatusCode, bodyBytes, nil
+}
+
+// isTransientStatus reports whether a response status means the service is
+// temporarily unable to answer, so the call is worth retrying.
+func isTransientStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusTooManyRequests:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/repositories"
+	"fmt"
+)
+
+// Where the threshold of a verification came from, most specific last. The
+// face service applies its own threshold when it ignores the one sent to it.
+const (
+	ThresholdSourceService = "service"
+	ThresholdSourceDefault = "default"
+	ThresholdSourceSite    = "site"
+	ThresholdSourceUser    = "user"
+)
+
+// MinFaceMatchThreshold is the lowest override accepted. Below it almost any
+// face would match.
+const MinFaceMatchThreshold = 0.5
+
+var ErrInvalidThreshold = errors.New("face match threshold must be at least 0.5 and at most 1")
+
+// FaceThreshold is the match threshold that applies to a verification.
+type FaceThreshold struct {
+	Value  float64
+	Source string // default, site, user
+}
+
+type FaceThresholdService interface {
+	// Resolve returns the threshold for the user at the office: the user's
+	// override, else the office's, else the default. Either ID may be empty.
+	Resolve(userID, officeID string) (*FaceThreshold, error)
+	// SetUserThreshold sets or, with nil, clears the user's override.
+	SetUserThreshold(userID string, threshold *float64) (*models.User, error)
+	// SetSiteThreshold sets or, with nil, clears the office's override.
+	SetSiteThreshold(officeID string, threshold *float64) (*models.Office, error)
+}
+
+type faceThresholdService struct {
+	userRepo         repositories.UserRepository
+	officeRepo       repositories.OfficeRepository
+	defaultThreshold float64
+}
+
+func NewFaceThresholdService(userRepo repositories.UserRepository, officeRepo repositories.OfficeRepository, defaultThreshold float64) FaceThresholdService {
+	if defaultThreshold <= 0 {
+		defaultThreshold = DefaultFaceMatchThreshold
+	}
+	return &faceThresholdService{
+		userRepo:         userRepo,
+		officeRepo:       officeRepo,
+		defaultThreshold: defaultThreshold,
+	}
+}
+
+func (s *faceThresholdService) Resolve(userID, officeID string) (*FaceThreshold, error) {
+	if userID != "" {
+		user, err := s.userRepo.FindByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if user.FaceMatchThreshold != nil {
+			return &FaceThreshold{Value: *user.FaceMatchThreshold, Source: ThresholdSourceUser}, nil
+		}
+	}
+	if officeID != "" {
+		// A deleted office falls back to the default
+		if office, err := s.officeRepo.FindByID(officeID); err == nil && office.FaceMatchThreshold != nil {
+			return &FaceThreshold{Value: *office.FaceMatchThreshold, Source: ThresholdSourceSite}, nil
+		}
+	}
+	return &FaceThreshold{Value: s.defaultThreshold, Source: ThresholdSourceDefault}, nil
+}
+
+func (s *faceThresholdService) SetUserThreshold(userID string, threshold *float64) (*models.User, error) {
+	if err := validateThreshold(threshold); err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err := s.userRepo.UpdateFaceMatchThreshold(userID, threshold); err != nil {
+		return nil, err
+	}
+	return s.userRepo.FindByID(userID)
+}
+
+func (s *faceThresholdService) SetSiteThreshold(officeID string, threshold *float64) (*models.Office, error) {
+	if err := validateThreshold(threshold); err != nil {
+		return nil, err
+	}
+	if _, err := s.officeRepo.FindByID(officeID); err != nil {
+		return nil, fmt.Errorf("office not found")
+	}
+	if err := s.officeRepo.UpdateFaceMatchThreshold(officeID, threshold); err != nil {
+		return nil, err
+	}
+	return s.officeRepo.FindByID(officeID)
+}
+
+func validateThreshold(threshold *float64) error {
+	if threshold != nil && (*threshold < MinFaceMatchThreshold || *threshold > 1) {
+		return ErrInvalidThreshold
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/repositories"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxAttemptsPerQuery bounds the attempts returned by one query.
+const maxAttemptsPerQuery = 500
+
+// ThresholdReport is the false-reject rate of the verifications made at one
+// threshold. A mismatch is counted as a false reject when the same user was
+// verified for the same punch within the retry window, i.e. the genuine
+// employee was rejected and tried again.
+type ThresholdReport struct {
+	Threshold       float64        `json:"threshold"`
+	Sources         map[string]int `json:"sources"` // attempts per threshold source
+	Attempts        int            `json:"attempts"`
+	Verified        int            `json:"verified"`
+	Mismatches      int            `json:"mismatches"`
+	FalseRejects    int            `json:"false_rejects"`
+	FalseRejectRate float64        `json:"false_reject_rate"` // false rejects among genuine attempts
+}
+
+type FaceVerificationAttemptService interface {
+	GetAttempts(filter repositories.FaceVerificationAttemptFilter) ([]*models.FaceVerificationAttempt, error)
+	GetThresholdReport(from, to time.Time) ([]*ThresholdReport, error)
+	// AttachPhoto links the stored photo of an accepted punch to its attempt.
+	// Photos of rejected punches are not stored.
+	AttachPhoto(attemptID, photo string)
+	// RecordRejected saves a punch refused before the face was compared, with
+	// the reason it was refused.
+	RecordRejected(userID string, sample FaceSample, reason error)
+}
+
+type faceVerificationAttemptService struct {
+	attemptRepo repositories.FaceVerificationAttemptRepository
+	retryWindow time.Duration
+}
+
+func NewFaceVerificationAttemptService(attemptRepo repositories.FaceVerificationAttemptRepository, retryWindow time.Duration) FaceVerificationAttemptService {
+	return &faceVerificationAttemptService{
+		attemptRepo: attemptRepo,
+		retryWindow: retryWindow,
+	}
+}
+
+func (s *faceVerificationAttemptService) GetAttempts(filter repositories.FaceVerificationAttemptFilter) ([]*models.FaceVerificationAttempt, error) {
+	if filter.Limit <= 0 || filter.Limit > maxAttemptsPerQuery {
+		filter.Limit = maxAttemptsPerQuery
+	}
+	return s.attemptRepo.Find(filter)
+}
+
+func (s *faceVerificationAttemptService) GetThresholdReport(from, to time.Time) ([]*ThresholdReport, error) {
+	// Counted in the database, the attempt log grows with every punch
+	counts, err := s.attemptRepo.CountCompared(from, to, s.retryWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make(map[float64]*ThresholdReport)
+	for _, count := range counts {
+		report, ok := reports[count.Threshold]
+		if !ok {
+			report = &ThresholdReport{Threshold: count.Threshold, Sources: make(map[string]int)}
+			reports[count.Threshold] = report
+		}
+
+		source := count.ThresholdSource
+		if source == "" {
+			source = ThresholdSourceDefault
+		}
+		report.Sources[source] += count.Attempts
+		report.Attempts += count.Attempts
+		report.Verified += count.Verified
+		report.Mismatches += count.Mismatches
+		report.FalseRejects += count.FalseRejects
+	}
+
+	result := make([]*ThresholdReport, 0, len(reports))
+	for _, report := range reports {
+		if genuine := report.Verified + report.FalseRejects; genuine > 0 {
+			report.FalseRejectRate = float64(report.FalseRejects) / float64(genuine)
+		}
+		result = append(result, report)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Threshold < result[j].Threshold
+	})
+	return result, nil
+}
+
+func (s *faceVerificationAttemptService) AttachPhoto(attemptID, photo string) {
+	if err := s.attemptRepo.UpdatePhoto(attemptID, photo); err != nil {
+		log.Printf("Failed to attach photo to verification attempt %s: %v", attemptID, err)
+	}
+}
+
+func (s *faceVerificationAttemptService) RecordRejected(userID string, sample FaceSample, reason error) {
+	attempt := &models.FaceVerificationAttempt{
+		ID:              uuid.New().String(),
+		UserID:          userID,
+		PunchType:       sample.PunchType,
+		OfficeID:        sample.OfficeID,
+		Outcome:         models.VerificationOutcomeRejected,
+		FailureReason:   reason.Error(),
+		ThresholdSource: ThresholdSourceDefault,
+		AttemptedAt:     time.Now(),
+	}
+	if err := s.attemptRepo.Create(attempt); err != nil {
+		log.Printf("Failed to record rejected punch: %v", err)
+	}
+}
+
+// recordingFaceVerifier stores every verification made by the wrapped verifier.
+type recordingFaceVerifier struct {
+	verifier    FaceVerifier
+	name        string
+	attemptRepo repositories.FaceVerificationAttemptRepository
+}
+
+// NewRecordingFaceVerifier wraps a verifier so each attempt, with its score
+// and outcome, is saved as a FaceVerificationAttempt under the verifier's name.
+func NewRecordingFaceVerifier(verifier FaceVerifier, name string, attemptRepo repositories.FaceVerificationAttemptRepository) FaceVerifier {
+	return &recordingFaceVerifier{
+		verifier:    verifier,
+		name:        name,
+		attemptRepo: attemptRepo,
+	}
+}
+
+func (v *recordingFaceVerifier) Verify(userID string, sample FaceSample) (*FaceVerification, error) {
+	result, err := v.verifier.Verify(userID, sample)
+
+	attempt := &models.FaceVerificationAttempt{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		PunchType:   sample.PunchType,
+		Verifier:    v.name,
+		OfficeID:    sample.OfficeID,
+		AttemptedAt: time.Now(),
+	}
+	attempt.ThresholdSource = ThresholdSourceDefault
+	if sample.Threshold != nil {
+		attempt.ThresholdSource = sample.Threshold.Source
+	}
+	switch {
+	case err != nil:
+		attempt.Outcome = models.VerificationOutcomeError
+		attempt.FailureReason = err.Error()
+	case result.Degraded:
+		attempt.Outcome = models.VerificationOutcomeDegraded
+		attempt.FailureReason = ErrFaceServiceUnavailable.Error()
+	default:
+		similarity, threshold := result.Similarity, result.Threshold
+		attempt.Similarity = &similarity
+		attempt.Threshold = &threshold
+		if sample.Threshold != nil && math.Abs(threshold-sample.Threshold.Value) > 1e-9 {
+			// The verifier did not apply the threshold it was given
+			attempt.ThresholdSource = ThresholdSourceService
+		}
+		attempt.Outcome = models.VerificationOutcomeVerified
+		if !result.Verified {
+			attempt.Outcome = models.VerificationOutcomeMismatch
+			attempt.FailureReason = fmt.Sprintf("similarity %.4f below threshold %.4f", similarity, threshold)
+		}
+	}
+
+	// A failed write must not block the punch, the attempt is only logged
+	if recordErr := v.attemptRepo.Create(attempt); recordErr != nil {
+		log.Printf("Failed to record verification attempt: %v", recordErr)
+	} else if result != nil {
+		result.AttemptID = attempt.ID
+	}
+
+	return result, err
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Face verifier implementations, selected with FACE_VERIFIER.
+const (
+	FaceVerifierHTTP      = "http"
+	FaceVerifierEmbedding = "embedding"
+)
+
+// DefaultFaceMatchThreshold matches the threshold of the face recognition service.
+const DefaultFaceMatchThreshold = 0.62
+
+var (
+	ErrEmbeddingRequired = errors.New("face embedding is required")
+	ErrNoEnrolledFace    = errors.New("no enrolled face for this user")
+	// ErrFaceServiceUnavailable is returned when the face recognition service
+	// cannot be reached, keeps failing, or the circuit breaker is open
+	ErrFaceServiceUnavailable = errors.New("face recognition service unavailable")
+)
+
+// FaceSample is the face captured at a punch: the photo and, when the client
+// extracted it on the device, its embedding.
+type FaceSample struct {
+	PhotoPath string
+	Embedding []float64
+	PunchType string // punch being verified, recorded with the attempt
+	// Model that produced Embedding, when the client reports it
+	Model FaceModel
+	// Answer to a liveness challenge, when the client sent one
+	Liveness *LivenessResponse
+	// Office the punch is made at, when known
+	OfficeID string
+	// Threshold to verify against, the verifier's default when nil
+	Threshold *FaceThreshold
+}
+
+// FaceVerification is the outcome of comparing a sample with the enrolled face.
+// A degraded result was not compared at all and must be reviewed.
+type FaceVerification struct {
+	Verified   bool
+	Degraded   bool
+	Similarity float64
+	Threshold  float64
+	AttemptID  string // recorded attempt, when verifications are recorded
+}
+
+// FaceVerifier decides whether a face sample belongs to the user.
+type FaceVerifier interface {
+	Verify(userID string, sample FaceSample) (*FaceVerification, error)
+}
+
+// FaceServiceConfig configures the client of the face recognition service.
+type FaceServiceConfig struct {
+	BaseURL          string
+	Timeout          time.Duration
+	Retries          int           // extra attempts after a transient failure
+	RetryDelay       time.Duration // multiplied by the attempt number
+	BreakerThreshold int           // consecutive failures that open the circuit, 0 disables it
+	BreakerCooldown  time.Duration
+	// DegradedMode accepts punches unverified, for later review, while the
+	// service is unavailable instead of rejecting them
+	DegradedMode bool
+	// Key authenticates the backend to the service, which only honours the
+	// threshold of a verification from a caller sending it
+	Key string
+}
+
+// NewFaceVerifier returns the verifier named by kind.
+func NewFaceVerifier(kind string, faceService FaceServiceConfig, embeddingService FaceEmbeddingService, threshold float64) (FaceVerifier, error) {
+	switch kind {
+	case FaceVerifierHTTP, "":
+		var verifier FaceVerifier = NewHTTPFaceVerifier(faceService)
+		if faceService.DegradedMode {
+			verifier = &degradedFaceVerifier{verifier: verifier}
+		}
+		return verifier, nil
+	case FaceVerifierEmbedding:
+		return NewEmbeddingFaceVerifier(embeddingService, threshold), nil
+	default:
+		return nil, fmt.Errorf("unknown face verifier %q", kind)
+	}
+}
+
+type httpFaceVerifier struct {
+	client *faceServiceClient
+}
+
+// NewHTTPFaceVerifier verifies photos with the face recognition service. The
+// client is shared between calls, retries transient failures and stops calling
+// the service while it keeps failing.
+func NewHTTPFaceVerifier(config FaceServiceConfig) FaceVerifier {
+	return &httpFaceVerifier{client: newFaceServiceClient(config)}
+}
+
+func (v *httpFaceVerifier) Verify(userID string, sample FaceSample) (*FaceVerification, error) {
+	fields := map[string]string{"user_id": userID}
+	if sample.Threshold != nil {
+		fields["threshold"] = strconv.FormatFloat(sample.Threshold.Value, 'f', -1, 64)
+	}
+	url, statusCode, bodyBytes, err := v.client.postPhoto("/verify", sample.PhotoPath, fields)
+	if err != nil {
+		return nil, err
+	}
+	return parseVerifyResponse(url, statusCode, bodyBytes)
+}
+
+func parseVerifyResponse(url string, statusCode int, bodyBytes []byte) (*FaceVerification, error) {
+	// Check if response is error (non-200 status)
+	if statusCode != http.StatusOK {
+		var errorResp map[string]interface{}
+		if err := json.Unmarshal(bodyBytes, &errorResp); err == nil {
+			if errorMsg, ok := errorResp["error"].(string); ok {
+				return nil, fmt.Errorf("face recognition service error: %s", errorMsg)
+			}
+			if message, ok := errorResp["message"].(string); ok {
+				return nil, fmt.Errorf("face recognition service error: %s", message)
+			}
+		}
+		errorBody := string(bodyBytes)
+		if errorBody == "" {
+			errorBody = "(empty response body)"
+		}
+		// Provide more helpful error message based on status code
+		switch statusCode {
+		case http.StatusForbidden:
+			return nil, fmt.Errorf("access forbidden (403). Check CORS configuration and ensure face recognition service is running on the correct port")
+		case http.StatusNotFound:
+			return nil, fmt.Errorf("endpoint not found (404). Check if face recognition service URL is correct: %s", url)
+		case http.StatusInternalServerError:
+			return nil, fmt.Errorf("internal server error (500) from face recognition service")
+		default:
+			return nil, fmt.Errorf("face recognition service returned status %d: %s", statusCode, errorBody)
+		}
+	}
+
+	var result struct {
+		Verified   *bool   `json:"verified"`
+		Similarity float64 `json:"similarity"`
+		Threshold  float64 `json:"threshold"`
+	}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.Verified == nil {
+		return nil, fmt.Errorf("invalid response from face recognition service: 'verified' field missing or not boolean")
+	}
+
+	return &FaceVerification{
+		Verified:   *result.Verified,
+		Similarity: result.Similarity,
+		Threshold:  result.Threshold,
+	}, nil
+}
+
+// degradedFaceVerifier lets punches through unverified while the wrapped
+// verifier's service is unavailable.
+type degradedFaceVerifier struct {
+	verifier FaceVerifier
+}
+
+func (v *degradedFaceVerifier) Verify(userID string, sample FaceSample) (*FaceVerification, error) {
+	result, err := v.verifier.Verify(userID, sample)
+	if errors.Is(err, ErrFaceServiceUnavailable) {
+		log.Printf("Face recognition unavailable, accepting punch for review: %v", err)
+		return &FaceVerification{Degraded: true}, nil
+	}
+	return result, err
+}
+
+type embeddingFaceVerifier struct {
+	embeddingService FaceEmbeddingService
+	threshold        float64
+}
+
+// NewEmbeddingFaceVerifier compares the embedding sent by the client with the
+// user's enrolled embeddings of the active model in process, without the face
+// recognition service. The threshold applies to samples without their own.
+func NewEmbeddingFaceVerifier(embeddingService FaceEmbeddingService, threshold float64) FaceVerifier {
+	if threshold <= 0 {
+		threshold = DefaultFaceMatchThreshold
+	}
+	return &embeddingFaceVerifier{
+		embeddingService: embeddingService,
+		threshold:        threshold,
+	}
+}
+
+func (v *embeddingFaceVerifier) Verify(userID string, sample FaceSample) (*FaceVerification, error) {
+	if len(sample.Embedding) == 0 {
+		return nil, ErrEmbeddingRequired
+	}
+	if active := v.embeddingService.ActiveModel(); !sample.Model.IsZero() && sample.Model != active {
+		return nil, fmt.Errorf("%w: got %s, expected %s", ErrEmbeddingModel, sample.Model, active)
+	}
+
+	enrolled, err := v.embeddingService.GetActiveEmbeddings(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(enrolled) == 0 {
+		return nil, ErrNoEnrolledFace
+	}
+
+	// Match against the closest of the user's reference faces
+	var best float64
+	matched := false
+	var lastErr error
+	for _, embedding := range enrolled {
+		if embedding.Dimension > 0 && embedding.Dimension != len(sample.Embedding) {
+			lastErr = fmt.Errorf("%w: embedding has %d dimensions, expected %d", ErrEmbeddingModel, len(sample.Embedding), embedding.Dimension)
+			continue
+		}
+		var reference []float64
+		if err := json.Unmarshal([]byte(embedding.Embedding), &reference); err != nil {
+			lastErr = fmt.Errorf("invalid enrolled embedding %s: %w", embedding.ID, err)
+			continue
+		}
+		similarity, err := cosineSimilarity(sample.Embedding, reference)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if !matched || similarity > best {
+			best = similarity
+			matched = true
+		}
+	}
+	if !matched {
+		return nil, lastErr
+	}
+
+	threshold := v.threshold
+	if sample.Threshold != nil {
+		threshold = sample.Threshold.Value
+	}
+	return &FaceVerification{
+		Verified:   best >= threshold,
+		Similarity: best,
+		Threshold:  threshold,
+	}, nil
+}
+
+// cosineSimilarity returns the cosine of the angle between two embeddings.
+func cosineSimilarity(a, b []float64) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("embedding has %d dimensions, expected %d", len(a), len(b))
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0, errors.New("embedding must not be a zero vector")
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB)), nil
+}
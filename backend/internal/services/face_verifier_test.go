@@ -0,0 +1,42 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    []float64
+		want    float64
+		wantErr bool
+	}{
+		{"identical", []float64{0.1, 0.2, 0.3}, []float64{0.1, 0.2, 0.3}, 1, false},
+		{"scaled", []float64{1, 2, 3}, []float64{2, 4, 6}, 1, false},
+		{"orthogonal", []float64{1, 0}, []float64{0, 1}, 0, false},
+		{"opposite", []float64{1, -1}, []float64{-1, 1}, -1, false},
+		{"at 45 degrees", []float64{1, 0}, []float64{1, 1}, math.Sqrt2 / 2, false},
+		{"dimension mismatch", []float64{1, 2, 3}, []float64{1, 2}, 0, true},
+		{"zero vector", []float64{0, 0}, []float64{1, 1}, 0, true},
+		{"empty", []float64{}, []float64{}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cosineSimilarity(tt.a, tt.b)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("cosineSimilarity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/repositories"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultKioskMinMargin is how much closer the identified employee must be
+// than the next best one.
+const DefaultKioskMinMargin = 0.05
+
+// kioskKeyPrefix starts every kiosk device key.
+const kioskKeyPrefix = "fvk_"
+
+var (
+	ErrInvalidDeviceKey      = errors.New("invalid device key")
+	ErrKioskDeviceNotFound   = errors.New("kiosk device not found")
+	ErrKioskDeviceRevoked    = errors.New("kiosk device is revoked")
+	ErrFaceNotIdentified     = errors.New("face does not match any employee of this site")
+	ErrAmbiguousFace         = errors.New("face matches several employees, please try again")
+	ErrNoEnrolledSiteMembers = errors.New("no employee of this site has an enrolled face")
+)
+
+// KioskIdentification is the employee identified from a face at a kiosk. The
+// match scores are not returned to the device, which could use them to tune a
+// spoof; the similarity of the verification is kept in the attempt log.
+type KioskIdentification struct {
+	UserID  string `json:"user_id"`
+	Outcome string `json:"outcome"` // verified
+}
+
+// KioskClockIn is the result of a clock-in at a kiosk.
+type KioskClockIn struct {
+	Identification *KioskIdentification `json:"identification"`
+	Attendance     *models.Attendance   `json:"attendance"`
+}
+
+type KioskService interface {
+	// RegisterDevice returns the device and its key. The key is not stored and
+	// cannot be retrieved again.
+	RegisterDevice(name, officeID, createdBy string) (*models.KioskDevice, string, error)
+	GetDevices() ([]*models.KioskDevice, error)
+	RevokeDevice(id string) (*models.KioskDevice, error)
+	Authenticate(key string) (*models.KioskDevice, error)
+	Identify(device *models.KioskDevice, face FaceSample) (*KioskIdentification, error)
+	ClockIn(device *models.KioskDevice, face FaceSample) (*KioskClockIn, error)
+}
+
+type kioskService struct {
+	deviceRepo        repositories.KioskDeviceRepository
+	officeService     OfficeService
+	embeddingService  FaceEmbeddingService
+	embedder          FaceEmbedder
+	attendanceService AttendanceService
+	thresholdService  FaceThresholdService
+	minMargin         float64
+}
+
+// NewKioskService identifies employees 1:N among the users assigned to the
+// kiosk's office. The embedder extracts the embedding when the kiosk only
+// sends a photo. Identification uses the threshold of the kiosk's office.
+func NewKioskService(deviceRepo repositories.KioskDeviceRepository, officeService OfficeService, embeddingService FaceEmbeddingService, embedder FaceEmbedder, attendanceService AttendanceService, thresholdService FaceThresholdService, minMargin float64) KioskService {
+	if minMargin < 0 {
+		minMargin = DefaultKioskMinMargin
+	}
+	return &kioskService{
+		deviceRepo:        deviceRepo,
+		officeService:     officeService,
+		embeddingService:  embeddingService,
+		embedder:          embedder,
+		attendanceService: attendanceService,
+		thresholdService:  thresholdService,
+		minMargin:         minMargin,
+	}
+}
+
+func (s *kioskService) RegisterDevice(name, officeID, createdBy string) (*models.KioskDevice, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("name is required")
+	}
+	if _, err := s.officeService.GetOffice(officeID); err != nil {
+		return nil, "", fmt.Errorf("office not found")
+	}
+
+	key, prefix, err := generateAPIKey(kioskKeyPrefix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	device := &models.KioskDevice{
+		ID:        uuid.New().String(),
+		Name:      name,
+		OfficeID:  officeID,
+		KeyPrefix: prefix,
+		KeyHash:   hashAPIKey(key),
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.deviceRepo.Create(device); err != nil {
+		return nil, "", err
+	}
+
+	return device, key, nil
+}
+
+func (s *kioskService) GetDevices() ([]*models.KioskDevice, error) {
+	return s.deviceRepo.FindAll()
+}
+
+func (s *kioskService) RevokeDevice(id string) (*models.KioskDevice, error) {
+	device, err := s.deviceRepo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrKioskDeviceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if device.RevokedAt != nil {
+		return nil, ErrKioskDeviceRevoked
+	}
+
+	now := time.Now()
+	device.RevokedAt = &now
+	device.UpdatedAt = now
+	if err := s.deviceRepo.Update(device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// Authenticate returns the active device the key belongs to.
+func (s *kioskService) Authenticate(key string) (*models.KioskDevice, error) {
+	prefix, ok := apiKeyPrefix(key, kioskKeyPrefix)
+	if !ok {
+		return nil, ErrInvalidDeviceKey
+	}
+
+	device, err := s.deviceRepo.FindByKeyPrefix(prefix)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidDeviceKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(key)), []byte(device.KeyHash)) != 1 {
+		return nil, ErrInvalidDeviceKey
+	}
+	if device.RevokedAt != nil {
+		return nil, ErrKioskDeviceRevoked
+	}
+
+	now := time.Now()
+	if device.LastSeenAt == nil || now.Sub(*device.LastSeenAt) >= lastUsedPrecision {
+		if err := s.deviceRepo.UpdateLastSeenAt(device.ID, now); err != nil {
+			log.Printf("Failed to update last use of kiosk device %s: %v", device.KeyPrefix, err)
+		}
+		device.LastSeenAt = &now
+	}
+
+	return device, nil
+}
+
+// Identify finds the employee of the kiosk's office whose enrolled faces are
+// closest to the sample. The best match must reach the threshold and beat
+// every other employee by the minimum margin.
+func (s *kioskService) Identify(device *models.KioskDevice, face FaceSample) (*KioskIdentification, error) {
+	if err := s.embed(&face); err != nil {
+		return nil, err
+	}
+
+	userIDs, err := s.officeService.GetOfficeUserIDs(device.OfficeID)
+	if err != nil {
+		return nil, err
+	}
+	enrolled, err := s.embeddingService.GetActiveEmbeddingsForUsers(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Best similarity of each employee over their reference faces
+	best := make(map[string]float64)
+	for _, embedding := range enrolled {
+		if embedding.Dimension > 0 && embedding.Dimension != len(face.Embedding) {
+			continue
+		}
+		var reference []float64
+		if err := json.Unmarshal([]byte(embedding.Embedding), &reference); err != nil {
+			continue
+		}
+		similarity, err := cosineSimilarity(face.Embedding, reference)
+		if err != nil {
+			continue
+		}
+		if current, ok := best[embedding.UserID]; !ok || similarity > current {
+			best[embedding.UserID] = similarity
+		}
+	}
+	if len(best) == 0 {
+		return nil, ErrNoEnrolledSiteMembers
+	}
+
+	threshold, err := s.thresholdService.Resolve("", device.OfficeID)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := make([]string, 0, len(best))
+	for userID := range best {
+		ranked = append(ranked, userID)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		return best[ranked[i]] > best[ranked[j]]
+	})
+
+	similarity := best[ranked[0]]
+	if similarity < threshold.Value {
+		return nil, ErrFaceNotIdentified
+	}
+	if len(ranked) > 1 && similarity-best[ranked[1]] < s.minMargin {
+		return nil, ErrAmbiguousFace
+	}
+
+	return &KioskIdentification{
+		UserID:  ranked[0],
+		Outcome: models.VerificationOutcomeVerified,
+	}, nil
+}
+
+// ClockIn identifies the employee and clocks them in at the kiosk's office.
+// The usual 1:1 face verification then confirms the match and records the
+// attempt.
+func (s *kioskService) ClockIn(device *models.KioskDevice, face FaceSample) (*KioskClockIn, error) {
+	// Extract the embedding once, for identification and verification
+	if err := s.embed(&face); err != nil {
+		return nil, err
+	}
+
+	identification, err := s.Identify(device, face)
+	if err != nil {
+		return nil, err
+	}
+
+	office := device.Office
+	if office == nil {
+		if office, err = s.officeService.GetOffice(device.OfficeID); err != nil {
+			return nil, err
+		}
+	}
+	coords := &Coordinates{Latitude: office.Latitude, Longitude: office.Longitude}
+	location := fmt.Sprintf("%f,%f", office.Latitude, office.Longitude)
+	face.OfficeID = office.ID
+
+	attendance, err := s.attendanceService.ClockIn(identification.UserID, face, location, coords)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KioskClockIn{
+		Identification: identification,
+		Attendance:     attendance,
+	}, nil
+}
+
+// embed extracts the embedding of the sample's photo unless the kiosk sent it,
+// and checks it comes from the active model.
+func (s *kioskService) embed(face *FaceSample) error {
+	if len(face.Embedding) == 0 {
+		if s.embedder == nil {
+			return ErrEmbeddingRequired
+		}
+		embedding, model, err := s.embedder.Embed(face.PhotoPath)
+		if err != nil {
+			return err
+		}
+		face.Embedding = embedding
+		face.Model = model
+	}
+
+	if active := s.embeddingService.ActiveModel(); !face.Model.IsZero() && face.Model != active {
+		return fmt.Errorf("%w: got %s, expected %s", ErrEmbeddingModel, face.Model, active)
+	}
+	return nil
+}
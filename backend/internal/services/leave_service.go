@@ -0,0 +1,343 @@
+package services
+
+import (
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/repositories"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLeaveNotFound       = errors.New("leave request not found")
+	ErrLeaveNotPending     = errors.New("leave request is no longer pending")
+	ErrLeaveOverlap        = errors.New("leave request overlaps an existing request")
+	ErrInsufficientBalance = errors.New("insufficient leave balance")
+	ErrLeaveSelfReview     = errors.New("leave requests cannot be reviewed by the requester")
+)
+
+type LeaveService interface {
+	CreateLeaveType(code, name string, annualAllowanceDays int, paid bool) (*models.LeaveType, error)
+	GetLeaveTypes() ([]*models.LeaveType, error)
+	UpdateLeaveType(id, name string, annualAllowanceDays *int, paid *bool) (*models.LeaveType, error)
+	RequestLeave(userID, leaveTypeID string, startDate, endDate time.Time, reason string) (*models.LeaveRequest, error)
+	CancelLeave(userID, requestID string) (*models.LeaveRequest, error)
+	GetUserLeaveRequests(userID string) ([]*models.LeaveRequest, error)
+	GetLeaveRequests(status string) ([]*models.LeaveRequest, error)
+	ApproveLeave(requestID, reviewerID, note string) (*models.LeaveRequest, error)
+	RejectLeave(requestID, reviewerID, note string) (*models.LeaveRequest, error)
+	GetBalances(userID string, year int) ([]*models.LeaveBalance, error)
+	GetApprovedLeaveDays(userID string, startDate, endDate time.Time) (map[string]*models.LeaveRequest, error)
+}
+
+type leaveService struct {
+	leaveRepo       repositories.LeaveRepository
+	calendarService CalendarService
+}
+
+func NewLeaveService(leaveRepo repositories.LeaveRepository, calendarService CalendarService) LeaveService {
+	return &leaveService{
+		leaveRepo:       leaveRepo,
+		calendarService: calendarService,
+	}
+}
+
+func (s *leaveService) CreateLeaveType(code, name string, annualAllowanceDays int, paid bool) (*models.LeaveType, error) {
+	if annualAllowanceDays < 0 {
+		return nil, fmt.Errorf("annual_allowance_days must not be negative")
+	}
+
+	leaveType := &models.LeaveType{
+		ID:                  uuid.New().String(),
+		Code:                code,
+		Name:                name,
+		AnnualAllowanceDays: annualAllowanceDays,
+		Paid:                paid,
+		CreatedAt:           time.Now(),
+		UpdatedAt:           time.Now(),
+	}
+
+	if err := s.leaveRepo.CreateType(leaveType); err != nil {
+		return nil, err
+	}
+
+	return leaveType, nil
+}
+
+func (s *leaveService) GetLeaveTypes() ([]*models.LeaveType, error) {
+	return s.leaveRepo.FindAllTypes()
+}
+
+func (s *leaveService) UpdateLeaveType(id, name string, annualAllowanceDays *int, paid *bool) (*models.LeaveType, error) {
+	leaveType, err := s.leaveRepo.FindTypeByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != "" {
+		leaveType.Name = name
+	}
+	if annualAllowanceDays != nil {
+		if *annualAllowanceDays < 0 {
+			return nil, fmt.Errorf("annual_allowance_days must not be negative")
+		}
+		leaveType.AnnualAllowanceDays = *annualAllowanceDays
+	}
+	if paid != nil {
+		leaveType.Paid = *paid
+	}
+	leaveType.UpdatedAt = time.Now()
+
+	if err := s.leaveRepo.UpdateType(leaveType); err != nil {
+		return nil, err
+	}
+
+	return leaveType, nil
+}
+
+func (s *leaveService) RequestLeave(userID, leaveTypeID string, startDate, endDate time.Time, reason string) (*models.LeaveRequest, error) {
+	if endDate.Before(startDate) {
+		return nil, fmt.Errorf("end_date must not be before start_date")
+	}
+	if startDate.Year() != endDate.Year() {
+		return nil, fmt.Errorf("leave request must not span multiple years")
+	}
+
+	leaveType, err := s.leaveRepo.FindTypeByID(leaveTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("leave type not found")
+	}
+
+	days, err := s.countLeaveDays(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	if days == 0 {
+		return nil, fmt.Errorf("leave request does not cover any working day")
+	}
+
+	overlapping, err := s.leaveRepo.FindOverlappingRequests(userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	if len(overlapping) > 0 {
+		return nil, ErrLeaveOverlap
+	}
+
+	balanceID := ""
+	if leaveType.AnnualAllowanceDays > 0 {
+		balance, err := s.getOrAccrueBalance(userID, leaveType, startDate.Year())
+		if err != nil {
+			return nil, err
+		}
+		if balance.RemainingDays() < days {
+			return nil, fmt.Errorf("%w: %d day(s) remaining", ErrInsufficientBalance, balance.RemainingDays())
+		}
+		balanceID = balance.ID
+	}
+
+	request := &models.LeaveRequest{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		LeaveTypeID: leaveTypeID,
+		BalanceID:   balanceID,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Days:        days,
+		Reason:      reason,
+		Status:      models.LeaveStatusPending,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		LeaveType:   *leaveType,
+	}
+
+	// The balance is checked again as the days are reserved, in case another
+	// request took them in the meantime
+	reserved, err := s.leaveRepo.CreateRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	if !reserved {
+		return nil, ErrInsufficientBalance
+	}
+
+	return request, nil
+}
+
+// CancelLeave lets the requester withdraw a request that has not been reviewed yet.
+func (s *leaveService) CancelLeave(userID, requestID string) (*models.LeaveRequest, error) {
+	request, err := s.findRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request.UserID != userID {
+		return nil, ErrLeaveNotFound
+	}
+
+	return s.closePending(request, models.LeaveStatusCancelled, "", "")
+}
+
+func (s *leaveService) GetUserLeaveRequests(userID string) ([]*models.LeaveRequest, error) {
+	return s.leaveRepo.FindRequestsByUserID(userID)
+}
+
+func (s *leaveService) GetLeaveRequests(status string) ([]*models.LeaveRequest, error) {
+	return s.leaveRepo.FindRequestsByStatus(status)
+}
+
+func (s *leaveService) ApproveLeave(requestID, reviewerID, note string) (*models.LeaveRequest, error) {
+	request, err := s.findRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.closePending(request, models.LeaveStatusApproved, reviewerID, note)
+}
+
+func (s *leaveService) RejectLeave(requestID, reviewerID, note string) (*models.LeaveRequest, error) {
+	request, err := s.findRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.closePending(request, models.LeaveStatusRejected, reviewerID, note)
+}
+
+// GetBalances returns the user's balance of every limited leave type for the year,
+// accruing the yearly allowance for types without a balance yet.
+func (s *leaveService) GetBalances(userID string, year int) ([]*models.LeaveBalance, error) {
+	leaveTypes, err := s.leaveRepo.FindAllTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]*models.LeaveBalance, 0, len(leaveTypes))
+	for _, leaveType := range leaveTypes {
+		if leaveType.AnnualAllowanceDays == 0 {
+			continue
+		}
+		balance, err := s.getOrAccrueBalance(userID, leaveType, year)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+
+	return balances, nil
+}
+
+// GetApprovedLeaveDays returns the approved leave covering each day in the
+// range, keyed by date (YYYY-MM-DD).
+func (s *leaveService) GetApprovedLeaveDays(userID string, startDate, endDate time.Time) (map[string]*models.LeaveRequest, error) {
+	requests, err := s.leaveRepo.FindApprovedByUserIDAndDateRange(userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	nonWorkingDays, err := s.calendarService.NonWorkingDays(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make(map[string]*models.LeaveRequest)
+	for _, request := range requests {
+		for day := request.StartDate; !day.After(request.EndDate); day = day.AddDate(0, 0, 1) {
+			key := day.Format("2006-01-02")
+			if day.Before(startDate) || day.After(endDate) || nonWorkingDays[key] != "" {
+				continue
+			}
+			days[key] = request
+		}
+	}
+
+	return days, nil
+}
+
+func (s *leaveService) findRequest(requestID string) (*models.LeaveRequest, error) {
+	request, err := s.leaveRepo.FindRequestByID(requestID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLeaveNotFound
+	}
+	return request, err
+}
+
+// closePending moves a pending request to a final status and releases the days
+// it reserved, counting them as used when the request is approved. The days
+// go back to the balance they were reserved on, whatever the allowance of the
+// leave type is now.
+func (s *leaveService) closePending(request *models.LeaveRequest, status, reviewerID, note string) (*models.LeaveRequest, error) {
+	if request.Status != models.LeaveStatusPending {
+		return nil, ErrLeaveNotPending
+	}
+	if request.UserID == reviewerID {
+		return nil, ErrLeaveSelfReview
+	}
+
+	now := time.Now()
+	request.Status = status
+	request.ReviewerID = reviewerID
+	request.ReviewNote = note
+	if reviewerID != "" {
+		request.ReviewedAt = &now
+	}
+	request.UpdatedAt = now
+
+	// Only one of concurrent reviews or a cancellation gets to close the request
+	closed, err := s.leaveRepo.CloseRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	if !closed {
+		return nil, ErrLeaveNotPending
+	}
+
+	return request, nil
+}
+
+// getOrAccrueBalance returns the user's balance for the year, creating it with
+// the leave type's yearly allowance the first time it is needed.
+func (s *leaveService) getOrAccrueBalance(userID string, leaveType *models.LeaveType, year int) (*models.LeaveBalance, error) {
+	balance, err := s.leaveRepo.FindBalance(userID, leaveType.ID, year)
+	if err == nil {
+		return balance, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	balance = &models.LeaveBalance{
+		ID:           uuid.New().String(),
+		UserID:       userID,
+		LeaveTypeID:  leaveType.ID,
+		Year:         year,
+		EntitledDays: leaveType.AnnualAllowanceDays,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+		LeaveType:    *leaveType,
+	}
+	if err := s.leaveRepo.CreateBalance(balance); err != nil {
+		return nil, err
+	}
+
+	return balance, nil
+}
+
+// countLeaveDays counts the working days in the inclusive range, which are the
+// days that consume leave.
+func (s *leaveService) countLeaveDays(startDate, endDate time.Time) (int, error) {
+	nonWorkingDays, err := s.calendarService.NonWorkingDays(startDate, endDate)
+	if err != nil {
+		return 0, err
+	}
+
+	days := 0
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		if nonWorkingDays[day.Format("2006-01-02")] == "" {
+			days++
+		}
+	}
+	return days, nil
+}
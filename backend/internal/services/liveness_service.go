@@ -0,0 +1,279 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/repositories"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Liveness modes control what happens to a clock-in without a passed liveness check.
+const (
+	LivenessModeOff     = "off"
+	LivenessModeFlag    = "flag"
+	LivenessModeRequire = "require"
+)
+
+var (
+	ErrLivenessRequired         = errors.New("liveness challenge response is required")
+	ErrLivenessFailed           = errors.New("liveness check failed")
+	ErrLivenessChallengeInvalid = errors.New("liveness challenge is unknown, expired or already used")
+	ErrLivenessPhotoMismatch    = errors.New("the photo must be one of the liveness frames")
+)
+
+// livenessFrameMaxDistance is how many bits the difference hash of the punch
+// photo may differ from a liveness frame for the photo to count as that frame,
+// allowing for it being re-encoded on upload.
+const livenessFrameMaxDistance = 6
+
+var livenessActions = []string{
+	models.LivenessActionBlink,
+	models.LivenessActionTurnLeft,
+	models.LivenessActionTurnRight,
+	models.LivenessActionNod,
+}
+
+// LivenessResponse is the answer to a challenge: frames recorded while the
+// person performed the actions. Subject is who the challenge was issued to.
+type LivenessResponse struct {
+	ChallengeID string
+	Subject     string
+	FramePaths  []string
+}
+
+// LivenessResult is the liveness outcome stored on the attendance.
+type LivenessResult struct {
+	Result      string // passed, failed, missing
+	Score       *float64
+	ChallengeID string
+}
+
+// LivenessEvidence is what the checker found in the frames.
+type LivenessEvidence struct {
+	Score     float64  // share of the actions performed, in order, by the same face
+	Completed []string // actions seen in the frames
+}
+
+// LivenessChecker checks that frames show the challenge actions performed live.
+type LivenessChecker interface {
+	Check(actions []string, framePaths []string) (*LivenessEvidence, error)
+}
+
+// KioskLivenessSubject is the subject of challenges issued to a kiosk, which
+// does not know who is in front of it yet.
+func KioskLivenessSubject(deviceID string) string {
+	return "kiosk:" + deviceID
+}
+
+type LivenessService interface {
+	IssueChallenge(subject string) (*models.LivenessChallenge, error)
+	// Evaluate checks a challenge response according to the liveness mode. The
+	// punch photo must be one of the frames, so the live person is the one
+	// whose face is verified. It returns nil when liveness is off.
+	Evaluate(response *LivenessResponse, photoPath string) (*LivenessResult, error)
+	DeleteExpiredChallenges(before time.Time) (int64, error)
+}
+
+type livenessService struct {
+	challengeRepo repositories.LivenessChallengeRepository
+	checker       LivenessChecker
+	mode          string
+	ttl           time.Duration
+	actionCount   int
+	minScore      float64
+}
+
+func NewLivenessService(challengeRepo repositories.LivenessChallengeRepository, checker LivenessChecker, mode string, ttl time.Duration, actionCount int, minScore float64) LivenessService {
+	if actionCount <= 0 || actionCount > len(livenessActions) {
+		actionCount = 2
+	}
+	return &livenessService{
+		challengeRepo: challengeRepo,
+		checker:       checker,
+		mode:          mode,
+		ttl:           ttl,
+		actionCount:   actionCount,
+		minScore:      minScore,
+	}
+}
+
+// IssueChallenge creates a challenge with a random sequence of actions.
+func (s *livenessService) IssueChallenge(subject string) (*models.LivenessChallenge, error) {
+	if s.mode == LivenessModeOff {
+		return nil, fmt.Errorf("liveness checks are disabled")
+	}
+
+	actions, err := randomActions(s.actionCount)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	challenge := &models.LivenessChallenge{
+		ID:        uuid.New().String(),
+		Subject:   subject,
+		Actions:   strings.Join(actions, ","),
+		ExpiresAt: now.Add(s.ttl),
+		CreatedAt: now,
+	}
+	if err := s.challengeRepo.Create(challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+func (s *livenessService) Evaluate(response *LivenessResponse, photoPath string) (*LivenessResult, error) {
+	if s.mode == LivenessModeOff {
+		return nil, nil
+	}
+	if response == nil {
+		if s.mode == LivenessModeRequire {
+			return nil, ErrLivenessRequired
+		}
+		return &LivenessResult{Result: models.LivenessResultMissing}, nil
+	}
+	if len(response.FramePaths) == 0 {
+		return nil, fmt.Errorf("frames are required to answer the liveness challenge")
+	}
+
+	challenge, err := s.challengeRepo.FindByID(response.ChallengeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLivenessChallengeInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if challenge.Subject != response.Subject || !now.Before(challenge.ExpiresAt) {
+		return nil, ErrLivenessChallengeInvalid
+	}
+	// Consume the challenge before checking so a response cannot be replayed
+	unused, err := s.challengeRepo.MarkUsed(challenge.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !unused {
+		return nil, ErrLivenessChallengeInvalid
+	}
+
+	if !photoMatchesFrames(photoPath, response.FramePaths, livenessFrameMaxDistance) {
+		if s.mode == LivenessModeRequire {
+			return nil, ErrLivenessPhotoMismatch
+		}
+		return &LivenessResult{Result: models.LivenessResultFailed, ChallengeID: challenge.ID}, nil
+	}
+
+	evidence, err := s.checker.Check(strings.Split(challenge.Actions, ","), response.FramePaths)
+	if err != nil {
+		if s.mode == LivenessModeRequire {
+			return nil, fmt.Errorf("liveness check failed: %w", err)
+		}
+		log.Printf("Liveness check of challenge %s failed, flagging the clock-in: %v", challenge.ID, err)
+		return &LivenessResult{Result: models.LivenessResultMissing, ChallengeID: challenge.ID}, nil
+	}
+
+	score := evidence.Score
+	result := &LivenessResult{
+		Result:      models.LivenessResultPassed,
+		Score:       &score,
+		ChallengeID: challenge.ID,
+	}
+	if score < s.minScore {
+		if s.mode == LivenessModeRequire {
+			return nil, fmt.Errorf("%w: score %.2f below %.2f", ErrLivenessFailed, score, s.minScore)
+		}
+		result.Result = models.LivenessResultFailed
+	}
+	return result, nil
+}
+
+func (s *livenessService) DeleteExpiredChallenges(before time.Time) (int64, error) {
+	return s.challengeRepo.DeleteExpired(before)
+}
+
+// photoMatchesFrames reports whether the photo is, up to maxDistance bits of
+// difference hash, one of the frames. Images that cannot be decoded match
+// nothing.
+func photoMatchesFrames(photoPath string, framePaths []string, maxDistance int) bool {
+	photo, err := decodeImage(photoPath)
+	if err != nil {
+		return false
+	}
+	photoHash := differenceHash(photo)
+	for _, framePath := range framePaths {
+		frame, err := decodeImage(framePath)
+		if err != nil {
+			continue
+		}
+		if hammingDistance(photoHash, differenceHash(frame)) <= maxDistance {
+			return true
+		}
+	}
+	return false
+}
+
+// randomActions picks count distinct actions in random order.
+func randomActions(count int) ([]string, error) {
+	actions := append([]string(nil), livenessActions...)
+	for i := len(actions) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return nil, err
+		}
+		actions[i], actions[j.Int64()] = actions[j.Int64()], actions[i]
+	}
+	return actions[:count], nil
+}
+
+type httpLivenessChecker struct {
+	client *faceServiceClient
+}
+
+// NewHTTPLivenessChecker checks liveness with the face recognition service.
+func NewHTTPLivenessChecker(config FaceServiceConfig) LivenessChecker {
+	return &httpLivenessChecker{client: newFaceServiceClient(config)}
+}
+
+func (c *httpLivenessChecker) Check(actions []string, framePaths []string) (*LivenessEvidence, error) {
+	files := make([]formFile, 0, len(framePaths))
+	for _, path := range framePaths {
+		files = append(files, formFile{Field: "frames", Path: path})
+	}
+
+	_, statusCode, bodyBytes, err := c.client.postFiles("/liveness", files, map[string]string{
+		"actions": strings.Join(actions, ","),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Score     *float64 `json:"score"`
+		Completed []string `json:"completed"`
+		Error     string   `json:"error"`
+	}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return nil, fmt.Errorf("face recognition service returned status %d: %s", statusCode, string(bodyBytes))
+	}
+	if statusCode != http.StatusOK {
+		if result.Error != "" {
+			return nil, fmt.Errorf("face recognition service error: %s", result.Error)
+		}
+		return nil, fmt.Errorf("face recognition service returned status %d", statusCode)
+	}
+	if result.Score == nil {
+		return nil, fmt.Errorf("invalid response from face recognition service: score missing")
+	}
+
+	return &LivenessEvidence{Score: *result.Score, Completed: result.Completed}, nil
+}
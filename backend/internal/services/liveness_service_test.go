@@ -0,0 +1,105 @@
+package services
+
+import (
+	"errors"
+	"face-verification-backend/internal/models"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type fakeChallengeRepository struct {
+	challenges map[string]*models.LivenessChallenge
+}
+
+func (r *fakeChallengeRepository) Create(challenge *models.LivenessChallenge) error {
+	r.challenges[challenge.ID] = challenge
+	return nil
+}
+
+func (r *fakeChallengeRepository) FindByID(id string) (*models.LivenessChallenge, error) {
+	challenge, ok := r.challenges[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return challenge, nil
+}
+
+func (r *fakeChallengeRepository) MarkUsed(id string, at time.Time) (bool, error) {
+	challenge := r.challenges[id]
+	if challenge.UsedAt != nil {
+		return false, nil
+	}
+	challenge.UsedAt = &at
+	return true, nil
+}
+
+func (r *fakeChallengeRepository) DeleteExpired(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// passingChecker reports every action performed.
+type passingChecker struct{}
+
+func (passingChecker) Check(actions []string, framePaths []string) (*LivenessEvidence, error) {
+	return &LivenessEvidence{Score: 1, Completed: actions}, nil
+}
+
+func writePNG(t *testing.T, img image.Image) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "frame.png")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLivenessEvaluatePhotoMustBeAFrame(t *testing.T) {
+	frame := writePNG(t, gradientImage(180, 160, true))
+	sameFrame := writePNG(t, gradientImage(90, 80, true))
+	otherPhoto := writePNG(t, gradientImage(180, 160, false))
+
+	tests := []struct {
+		name       string
+		mode       string
+		photoPath  string
+		wantErr    error
+		wantResult string
+	}{
+		{"photo is a frame", LivenessModeRequire, frame, nil, models.LivenessResultPassed},
+		{"photo is a resized frame", LivenessModeRequire, sameFrame, nil, models.LivenessResultPassed},
+		{"photo is not a frame", LivenessModeRequire, otherPhoto, ErrLivenessPhotoMismatch, ""},
+		{"photo is missing", LivenessModeRequire, filepath.Join(t.TempDir(), "missing.png"), ErrLivenessPhotoMismatch, ""},
+		{"photo is not a frame when flagging", LivenessModeFlag, otherPhoto, nil, models.LivenessResultFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeChallengeRepository{challenges: map[string]*models.LivenessChallenge{}}
+			service := NewLivenessService(repo, passingChecker{}, tt.mode, time.Minute, 2, 0.8)
+			challenge, err := service.IssueChallenge("user-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			response := &LivenessResponse{ChallengeID: challenge.ID, Subject: "user-1", FramePaths: []string{frame}}
+			result, err := service.Evaluate(response, tt.photoPath)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Evaluate() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && result.Result != tt.wantResult {
+				t.Errorf("Evaluate() result = %q, want %q", result.Result, tt.wantResult)
+			}
+		})
+	}
+}
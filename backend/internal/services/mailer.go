@@ -0,0 +1,118 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Mailer implementations, selected with MAILER.
+const (
+	MailerSMTP = "smtp"
+	MailerLog  = "log"
+)
+
+// MailMessage is a plain text email.
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails to users.
+type Mailer interface {
+	Send(message MailMessage) error
+}
+
+// SMTPConfig configures the SMTP mailer. Username may be empty for relays
+// that do not require authentication.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// NewMailer returns the mailer named by kind. The log mailer, for development,
+// logs who each email goes to and, when outboxDir is set, writes the email to
+// a .eml file in it. It never logs the body, which may hold a reset link. The
+// kind must be set explicitly, so a deployment cannot fall back to the log
+// mailer by accident.
+func NewMailer(kind string, smtpConfig SMTPConfig, outboxDir string) (Mailer, error) {
+	switch kind {
+	case "":
+		return nil, fmt.Errorf("MAILER is not set, use smtp, or log for development")
+	case MailerLog:
+		return &logMailer{from: smtpConfig.From, outboxDir: outboxDir}, nil
+	case MailerSMTP:
+		if smtpConfig.Host == "" || smtpConfig.From == "" {
+			return nil, fmt.Errorf("smtp mailer requires a host and a from address")
+		}
+		return &smtpMailer{config: smtpConfig}, nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", kind)
+	}
+}
+
+type smtpMailer struct {
+	config SMTPConfig
+}
+
+func (m *smtpMailer) Send(message MailMessage) error {
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+	// smtp.SendMail upgrades to TLS when the server supports STARTTLS
+	if err := smtp.SendMail(addr, auth, m.config.From, []string{message.To}, formatMail(m.config.From, message)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+type logMailer struct {
+	from      string
+	outboxDir string
+}
+
+func (m *logMailer) Send(message MailMessage) error {
+	if m.outboxDir == "" {
+		log.Printf("Email to %s not sent: %s (set MAIL_OUTBOX_DIR to keep emails)", message.To, message.Subject)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.outboxDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create mail outbox: %w", err)
+	}
+	name := time.Now().Format("20060102-150405") + "-" + uuid.New().String()[:8] + ".eml"
+	path := filepath.Join(m.outboxDir, name)
+	if err := os.WriteFile(path, formatMail(m.from, message), 0o600); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	log.Printf("Email to %s written to %s: %s", message.To, path, message.Subject)
+	return nil
+}
+
+// formatMail renders a message as an RFC 5322 email with a UTF-8 text body.
+func formatMail(from string, message MailMessage) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + message.To + "\r\n")
+	b.WriteString("Subject: " + message.Subject + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
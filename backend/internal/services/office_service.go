@@ -0,0 +1,230 @@
+package services
+
+import (
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/repositories"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const earthRadiusMeters = 6371000.0
+
+// Geofence modes control what happens to a clock-in outside every assigned office.
+const (
+	GeofenceModeOff    = "off"
+	GeofenceModeFlag   = "flag"
+	GeofenceModeReject = "reject"
+)
+
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// GeofenceResult describes the office nearest to a clock-in position.
+type GeofenceResult struct {
+	Office         *models.Office
+	DistanceMeters float64
+	Inside         bool
+}
+
+type OfficeService interface {
+	CreateOffice(name, address string, latitude, longitude, radiusMeters float64) (*models.Office, error)
+	GetOffices() ([]*models.Office, error)
+	GetOffice(id string) (*models.Office, error)
+	UpdateOffice(id, name, address string, latitude, longitude, radiusMeters *float64) (*models.Office, error)
+	DeleteOffice(id string) error
+	AssignOffices(userID string, officeIDs []string) error
+	GetUserOffices(userID string) ([]*models.Office, error)
+	GetOfficeUserIDs(officeID string) ([]string, error)
+	CheckGeofence(userID string, coords Coordinates) (*GeofenceResult, error)
+}
+
+type officeService struct {
+	officeRepo repositories.OfficeRepository
+	userRepo   repositories.UserRepository
+}
+
+func NewOfficeService(officeRepo repositories.OfficeRepository, userRepo repositories.UserRepository) OfficeService {
+	return &officeService{
+		officeRepo: officeRepo,
+		userRepo:   userRepo,
+	}
+}
+
+func (s *officeService) CreateOffice(name, address string, latitude, longitude, radiusMeters float64) (*models.Office, error) {
+	if err := validateOfficeArea(latitude, longitude, radiusMeters); err != nil {
+		return nil, err
+	}
+
+	office := &models.Office{
+		ID:           uuid.New().String(),
+		Name:         name,
+		Address:      address,
+		Latitude:     latitude,
+		Longitude:    longitude,
+		RadiusMeters: radiusMeters,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	if err := s.officeRepo.Create(office); err != nil {
+		return nil, err
+	}
+
+	return office, nil
+}
+
+func (s *officeService) GetOffices() ([]*models.Office, error) {
+	return s.officeRepo.FindAll()
+}
+
+func (s *officeService) GetOffice(id string) (*models.Office, error) {
+	return s.officeRepo.FindByID(id)
+}
+
+func (s *officeService) UpdateOffice(id, name, address string, latitude, longitude, radiusMeters *float64) (*models.Office, error) {
+	office, err := s.officeRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != "" {
+		office.Name = name
+	}
+	if address != "" {
+		office.Address = address
+	}
+	if latitude != nil {
+		office.Latitude = *latitude
+	}
+	if longitude != nil {
+		office.Longitude = *longitude
+	}
+	if radiusMeters != nil {
+		office.RadiusMeters = *radiusMeters
+	}
+
+	if err := validateOfficeArea(office.Latitude, office.Longitude, office.RadiusMeters); err != nil {
+		return nil, err
+	}
+	office.UpdatedAt = time.Now()
+
+	if err := s.officeRepo.Update(office); err != nil {
+		return nil, err
+	}
+
+	return office, nil
+}
+
+func (s *officeService) DeleteOffice(id string) error {
+	return s.officeRepo.Delete(id)
+}
+
+func (s *officeService) AssignOffices(userID string, officeIDs []string) error {
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		return fmt.Errorf("user not found")
+	}
+	for _, officeID := range officeIDs {
+		if _, err := s.officeRepo.FindByID(officeID); err != nil {
+			return fmt.Errorf("office not found: %s", officeID)
+		}
+	}
+	return s.officeRepo.ReplaceUserOffices(userID, officeIDs)
+}
+
+func (s *officeService) GetUserOffices(userID string) ([]*models.Office, error) {
+	return s.officeRepo.FindByUserID(userID)
+}
+
+// CheckGeofence finds the assigned office nearest to coords. It returns a nil
+// result when the user has no offices assigned.
+func (s *officeService) CheckGeofence(userID string, coords Coordinates) (*GeofenceResult, error) {
+	offices, err := s.officeRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(offices) == 0 {
+		return nil, nil
+	}
+
+	// Prefer the nearest office whose radius contains the point, otherwise the
+	// nearest office overall
+	var best *GeofenceResult
+	for _, office := range offices {
+		distance := haversineMeters(coords, Coordinates{Latitude: office.Latitude, Longitude: office.Longitude})
+		inside := distance <= office.RadiusMeters
+		if best == nil || (inside && !best.Inside) || (inside == best.Inside && distance < best.DistanceMeters) {
+			best = &GeofenceResult{Office: office, DistanceMeters: distance, Inside: inside}
+		}
+	}
+
+	return best, nil
+}
+
+// GetOfficeUserIDs returns the IDs of the users assigned to the office.
+func (s *officeService) GetOfficeUserIDs(officeID string) ([]string, error) {
+	return s.officeRepo.FindUserIDsByOfficeID(officeID)
+}
+
+// ParseCoordinates parses a "latitude,longitude" pair as sent by the mobile app.
+func ParseCoordinates(location string) (*Coordinates, error) {
+	parts := strings.Split(location, ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("location is not a latitude,longitude pair")
+	}
+
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latitude")
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid longitude")
+	}
+	return NewCoordinates(latitude, longitude)
+}
+
+// NewCoordinates validates a latitude/longitude pair.
+func NewCoordinates(latitude, longitude float64) (*Coordinates, error) {
+	if err := validateCoordinates(latitude, longitude); err != nil {
+		return nil, err
+	}
+	return &Coordinates{Latitude: latitude, Longitude: longitude}, nil
+}
+
+func validateCoordinates(latitude, longitude float64) error {
+	if latitude < -90 || latitude > 90 {
+		return fmt.Errorf("latitude must be between -90 and 90")
+	}
+	if longitude < -180 || longitude > 180 {
+		return fmt.Errorf("longitude must be between -180 and 180")
+	}
+	return nil
+}
+
+func validateOfficeArea(latitude, longitude, radiusMeters float64) error {
+	if err := validateCoordinates(latitude, longitude); err != nil {
+		return err
+	}
+	if radiusMeters <= 0 {
+		return fmt.Errorf("radius_meters must be positive")
+	}
+	return nil
+}
+
+// haversineMeters returns the great-circle distance between two points.
+func haversineMeters(a, b Coordinates) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := (b.Latitude - a.Latitude) * math.Pi / 180
+	dLng := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/repositories"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOvertimeNotFound   = errors.New("overtime request not found")
+	ErrOvertimeNotPending = errors.New("overtime request is no longer pending")
+	ErrOvertimeExists     = errors.New("an overtime request for this day already exists")
+	ErrOvertimeSelfReview = errors.New("overtime requests cannot be reviewed by the requester")
+)
+
+// OvertimeDay is the overtime of one user on one workday.
+type OvertimeDay struct {
+	WorkDate        time.Time `json:"work_date"`
+	WorkedMinutes   int       `json:"worked_minutes"`   // past the scheduled shift end
+	ApprovedMinutes int       `json:"approved_minutes"` // worked minutes covered by an approved request
+	RequestID       string    `json:"request_id,omitempty"`
+	RequestStatus   string    `json:"request_status,omitempty"`
+}
+
+// OvertimeSummary totals a user's overtime over a period. Only approved
+// minutes are payable; the rest is either waiting for review or unapproved.
+type OvertimeSummary struct {
+	UserID            string         `json:"user_id"`
+	WorkedMinutes     int            `json:"worked_minutes"`
+	ApprovedMinutes   int            `json:"approved_minutes"`
+	PendingMinutes    int            `json:"pending_minutes"`
+	UnapprovedMinutes int            `json:"unapproved_minutes"`
+	Days              []*OvertimeDay `json:"days"`
+}
+
+type OvertimeService interface {
+	RequestOvertime(userID string, workDate time.Time, minutes int, reason string) (*models.OvertimeRequest, error)
+	GetUserOvertimeRequests(userID string) ([]*models.OvertimeRequest, error)
+	GetOvertimeRequests(status string) ([]*models.OvertimeRequest, error)
+	ApproveOvertime(requestID, reviewerID, note string, minutes *int) (*models.OvertimeRequest, error)
+	RejectOvertime(requestID, reviewerID, note string) (*models.OvertimeRequest, error)
+	GetSummary(userID string, startDate, endDate time.Time) (*OvertimeSummary, error)
+	GetSummaries(startDate, endDate time.Time) ([]*OvertimeSummary, error)
+}
+
+type overtimeService struct {
+	overtimeRepo   repositories.OvertimeRepository
+	attendanceRepo repositories.AttendanceRepository
+}
+
+func NewOvertimeService(overtimeRepo repositories.OvertimeRepository, attendanceRepo repositories.AttendanceRepository) OvertimeService {
+	return &overtimeService{
+		overtimeRepo:   overtimeRepo,
+		attendanceRepo: attendanceRepo,
+	}
+}
+
+func (s *overtimeService) RequestOvertime(userID string, workDate time.Time, minutes int, reason string) (*models.OvertimeRequest, error) {
+	if minutes <= 0 {
+		return nil, fmt.Errorf("minutes must be positive")
+	}
+
+	if _, err := s.overtimeRepo.FindActiveByUserIDAndWorkDate(userID, workDate); err == nil {
+		return nil, ErrOvertimeExists
+	}
+
+	request := &models.OvertimeRequest{
+		ID:               uuid.New().String(),
+		UserID:           userID,
+		WorkDate:         workDate,
+		RequestedMinutes: minutes,
+		Reason:           reason,
+		Status:           models.OvertimeStatusPending,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
+
+	if err := s.overtimeRepo.Create(request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+func (s *overtimeService) GetUserOvertimeRequests(userID string) ([]*models.OvertimeRequest, error) {
+	return s.overtimeRepo.FindByUserID(userID)
+}
+
+func (s *overtimeService) GetOvertimeRequests(status string) ([]*models.OvertimeRequest, error) {
+	return s.overtimeRepo.FindByStatus(status)
+}
+
+// ApproveOvertime approves the request for the requested minutes, or for the
+// given minutes when the reviewer approves less. Reviewers cannot approve more
+// than was requested.
+func (s *overtimeService) ApproveOvertime(requestID, reviewerID, note string, minutes *int) (*models.OvertimeRequest, error) {
+	request, err := s.findPending(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	approved := request.RequestedMinutes
+	if minutes != nil {
+		if *minutes <= 0 || *minutes > request.RequestedMinutes {
+			return nil, fmt.Errorf("minutes must be between 1 and the %d requested", request.RequestedMinutes)
+		}
+		approved = *minutes
+	}
+
+	request.ApprovedMinutes = approved
+	return s.review(request, models.OvertimeStatusApproved, reviewerID, note)
+}
+
+func (s *overtimeService) RejectOvertime(requestID, reviewerID, note string) (*models.OvertimeRequest, error) {
+	request, err := s.findPending(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.review(request, models.OvertimeStatusRejected, reviewerID, note)
+}
+
+func (s *overtimeService) GetSummary(userID string, startDate, endDate time.Time) (*OvertimeSummary, error) {
+	summaries, err := s.summarize(userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	if len(summaries) == 0 {
+		return &OvertimeSummary{UserID: userID, Days: []*OvertimeDay{}}, nil
+	}
+	return summaries[0], nil
+}
+
+// GetSummaries returns the summary of every user with overtime or an overtime
+// request in the period.
+func (s *overtimeService) GetSummaries(startDate, endDate time.Time) ([]*OvertimeSummary, error) {
+	return s.summarize("", startDate, endDate)
+}
+
+func (s *overtimeService) summarize(userID string, startDate, endDate time.Time) ([]*OvertimeSummary, error) {
+	attendances, err := s.attendanceRepo.FindWithOvertimeByDateRange(userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := s.overtimeRepo.FindActiveByDateRange(userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[string]*OvertimeSummary)
+	days := make(map[string]*OvertimeDay)
+	dayOf := func(userID string, workDate time.Time) *OvertimeDay {
+		key := userID + "/" + workDate.Format("2006-01-02")
+		if day, ok := days[key]; ok {
+			return day
+		}
+		summary, ok := summaries[userID]
+		if !ok {
+			summary = &OvertimeSummary{UserID: userID}
+			summaries[userID] = summary
+		}
+		day := &OvertimeDay{WorkDate: workDate}
+		days[key] = day
+		summary.Days = append(summary.Days, day)
+		return day
+	}
+
+	for _, attendance := range attendances {
+		dayOf(attendance.UserID, attendance.WorkDate).WorkedMinutes = attendance.OvertimeMinutes
+	}
+	approvedLimits := make(map[*OvertimeDay]int)
+	for _, request := range requests {
+		day := dayOf(request.UserID, request.WorkDate)
+		day.RequestID = request.ID
+		day.RequestStatus = request.Status
+		if request.Status == models.OvertimeStatusApproved {
+			approvedLimits[day] = request.ApprovedMinutes
+		}
+	}
+
+	result := make([]*OvertimeSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		for _, day := range summary.Days {
+			day.ApprovedMinutes = min(day.WorkedMinutes, approvedLimits[day])
+			summary.WorkedMinutes += day.WorkedMinutes
+			summary.ApprovedMinutes += day.ApprovedMinutes
+			if day.RequestStatus == models.OvertimeStatusPending {
+				summary.PendingMinutes += day.WorkedMinutes
+			} else {
+				summary.UnapprovedMinutes += day.WorkedMinutes - day.ApprovedMinutes
+			}
+		}
+		sort.Slice(summary.Days, func(i, j int) bool {
+			return summary.Days[i].WorkDate.Before(summary.Days[j].WorkDate)
+		})
+		result = append(result, summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UserID < result[j].UserID
+	})
+
+	return result, nil
+}
+
+func (s *overtimeService) findPending(requestID string) (*models.OvertimeRequest, error) {
+	request, err := s.overtimeRepo.FindByID(requestID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOvertimeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != models.OvertimeStatusPending {
+		return nil, ErrOvertimeNotPending
+	}
+	return request, nil
+}
+
+// review closes a pending request. Requesters cannot review their own requests,
+// and only one of concurrent reviews gets to close a request.
+func (s *overtimeService) review(request *models.OvertimeRequest, status, reviewerID, note string) (*models.OvertimeRequest, error) {
+	if request.UserID == reviewerID {
+		return nil, ErrOvertimeSelfReview
+	}
+
+	now := time.Now()
+	request.Status = status
+	request.ReviewerID = reviewerID
+	request.ReviewNote = note
+	request.ReviewedAt = &now
+	request.UpdatedAt = now
+
+	reviewed, err := s.overtimeRepo.Review(request)
+	if err != nil {
+		return nil, err
+	}
+	if !reviewed {
+		return nil, ErrOvertimeNotPending
+	}
+
+	return request, nil
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/repositories"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// DefaultPasswordResetTTL is how long a reset link can be used.
+const DefaultPasswordResetTTL = 30 * time.Minute
+
+const (
+	// passwordResetTokenPrefix starts every password reset token.
+	passwordResetTokenPrefix = "fvp_"
+	// passwordResetCooldown is the minimum time between two reset emails to
+	// the same user.
+	passwordResetCooldown = time.Minute
+)
+
+var ErrInvalidResetToken = errors.New("Link reset password tidak valid atau sudah kedaluwarsa")
+
+type PasswordResetService interface {
+	// RequestReset emails a reset link to the user with the email. Unknown
+	// emails are ignored, so the response does not reveal who has an account.
+	RequestReset(email, clientIP string) error
+	// ResetPassword sets a new password with a token from a reset link and
+	// signs the user out on every device.
+	ResetPassword(token, newPassword string) error
+	DeleteExpiredTokens(before time.Time) (int64, error)
+}
+
+type passwordResetService struct {
+	userRepo    repositories.UserRepository
+	resetRepo   repositories.PasswordResetRepository
+	authService AuthService
+	mailer      Mailer
+	resetURL    string
+	ttl         time.Duration
+}
+
+func NewPasswordResetService(userRepo repositories.UserRepository, resetRepo repositories.PasswordResetRepository, authService AuthService, mailer Mailer, resetURL string, ttl time.Duration) PasswordResetService {
+	if ttl <= 0 {
+		ttl = DefaultPasswordResetTTL
+	}
+	return &passwordResetService{
+		userRepo:    userRepo,
+		resetRepo:   resetRepo,
+		authService: authService,
+		mailer:      mailer,
+		resetURL:    resetURL,
+		ttl:         ttl,
+	}
+}
+
+func (s *passwordResetService) RequestReset(email, clientIP string) error {
+	user, err := s.userRepo.FindByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	latest, err := s.resetRepo.FindLatestByUserID(user.ID)
+	if err == nil && now.Sub(latest.CreatedAt) < passwordResetCooldown {
+		return nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	token, _, err := generateAPIKey(passwordResetTokenPrefix)
+	if err != nil {
+		return err
+	}
+	// Only the most recent link works
+	if err := s.resetRepo.InvalidateByUserID(user.ID, now); err != nil {
+		return err
+	}
+	if err := s.resetRepo.Create(&models.PasswordResetToken{
+		ID:          uuid.New().String(),
+		UserID:      user.ID,
+		TokenHash:   hashAPIKey(token),
+		ExpiresAt:   now.Add(s.ttl),
+		RequestedIP: clientIP,
+		CreatedAt:   now,
+	}); err != nil {
+		return err
+	}
+
+	message := MailMessage{
+		To:      user.Email,
+		Subject: "Reset password",
+		Body: fmt.Sprintf("Halo %s,\n\n"+
+			"Kami menerima permintaan untuk mereset password akun Anda. Buka link berikut untuk membuat password baru:\n\n"+
+			"%s\n\n"+
+			"Link ini berlaku selama %d menit dan hanya dapat digunakan sekali. "+
+			"Jika Anda tidak meminta reset password, abaikan email ini.\n",
+			user.Name, s.resetLink(token), int(s.ttl.Minutes())),
+	}
+	// Sent in the background so the response time does not reveal whether the
+	// email has an account
+	go func() {
+		if err := s.mailer.Send(message); err != nil {
+			log.Printf("Failed to send password reset email to user %s: %v", user.ID, err)
+		}
+	}()
+	return nil
+}
+
+func (s *passwordResetService) ResetPassword(token, newPassword string) error {
+	if _, ok := apiKeyPrefix(token, passwordResetTokenPrefix); !ok {
+		return ErrInvalidResetToken
+	}
+
+	reset, err := s.resetRepo.FindByTokenHash(hashAPIKey(token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if !reset.IsUsable(now) {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.userRepo.FindByID(reset.UserID)
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("Gagal mengenkripsi password")
+	}
+
+	used, err := s.resetRepo.MarkUsed(reset.ID, now)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidResetToken
+	}
+
+	user.Password = string(hashedPassword)
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	if err := s.resetRepo.InvalidateByUserID(user.ID, now); err != nil {
+		return err
+	}
+	// Whoever knew the old password is signed out too
+	_, err = s.authService.RevokeAllSessions(user.ID)
+	return err
+}
+
+func (s *passwordResetService) DeleteExpiredTokens(before time.Time) (int64, error) {
+	return s.resetRepo.DeleteExpired(before)
+}
+
+// resetLink adds the token to the configured reset page URL.
+func (s *passwordResetService) resetLink(token string) string {
+	link, err := url.Parse(s.resetURL)
+	if err != nil {
+		return s.resetURL + "?token=" + url.QueryEscape(token)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
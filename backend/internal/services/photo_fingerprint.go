@@ -0,0 +1,236 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math/bits"
+	"strconv"
+	"time"
+)
+
+// photoFingerprint identifies a photo: exactly by its content and
+// approximately by what it shows.
+type photoFingerprint struct {
+	ContentHash    string
+	PerceptualHash uint64
+	HasPerceptual  bool       // false when the image could not be decoded
+	CapturedAt     *time.Time // EXIF capture time, when present
+}
+
+func fingerprintPhoto(data []byte) *photoFingerprint {
+	sum := sha256.Sum256(data)
+	fingerprint := &photoFingerprint{ContentHash: hex.EncodeToString(sum[:])}
+
+	if img, _, err := image.Decode(bytes.NewReader(data)); err == nil {
+		fingerprint.PerceptualHash = differenceHash(img)
+		fingerprint.HasPerceptual = true
+	}
+	if capturedAt, ok := exifCaptureTime(data); ok {
+		fingerprint.CapturedAt = &capturedAt
+	}
+	return fingerprint
+}
+
+// dHashSamples is the number of pixels sampled per axis in each cell of the
+// difference hash grid, so large photos cost the same as small ones.
+const dHashSamples = 8
+
+// differenceHash computes a 64-bit difference hash: the image is reduced to a
+// 9x8 grayscale grid and each bit tells whether a cell is brighter than its
+// right neighbour. Re-encoded, resized or slightly edited copies of a photo
+// keep most of their bits.
+func differenceHash(img image.Image) uint64 {
+	const cols, rows = 9, 8
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return 0
+	}
+
+	var grid [rows][cols]float64
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			var sum float64
+			for sy := 0; sy < dHashSamples; sy++ {
+				y := bounds.Min.Y + ((row*dHashSamples+sy)*2+1)*height/(rows*dHashSamples*2)
+				for sx := 0; sx < dHashSamples; sx++ {
+					x := bounds.Min.X + ((col*dHashSamples+sx)*2+1)*width/(cols*dHashSamples*2)
+					r, g, b, _ := img.At(x, y).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+				}
+			}
+			grid[row][col] = sum
+		}
+	}
+
+	var hash uint64
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols-1; col++ {
+			hash <<= 1
+			if grid[row][col] > grid[row][col+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+func formatPerceptualHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+func parsePerceptualHash(value string) (uint64, error) {
+	return strconv.ParseUint(value, 16, 64)
+}
+
+func hammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// EXIF tags read for the capture time.
+const (
+	exifTagDateTime           = 0x0132
+	exifTagExifIFD            = 0x8769
+	exifTagDateTimeOriginal   = 0x9003
+	exifTagOffsetTimeOriginal = 0x9011
+)
+
+// exifCaptureTime reads the capture time from the EXIF data of a JPEG. The
+// EXIF date has no time zone unless the camera recorded its offset, so it is
+// read in the server's time zone otherwise.
+func exifCaptureTime(data []byte) (time.Time, bool) {
+	tiff, ok := jpegExifSegment(data)
+	if !ok || len(tiff) < 8 {
+		return time.Time{}, false
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return time.Time{}, false
+	}
+	if order.Uint16(tiff[2:4]) != 42 {
+		return time.Time{}, false
+	}
+
+	ifd0 := readExifIFD(tiff, order, order.Uint32(tiff[4:8]))
+	value, offset := ifd0[exifTagDateTime], ""
+	if pointer, ok := ifd0[exifTagExifIFD]; ok && len(pointer) == 4 {
+		exifIFD := readExifIFD(tiff, order, order.Uint32(pointer))
+		if original, ok := exifIFD[exifTagDateTimeOriginal]; ok {
+			value = original
+			offset = exifString(exifIFD[exifTagOffsetTimeOriginal])
+		}
+	}
+
+	text := exifString(value)
+	if text == "" {
+		return time.Time{}, false
+	}
+	if offset != "" {
+		if t, err := time.Parse("2006:01:02 15:04:05-07:00", text+offset); err == nil {
+			return t, true
+		}
+	}
+	t, err := time.ParseInLocation("2006:01:02 15:04:05", text, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// jpegExifSegment returns the TIFF data of the EXIF segment of a JPEG.
+func jpegExifSegment(data []byte) ([]byte, bool) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, false
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, false
+		}
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 { // image data starts, no EXIF before it
+			return nil, false
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 2 || pos+2+length > len(data) {
+			return nil, false
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return segment[6:], true
+		}
+		pos += 2 + length
+	}
+	return nil, false
+}
+
+// readExifIFD returns the raw values of the entries of the IFD at offset.
+// Values of four bytes or less are stored in the entry itself.
+func readExifIFD(tiff []byte, order binary.ByteOrder, offset uint32) map[uint16][]byte {
+	entries := make(map[uint16][]byte)
+	start := int(offset)
+	if offset == 0 || start+2 > len(tiff) {
+		return entries
+	}
+
+	count := int(order.Uint16(tiff[start : start+2]))
+	for i := 0; i < count; i++ {
+		entry := start + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		tag := order.Uint16(tiff[entry : entry+2])
+		size := exifTypeSize(order.Uint16(tiff[entry+2 : entry+4]))
+		if size == 0 {
+			continue
+		}
+		length := int(order.Uint32(tiff[entry+4:entry+8])) * size
+		if length <= 4 {
+			entries[tag] = tiff[entry+8 : entry+8+length]
+			continue
+		}
+		valueOffset := int(order.Uint32(tiff[entry+8 : entry+12]))
+		if valueOffset < 0 || valueOffset+length > len(tiff) {
+			continue
+		}
+		entries[tag] = tiff[valueOffset : valueOffset+length]
+	}
+	return entries
+}
+
+// exifTypeSize returns the size in bytes of one value of an EXIF type, or 0
+// for types that are not read.
+func exifTypeSize(exifType uint16) int {
+	switch exifType {
+	case 1, 2, 7: // byte, ascii, undefined
+		return 1
+	case 3: // short
+		return 2
+	case 4: // long
+		return 4
+	default:
+		return 0
+	}
+}
+
+// exifString returns an ASCII value without its NUL terminator.
+func exifString(value []byte) string {
+	if i := bytes.IndexByte(value, 0); i >= 0 {
+		value = value[:i]
+	}
+	return string(bytes.TrimSpace(value))
+}
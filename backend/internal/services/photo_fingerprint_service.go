@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/repositories"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrPhotoReplay is returned when a punch photo is identical to one the user
+// submitted before.
+var ErrPhotoReplay = errors.New("this photo was already submitted, take a new one")
+
+// PhotoCheck is the outcome of checking a punch photo against the user's
+// previous submissions.
+type PhotoCheck struct {
+	ContentHash    string
+	PerceptualHash string
+	CapturedAt     *time.Time
+	Flags          []string // near_duplicate, capture_time_skew
+}
+
+// Flagged reports whether the photo should be reviewed.
+func (c *PhotoCheck) Flagged() bool {
+	return c != nil && len(c.Flags) > 0
+}
+
+type PhotoFingerprintService interface {
+	// Check rejects a photo the user already submitted and flags one that
+	// looks like an earlier submission or was not taken around now.
+	Check(userID, photoPath string, now time.Time) (*PhotoCheck, error)
+	// Record keeps the fingerprint of an accepted photo for later checks.
+	Record(userID, attendanceID, punchType string, check *PhotoCheck) error
+}
+
+type photoFingerprintService struct {
+	photoRepo             repositories.PunchPhotoRepository
+	nearDuplicateDistance int
+	maxCaptureSkew        time.Duration
+	history               time.Duration
+}
+
+// NewPhotoFingerprintService returns a service that flags photos within
+// nearDuplicateDistance bits of a submission from the last history, and photos
+// whose EXIF capture time is more than maxCaptureSkew away from the server
+// time. A negative distance or a zero skew disables that check.
+func NewPhotoFingerprintService(photoRepo repositories.PunchPhotoRepository, nearDuplicateDistance int, maxCaptureSkew, history time.Duration) PhotoFingerprintService {
+	return &photoFingerprintService{
+		photoRepo:             photoRepo,
+		nearDuplicateDistance: nearDuplicateDistance,
+		maxCaptureSkew:        maxCaptureSkew,
+		history:               history,
+	}
+}
+
+func (s *photoFingerprintService) Check(userID, photoPath string, now time.Time) (*PhotoCheck, error) {
+	data, err := os.ReadFile(photoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read photo: %w", err)
+	}
+	fingerprint := fingerprintPhoto(data)
+
+	if _, err := s.photoRepo.FindByUserIDAndContentHash(userID, fingerprint.ContentHash); err == nil {
+		return nil, ErrPhotoReplay
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	check := &PhotoCheck{
+		ContentHash: fingerprint.ContentHash,
+		CapturedAt:  fingerprint.CapturedAt,
+	}
+
+	if fingerprint.HasPerceptual {
+		check.PerceptualHash = formatPerceptualHash(fingerprint.PerceptualHash)
+		if s.nearDuplicateDistance >= 0 {
+			nearDuplicate, err := s.isNearDuplicate(userID, fingerprint.PerceptualHash, now)
+			if err != nil {
+				return nil, err
+			}
+			if nearDuplicate {
+				check.Flags = append(check.Flags, models.PhotoFlagNearDuplicate)
+			}
+		}
+	}
+
+	if s.maxCaptureSkew > 0 && fingerprint.CapturedAt != nil {
+		skew := now.Sub(*fingerprint.CapturedAt)
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > s.maxCaptureSkew {
+			check.Flags = append(check.Flags, models.PhotoFlagCaptureTimeSkew)
+		}
+	}
+
+	return check, nil
+}
+
+func (s *photoFingerprintService) isNearDuplicate(userID string, hash uint64, now time.Time) (bool, error) {
+	previous, err := s.photoRepo.FindByUserIDSince(userID, now.Add(-s.history))
+	if err != nil {
+		return false, err
+	}
+	for _, photo := range previous {
+		previousHash, err := parsePerceptualHash(photo.PerceptualHash)
+		if err != nil {
+			continue
+		}
+		if hammingDistance(hash, previousHash) <= s.nearDuplicateDistance {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *photoFingerprintService) Record(userID, attendanceID, punchType string, check *PhotoCheck) error {
+	if check == nil {
+		return nil
+	}
+	return s.photoRepo.Create(&models.PunchPhoto{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		AttendanceID:   attendanceID,
+		PunchType:      punchType,
+		ContentHash:    check.ContentHash,
+		PerceptualHash: check.PerceptualHash,
+		CapturedAt:     check.CapturedAt,
+		CreatedAt:      time.Now(),
+	})
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// gradientImage is a horizontal grayscale gradient, brightest on the left when
+// decreasing is set.
+func gradientImage(width, height int, decreasing bool) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		level := uint8(x * 255 / (width - 1))
+		if decreasing {
+			level = 255 - level
+		}
+		for y := 0; y < height; y++ {
+			img.SetGray(x, y, color.Gray{Y: level})
+		}
+	}
+	return img
+}
+
+func uniformImage(width, height int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.SetGray(x, y, color.Gray{Y: 128})
+		}
+	}
+	return img
+}
+
+func TestHammingDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b uint64
+		want int
+	}{
+		{"identical", 0xdeadbeef, 0xdeadbeef, 0},
+		{"one bit", 0b1000, 0b0000, 1},
+		{"several bits", 0b1011, 0b0110, 3},
+		{"complement", 0, ^uint64(0), 64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hammingDistance(tt.a, tt.b); got != tt.want {
+				t.Errorf("hammingDistance(%x, %x) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+			if got := hammingDistance(tt.b, tt.a); got != tt.want {
+				t.Errorf("hammingDistance is not symmetric: %d", got)
+			}
+		})
+	}
+}
+
+func TestDifferenceHash(t *testing.T) {
+	tests := []struct {
+		name string
+		img  image.Image
+		want uint64
+	}{
+		{"brighter on the left", gradientImage(180, 160, true), ^uint64(0)},
+		{"brighter on the right", gradientImage(180, 160, false), 0},
+		{"uniform", uniformImage(64, 64), 0},
+		{"empty", image.NewGray(image.Rect(0, 0, 0, 0)), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := differenceHash(tt.img); got != tt.want {
+				t.Errorf("differenceHash() = %016x, want %016x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDifferenceHashDistance(t *testing.T) {
+	original := differenceHash(gradientImage(900, 800, true))
+
+	tests := []struct {
+		name string
+		img  image.Image
+		want int
+	}{
+		{"resized copy", gradientImage(90, 80, true), 0},
+		{"different aspect ratio", gradientImage(400, 800, true), 0},
+		{"mirrored", gradientImage(900, 800, false), 64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hammingDistance(original, differenceHash(tt.img)); got != tt.want {
+				t.Errorf("distance = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPerceptualHashRoundTrip(t *testing.T) {
+	for _, hash := range []uint64{0, 1, 0x0123456789abcdef, ^uint64(0)} {
+		formatted := formatPerceptualHash(hash)
+		if len(formatted) != 16 {
+			t.Errorf("formatPerceptualHash(%x) = %q, want 16 hex digits", hash, formatted)
+		}
+		parsed, err := parsePerceptualHash(formatted)
+		if err != nil || parsed != hash {
+			t.Errorf("parsePerceptualHash(%q) = %x, %v, want %x", formatted, parsed, err, hash)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/repositories"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultKeyRotationGrace is how long a rotated key keeps working, so the
+// services using it can switch to the new key without downtime.
+const DefaultKeyRotationGrace = time.Hour
+
+// serviceKeyPrefix starts every service key, to recognise leaked keys.
+const serviceKeyPrefix = "fvs_"
+
+// lastUsedPrecision bounds how often LastUsedAt is written for a busy key.
+const lastUsedPrecision = time.Minute
+
+var (
+	ErrInvalidServiceKey         = errors.New("invalid service key")
+	ErrServiceCredentialNotFound = errors.New("service credential not found")
+	ErrServiceCredentialInactive = errors.New("service credential is revoked or expired")
+	ErrUnknownScope              = errors.New("unknown scope")
+)
+
+var knownScopes = map[string]bool{
+	models.ScopeEmbeddingsRead: true,
+}
+
+type ServiceCredentialService interface {
+	// CreateCredential returns the credential and its key. The key is not
+	// stored and cannot be retrieved again.
+	CreateCredential(name string, scopes []string, createdBy string, expiresAt *time.Time) (*models.ServiceCredential, string, error)
+	GetCredentials() ([]*models.ServiceCredential, error)
+	// RotateCredential issues a new key with the same name and scopes. The old
+	// key expires after the grace period.
+	RotateCredential(id, createdBy string, grace time.Duration) (*models.ServiceCredential, string, error)
+	RevokeCredential(id string) (*models.ServiceCredential, error)
+	Authenticate(key string) (*models.ServiceCredential, error)
+}
+
+type serviceCredentialService struct {
+	credentialRepo repositories.ServiceCredentialRepository
+}
+
+func NewServiceCredentialService(credentialRepo repositories.ServiceCredentialRepository) ServiceCredentialService {
+	return &serviceCredentialService{credentialRepo: credentialRepo}
+}
+
+func (s *serviceCredentialService) CreateCredential(name string, scopes []string, createdBy string, expiresAt *time.Time) (*models.ServiceCredential, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("name is required")
+	}
+	if len(scopes) == 0 {
+		return nil, "", fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !knownScopes[scope] {
+			return nil, "", fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", fmt.Errorf("expires_at must be in the future")
+	}
+
+	return s.issue(name, strings.Join(scopes, ","), createdBy, expiresAt)
+}
+
+func (s *serviceCredentialService) GetCredentials() ([]*models.ServiceCredential, error) {
+	return s.credentialRepo.FindAll()
+}
+
+func (s *serviceCredentialService) RotateCredential(id, createdBy string, grace time.Duration) (*models.ServiceCredential, string, error) {
+	old, err := s.find(id)
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	if !old.IsActive(now) {
+		return nil, "", ErrServiceCredentialInactive
+	}
+	if grace < 0 {
+		grace = 0
+	}
+
+	credential, key, err := s.issue(old.Name, old.Scopes, createdBy, old.ExpiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	expiresAt := now.Add(grace)
+	if old.ExpiresAt == nil || expiresAt.Before(*old.ExpiresAt) {
+		old.ExpiresAt = &expiresAt
+	}
+	old.RotatedTo = credential.ID
+	old.UpdatedAt = now
+	if err := s.credentialRepo.Update(old); err != nil {
+		return nil, "", err
+	}
+
+	return credential, key, nil
+}
+
+func (s *serviceCredentialService) RevokeCredential(id string) (*models.ServiceCredential, error) {
+	credential, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if credential.RevokedAt != nil {
+		return nil, ErrServiceCredentialInactive
+	}
+
+	now := time.Now()
+	credential.RevokedAt = &now
+	credential.UpdatedAt = now
+	if err := s.credentialRepo.Update(credential); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// Authenticate returns the active credential the key belongs to.
+func (s *serviceCredentialService) Authenticate(key string) (*models.ServiceCredential, error) {
+	prefix, ok := apiKeyPrefix(key, serviceKeyPrefix)
+	if !ok {
+		return nil, ErrInvalidServiceKey
+	}
+
+	credential, err := s.credentialRepo.FindByKeyPrefix(prefix)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidServiceKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(key)), []byte(credential.KeyHash)) != 1 {
+		return nil, ErrInvalidServiceKey
+	}
+
+	now := time.Now()
+	if !credential.IsActive(now) {
+		return nil, ErrServiceCredentialInactive
+	}
+	if credential.LastUsedAt == nil || now.Sub(*credential.LastUsedAt) >= lastUsedPrecision {
+		if err := s.credentialRepo.UpdateLastUsedAt(credential.ID, now); err != nil {
+			log.Printf("Failed to update last use of service key %s: %v", credential.KeyPrefix, err)
+		}
+		credential.LastUsedAt = &now
+	}
+
+	return credential, nil
+}
+
+func (s *serviceCredentialService) issue(name, scopes, createdBy string, expiresAt *time.Time) (*models.ServiceCredential, string, error) {
+	key, prefix, err := generateAPIKey(serviceKeyPrefix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	credential := &models.ServiceCredential{
+		ID:        uuid.New().String(),
+		Name:      name,
+		KeyPrefix: prefix,
+		KeyHash:   hashAPIKey(key),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.credentialRepo.Create(credential); err != nil {
+		return nil, "", err
+	}
+
+	return credential, key, nil
+}
+
+func (s *serviceCredentialService) find(id string) (*models.ServiceCredential, error) {
+	credential, err := s.credentialRepo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrServiceCredentialNotFound
+	}
+	return credential, err
+}
+
+// generateAPIKey returns a key of the form <prefix><id>_<secret> and its
+// public part, <prefix><id>, used to look the key up.
+func generateAPIKey(keyPrefix string) (string, string, error) {
+	id := make([]byte, 6)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	prefix := keyPrefix + hex.EncodeToString(id)
+	return prefix + "_" + hex.EncodeToString(secret), prefix, nil
+}
+
+// apiKeyPrefix returns the public part of a key generated by generateAPIKey.
+func apiKeyPrefix(key, keyPrefix string) (string, bool) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return "", false
+	}
+	i := strings.LastIndex(key, "_")
+	if i <= len(keyPrefix) {
+		return "", false
+	}
+	return key[:i], true
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"errors"
+	"face-verification-backend/internal/models"
+	"face-verification-backend/internal/repositories"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const shiftTimeLayout = "15:04"
+
+var (
+	ErrNoShiftAssigned = errors.New("no shift assigned")
+	ErrShiftNotFound   = errors.New("shift not found")
+	ErrShiftInUse      = errors.New("shift is assigned to users")
+	ErrUserNotFound    = errors.New("user not found")
+)
+
+type ShiftService interface {
+	CreateShift(name, startTime, endTime string, gracePeriodMinutes int) (*models.Shift, error)
+	GetShifts() ([]*models.Shift, error)
+	GetShift(id string) (*models.Shift, error)
+	UpdateShift(id, name, startTime, endTime string, gracePeriodMinutes *int) (*models.Shift, error)
+	DeleteShift(id string) error
+	AssignShift(userID, shiftID string) error
+	GetUserShift(userID string) (*models.Shift, error)
+	GetScheduleStart(userID string) (time.Time, error)
+}
+
+type shiftService struct {
+	shiftRepo repositories.ShiftRepository
+	userRepo  repositories.UserRepository
+}
+
+func NewShiftService(shiftRepo repositories.ShiftRepository, userRepo repositories.UserRepository) ShiftService {
+	return &shiftService{
+		shiftRepo: shiftRepo,
+		userRepo:  userRepo,
+	}
+}
+
+func (s *shiftService) CreateShift(name, startTime, endTime string, gracePeriodMinutes int) (*models.Shift, error) {
+	if err := validateShiftTimes(startTime, endTime); err != nil {
+		return nil, err
+	}
+	if gracePeriodMinutes < 0 {
+		return nil, fmt.Errorf("grace_period_minutes must not be negative")
+	}
+
+	shift := &models.Shift{
+		ID:                 uuid.New().String(),
+		Name:               name,
+		StartTime:          startTime,
+		EndTime:            endTime,
+		GracePeriodMinutes: gracePeriodMinutes,
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
+	}
+
+	if err := s.shiftRepo.Create(shift); err != nil {
+		return nil, err
+	}
+
+	return shift, nil
+}
+
+func (s *shiftService) GetShifts() ([]*models.Shift, error) {
+	return s.shiftRepo.FindAll()
+}
+
+func (s *shiftService) GetShift(id string) (*models.Shift, error) {
+	return s.shiftRepo.FindByID(id)
+}
+
+func (s *shiftService) UpdateShift(id, name, startTime, endTime string, gracePeriodMinutes *int) (*models.Shift, error) {
+	shift, err := s.shiftRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != "" {
+		shift.Name = name
+	}
+	if startTime != "" {
+		shift.StartTime = startTime
+	}
+	if endTime != "" {
+		shift.EndTime = endTime
+	}
+	if gracePeriodMinutes != nil {
+		if *gracePeriodMinutes < 0 {
+			return nil, fmt.Errorf("grace_period_minutes must not be negative")
+		}
+		shift.GracePeriodMinutes = *gracePeriodMinutes
+	}
+
+	if err := validateShiftTimes(shift.StartTime, shift.EndTime); err != nil {
+		return nil, err
+	}
+	shift.UpdatedAt = time.Now()
+
+	if err := s.shiftRepo.Update(shift); err != nil {
+		return nil, err
+	}
+
+	return shift, nil
+}
+
+// DeleteShift refuses to delete a shift users are still assigned to, since
+// their attendance would lose its lateness classification.
+func (s *shiftService) DeleteShift(id string) error {
+	assigned, err := s.shiftRepo.Delete(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrShiftNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if assigned > 0 {
+		return fmt.Errorf("%w: reassign its %d user(s) first", ErrShiftInUse, assigned)
+	}
+	return nil
+}
+
+// AssignShift sets the user's shift. An empty shiftID removes the assignment.
+func (s *shiftService) AssignShift(userID, shiftID string) error {
+	if _, err := s.userRepo.FindByID(userID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	} else if err != nil {
+		return err
+	}
+	if shiftID != "" {
+		if _, err := s.shiftRepo.FindByID(shiftID); errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrShiftNotFound
+		} else if err != nil {
+			return err
+		}
+	}
+	return s.userRepo.UpdateShiftID(userID, shiftID)
+}
+
+func (s *shiftService) GetUserShift(userID string) (*models.Shift, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ShiftID == "" {
+		return nil, ErrNoShiftAssigned
+	}
+	return s.shiftRepo.FindByID(user.ShiftID)
+}
+
+// GetScheduleStart returns when attendance starts being expected from the user:
+// when the user was created, or first assigned a shift if that was later.
+func (s *shiftService) GetScheduleStart(userID string) (time.Time, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if user.ShiftAssignedAt != nil && user.ShiftAssignedAt.After(user.CreatedAt) {
+		return *user.ShiftAssignedAt, nil
+	}
+	return user.CreatedAt, nil
+}
+
+func validateShiftTimes(startTime, endTime string) error {
+	if _, err := time.Parse(shiftTimeLayout, startTime); err != nil {
+		return fmt.Errorf("invalid start_time, expected HH:MM")
+	}
+	if _, err := time.Parse(shiftTimeLayout, endTime); err != nil {
+		return fmt.Errorf("invalid end_time, expected HH:MM")
+	}
+	return nil
+}
+
+// shiftBounds returns the scheduled start and end of the shift on the given
+// workday. A shift whose end is not after its start finishes on the next day.
+func shiftBounds(shift *models.Shift, workDate time.Time) (time.Time, time.Time) {
+	start := workDate.Add(clockOffset(shift.StartTime))
+	end := workDate.Add(clockOffset(shift.EndTime))
+	if !end.After(start) {
+		end = end.Add(24 * time.Hour)
+	}
+	return start, end
+}
+
+// shiftDayBoundary places the workday boundary halfway through the off-duty gap
+// before the start of the shift, so a shift that crosses midnight stays on a
+// single workday. The boundary is negative when it falls the evening before, as
+// for an early shift, so the shift still belongs to the day it starts on.
+func shiftDayBoundary(shift *models.Shift) time.Duration {
+	start := clockOffset(shift.StartTime)
+	end := clockOffset(shift.EndTime)
+	if end <= start {
+		end += 24 * time.Hour
+	}
+	gap := 24*time.Hour - (end - start)
+	return start - gap/2
+}
+
+// clockOffset converts an HH:MM shift time to an offset from midnight.
+func clockOffset(clock string) time.Duration {
+	t, _ := time.Parse(shiftTimeLayout, clock)
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
+
+// classifyClockIn reports whether a clock-in is on time or late, and by how many minutes.
+func classifyClockIn(shift *models.Shift, workDate, clockIn time.Time) (string, int) {
+	start, _ := shiftBounds(shift, workDate)
+	grace := time.Duration(shift.GracePeriodMinutes) * time.Minute
+	if clockIn.After(start.Add(grace)) {
+		return models.AttendanceStatusLate, int(clockIn.Sub(start).Minutes())
+	}
+	return models.AttendanceStatusOnTime, 0
+}
+
+// classifyClockOut reports whether a clock-out is an early leave, overtime or on time,
+// together with the early-leave and overtime minutes.
+func classifyClockOut(shift *models.Shift, workDate, clockOut time.Time) (string, int, int) {
+	_, end := shiftBounds(shift, workDate)
+	grace := time.Duration(shift.GracePeriodMinutes) * time.Minute
+	switch {
+	case clockOut.Before(end):
+		return models.AttendanceStatusEarlyLeave, int(end.Sub(clockOut).Minutes()), 0
+	case clockOut.After(end.Add(grace)):
+		return models.AttendanceStatusOvertime, 0, int(clockOut.Sub(end).Minutes())
+	default:
+		return models.AttendanceStatusOnTime, 0, 0
+	}
+}
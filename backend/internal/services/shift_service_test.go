@@ -0,0 +1,167 @@
+package services
+
+import (
+	"face-verification-backend/internal/models"
+	"testing"
+	"time"
+)
+
+func TestShiftBounds(t *testing.T) {
+	workDate := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		start     string
+		end       string
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{"day shift", "08:00", "17:00", at(4, 8, 0), at(4, 17, 0)},
+		{"overnight shift", "22:00", "06:00", at(4, 22, 0), at(5, 6, 0)},
+		{"ends at midnight", "16:00", "00:00", at(4, 16, 0), at(5, 0, 0)},
+		{"round the clock", "07:00", "07:00", at(4, 7, 0), at(5, 7, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := shiftBounds(&models.Shift{StartTime: tt.start, EndTime: tt.end}, workDate)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("shiftBounds() = %v, %v, want %v, %v", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestShiftDayBoundary(t *testing.T) {
+	tests := []struct {
+		name  string
+		start string
+		end   string
+		want  time.Duration
+	}{
+		{"day shift", "08:00", "17:00", 30 * time.Minute},
+		{"overnight shift", "22:00", "06:00", 14 * time.Hour},
+		{"evening shift", "14:00", "22:00", 6 * time.Hour},
+		{"round the clock", "07:00", "07:00", 7 * time.Hour},
+		{"early shift", "06:00", "14:00", -2 * time.Hour},
+		{"starts at midnight", "00:00", "08:00", -8 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shiftDayBoundary(&models.Shift{StartTime: tt.start, EndTime: tt.end}); got != tt.want {
+				t.Errorf("shiftDayBoundary() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEarlyShiftClockIn(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name         string
+		shift        *models.Shift
+		clockIn      time.Time
+		wantWorkDate time.Time
+		wantStatus   string
+		wantMinutes  int
+	}{
+		{"before an early shift", &models.Shift{StartTime: "06:00", EndTime: "14:00"}, at(5, 5, 55), at(5, 0, 0), models.AttendanceStatusOnTime, 0},
+		{"late for an early shift", &models.Shift{StartTime: "06:00", EndTime: "14:00"}, at(5, 6, 20), at(5, 0, 0), models.AttendanceStatusLate, 20},
+		{"before a shift starting at midnight", &models.Shift{StartTime: "00:00", EndTime: "08:00"}, at(4, 23, 50), at(5, 0, 0), models.AttendanceStatusOnTime, 0},
+		{"late for a shift starting at midnight", &models.Shift{StartTime: "00:00", EndTime: "08:00"}, at(5, 0, 30), at(5, 0, 0), models.AttendanceStatusLate, 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workDate := workDateOf(tt.clockIn, shiftDayBoundary(tt.shift))
+			if !workDate.Equal(tt.wantWorkDate) {
+				t.Fatalf("workDateOf() = %v, want %v", workDate, tt.wantWorkDate)
+			}
+			status, minutes := classifyClockIn(tt.shift, workDate, tt.clockIn)
+			if status != tt.wantStatus || minutes != tt.wantMinutes {
+				t.Errorf("classifyClockIn() = %q, %d, want %q, %d", status, minutes, tt.wantStatus, tt.wantMinutes)
+			}
+		})
+	}
+}
+
+func TestClassifyClockIn(t *testing.T) {
+	workDate := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	day := &models.Shift{StartTime: "08:00", EndTime: "17:00", GracePeriodMinutes: 10}
+	night := &models.Shift{StartTime: "22:00", EndTime: "06:00", GracePeriodMinutes: 15}
+	at := func(day, hour, minute, second int) time.Time {
+		return time.Date(2024, 3, day, hour, minute, second, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name        string
+		shift       *models.Shift
+		clockIn     time.Time
+		wantStatus  string
+		wantMinutes int
+	}{
+		{"early", day, at(4, 7, 30, 0), models.AttendanceStatusOnTime, 0},
+		{"exactly at the start", day, at(4, 8, 0, 0), models.AttendanceStatusOnTime, 0},
+		{"last moment of the grace period", day, at(4, 8, 10, 0), models.AttendanceStatusOnTime, 0},
+		{"just after the grace period", day, at(4, 8, 10, 1), models.AttendanceStatusLate, 10},
+		{"late", day, at(4, 9, 5, 0), models.AttendanceStatusLate, 65},
+		{"overnight before midnight", night, at(4, 21, 50, 0), models.AttendanceStatusOnTime, 0},
+		{"overnight within the grace period", night, at(4, 22, 15, 0), models.AttendanceStatusOnTime, 0},
+		{"overnight late before midnight", night, at(4, 22, 16, 0), models.AttendanceStatusLate, 16},
+		{"overnight late after midnight", night, at(5, 0, 30, 0), models.AttendanceStatusLate, 150},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, minutes := classifyClockIn(tt.shift, workDate, tt.clockIn)
+			if status != tt.wantStatus || minutes != tt.wantMinutes {
+				t.Errorf("classifyClockIn() = %q, %d, want %q, %d", status, minutes, tt.wantStatus, tt.wantMinutes)
+			}
+		})
+	}
+}
+
+func TestClassifyClockOut(t *testing.T) {
+	workDate := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	day := &models.Shift{StartTime: "08:00", EndTime: "17:00", GracePeriodMinutes: 10}
+	night := &models.Shift{StartTime: "22:00", EndTime: "06:00", GracePeriodMinutes: 15}
+	at := func(day, hour, minute, second int) time.Time {
+		return time.Date(2024, 3, day, hour, minute, second, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name           string
+		shift          *models.Shift
+		clockOut       time.Time
+		wantStatus     string
+		wantEarlyLeave int
+		wantOvertime   int
+	}{
+		{"early leave", day, at(4, 16, 30, 0), models.AttendanceStatusEarlyLeave, 30, 0},
+		{"one second early", day, at(4, 16, 59, 59), models.AttendanceStatusEarlyLeave, 0, 0},
+		{"exactly at the end", day, at(4, 17, 0, 0), models.AttendanceStatusOnTime, 0, 0},
+		{"last moment of the grace period", day, at(4, 17, 10, 0), models.AttendanceStatusOnTime, 0, 0},
+		{"just after the grace period", day, at(4, 17, 10, 1), models.AttendanceStatusOvertime, 0, 10},
+		{"overnight before midnight", night, at(4, 23, 0, 0), models.AttendanceStatusEarlyLeave, 420, 0},
+		{"overnight early leave after midnight", night, at(5, 5, 0, 0), models.AttendanceStatusEarlyLeave, 60, 0},
+		{"overnight exactly at the end", night, at(5, 6, 0, 0), models.AttendanceStatusOnTime, 0, 0},
+		{"overnight overtime", night, at(5, 7, 0, 0), models.AttendanceStatusOvertime, 0, 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, earlyLeave, overtime := classifyClockOut(tt.shift, workDate, tt.clockOut)
+			if status != tt.wantStatus || earlyLeave != tt.wantEarlyLeave || overtime != tt.wantOvertime {
+				t.Errorf("classifyClockOut() = %q, %d, %d, want %q, %d, %d",
+					status, earlyLeave, overtime, tt.wantStatus, tt.wantEarlyLeave, tt.wantOvertime)
+			}
+		})
+	}
+}
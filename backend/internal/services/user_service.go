@@ -1,9 +1,11 @@
 package services
 
 import (
+	"errors"
 	"face-verification-backend/internal/models"
 	"face-verification-backend/internal/repositories"
 	"fmt"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -11,19 +13,39 @@ import (
 type UserService interface {
 	UploadProfilePhoto(userID string, photoPath string) (string, error)
 	UpdateProfile(userID, name, position string) error
-	ChangePassword(userID, oldPassword, newPassword string) error
+	// ChangePassword sets a new password and signs the user out on every
+	// device but the one holding sessionID.
+	ChangePassword(userID, sessionID, oldPassword, newPassword string) error
 	GetUser(userID string) (*models.User, error)
+	// UpdateRole sets the role of a user and the permissions granted on top
+	// of it. The user is signed out everywhere, since their tokens still carry
+	// the old role. Admins cannot change their own role, so there is always
+	// one left.
+	UpdateRole(actorID, userID, role string, permissions []string) (*models.User, error)
+	// GrantAdminRole makes the users with the emails admins, to set up the
+	// first admin. It fails with ErrAdminExists once there is an admin, so an
+	// admin removed later is not granted the role again.
+	GrantAdminRole(emails []string) (int64, error)
 }
 
+var (
+	ErrInvalidRole       = errors.New("unknown role")
+	ErrInvalidPermission = errors.New("unknown permission")
+	ErrOwnRole           = errors.New("you cannot change your own role")
+	ErrAdminExists       = errors.New("an admin already exists")
+)
+
 type userService struct {
-	userRepo         repositories.UserRepository
+	userRepo          repositories.UserRepository
 	cloudinaryService CloudinaryService
+	authService       AuthService
 }
 
-func NewUserService(userRepo repositories.UserRepository, cloudinaryService CloudinaryService) UserService {
+func NewUserService(userRepo repositories.UserRepository, cloudinaryService CloudinaryService, authService AuthService) UserService {
 	return &userService{
-		userRepo:         userRepo,
+		userRepo:          userRepo,
 		cloudinaryService: cloudinaryService,
+		authService:       authService,
 	}
 }
 
@@ -66,7 +88,7 @@ func (s *userService) UpdateProfile(userID, name, position string) error {
 	return s.userRepo.Update(user)
 }
 
-func (s *userService) ChangePassword(userID, oldPassword, newPassword string) error {
+func (s *userService) ChangePassword(userID, sessionID, oldPassword, newPassword string) error {
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil {
 		return fmt.Errorf("user not found")
@@ -84,10 +106,66 @@ func (s *userService) ChangePassword(userID, oldPassword, newPassword string) er
 	}
 
 	user.Password = string(hashedPassword)
-	return s.userRepo.Update(user)
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	// Whoever knew the old password must not stay signed in
+	_, err = s.authService.RevokeOtherSessions(userID, sessionID)
+	return err
 }
 
 func (s *userService) GetUser(userID string) (*models.User, error) {
 	return s.userRepo.FindByID(userID)
 }
 
+func (s *userService) UpdateRole(actorID, userID, role string, permissions []string) (*models.User, error) {
+	if !models.IsValidRole(role) {
+		return nil, fmt.Errorf("%w %q", ErrInvalidRole, role)
+	}
+	for _, permission := range permissions {
+		if !models.IsValidPermission(permission) {
+			return nil, fmt.Errorf("%w %q", ErrInvalidPermission, permission)
+		}
+	}
+	if actorID == userID {
+		return nil, ErrOwnRole
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	joined := strings.Join(permissions, ",")
+	if user.Role == role && user.Permissions == joined {
+		return user, nil
+	}
+	if err := s.userRepo.UpdateRole(userID, role, joined); err != nil {
+		return nil, err
+	}
+	if _, err := s.authService.RevokeAllSessions(userID); err != nil {
+		return nil, err
+	}
+	return s.userRepo.FindByID(userID)
+}
+
+func (s *userService) GrantAdminRole(emails []string) (int64, error) {
+	var cleaned []string
+	for _, email := range emails {
+		if email = strings.TrimSpace(email); email != "" {
+			cleaned = append(cleaned, email)
+		}
+	}
+	if len(cleaned) == 0 {
+		return 0, nil
+	}
+
+	admins, err := s.userRepo.CountByRole(models.RoleAdmin)
+	if err != nil {
+		return 0, err
+	}
+	if admins > 0 {
+		return 0, ErrAdminExists
+	}
+	return s.userRepo.UpdateRoleByEmails(cleaned, models.RoleAdmin)
+}
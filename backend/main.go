@@ -1,13 +1,19 @@
 package main
 
 import (
+	"errors"
 	"face-verification-backend/internal/config"
 	"face-verification-backend/internal/database"
+	"face-verification-backend/internal/encryption"
 	"face-verification-backend/internal/handlers"
 	"face-verification-backend/internal/middleware"
+	"face-verification-backend/internal/models"
 	"face-verification-backend/internal/repositories"
+	"face-verification-backend/internal/scheduler"
 	"face-verification-backend/internal/services"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,16 +29,40 @@ func main() {
 	}
 
 	// Auto migrate
-	if err := database.Migrate(db); err != nil {
+	if err := database.Migrate(db, cfg.DayBoundaryHour); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
+	// Face embeddings are encrypted at rest when keys are configured
+	embeddingKeyring, err := encryption.LoadKeyring(cfg.EmbeddingEncryptionKeyID, cfg.EmbeddingEncryptionKeys)
+	if err != nil {
+		log.Fatal("Failed to load embedding encryption keys:", err)
+	}
+	if embeddingKeyring == nil {
+		log.Printf("Warning: EMBEDDING_ENCRYPTION_KEYS not set, face embeddings are stored unencrypted")
+	}
+
 	// Initialize repositories
 	userRepo := repositories.NewUserRepository(db)
 	attendanceRepo := repositories.NewAttendanceRepository(db)
 	taskRepo := repositories.NewTaskRepository(db)
 	trainingRepo := repositories.NewTrainingRepository(db)
-	faceEmbeddingRepo := repositories.NewFaceEmbeddingRepository(db)
+	faceEmbeddingRepo := repositories.NewFaceEmbeddingRepository(db, embeddingKeyring)
+	shiftRepo := repositories.NewShiftRepository(db)
+	officeRepo := repositories.NewOfficeRepository(db)
+	leaveRepo := repositories.NewLeaveRepository(db)
+	correctionRepo := repositories.NewAttendanceCorrectionRepository(db)
+	calendarRepo := repositories.NewCalendarRepository(db)
+	overtimeRepo := repositories.NewOvertimeRepository(db)
+	attemptRepo := repositories.NewFaceVerificationAttemptRepository(db)
+	serviceCredentialRepo := repositories.NewServiceCredentialRepository(db)
+	embeddingAuditRepo := repositories.NewEmbeddingAuditRepository(db)
+	kioskDeviceRepo := repositories.NewKioskDeviceRepository(db)
+	livenessChallengeRepo := repositories.NewLivenessChallengeRepository(db)
+	punchPhotoRepo := repositories.NewPunchPhotoRepository(db)
+	enrollmentRepo := repositories.NewFaceEnrollmentRepository(db)
+	authSessionRepo := repositories.NewAuthSessionRepository(db)
+	passwordResetRepo := repositories.NewPasswordResetRepository(db)
 
 	// Initialize Cloudinary service
 	cloudinaryService, err := services.NewCloudinaryService(
@@ -46,20 +76,157 @@ func main() {
 	}
 
 	// Initialize services
-	authService := services.NewAuthService(userRepo, cfg.JWTSecret)
-	attendanceService := services.NewAttendanceService(attendanceRepo, cfg.FaceRecognitionURL, cloudinaryService)
-	userService := services.NewUserService(userRepo, cloudinaryService)
+	authService := services.NewAuthService(userRepo, authSessionRepo, cfg.JWTSecret,
+		time.Duration(cfg.AccessTokenTTLMinutes)*time.Minute, time.Duration(cfg.RefreshTokenTTLDays)*24*time.Hour)
+	mailer, err := services.NewMailer(cfg.Mailer, services.SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.MailFrom,
+	}, cfg.MailOutboxDir)
+	if err != nil {
+		log.Fatal("Failed to configure mailer:", err)
+	}
+	passwordResetService := services.NewPasswordResetService(userRepo, passwordResetRepo, authService, mailer,
+		cfg.PasswordResetURL, time.Duration(cfg.PasswordResetTTLMinutes)*time.Minute)
+	shiftService := services.NewShiftService(shiftRepo, userRepo)
+	officeService := services.NewOfficeService(officeRepo, userRepo)
+	calendarService := services.NewCalendarService(calendarRepo)
+	leaveService := services.NewLeaveService(leaveRepo, calendarService)
+	faceEmbeddingService := services.NewFaceEmbeddingService(faceEmbeddingRepo, userRepo, services.FaceModel{
+		Name:    cfg.FaceModelName,
+		Version: cfg.FaceModelVersion,
+	}, services.FaceModel{
+		Name:    cfg.FaceLegacyModelName,
+		Version: cfg.FaceLegacyModelVersion,
+	})
+	faceService := services.FaceServiceConfig{
+		BaseURL:          cfg.FaceRecognitionURL,
+		Timeout:          time.Duration(cfg.FaceServiceTimeoutSeconds) * time.Second,
+		Retries:          cfg.FaceServiceRetries,
+		RetryDelay:       200 * time.Millisecond,
+		BreakerThreshold: cfg.FaceServiceBreakerThreshold,
+		BreakerCooldown:  time.Duration(cfg.FaceServiceBreakerCooldownSeconds) * time.Second,
+		DegradedMode:     cfg.FaceServiceDegradedMode,
+		Key:              cfg.FaceServiceKey,
+	}
+	if (cfg.FaceVerifier == services.FaceVerifierHTTP || cfg.FaceVerifier == "") && cfg.FaceServiceKey == "" {
+		log.Printf("Warning: FACE_SERVICE_KEY not set, the face service ignores user and site thresholds")
+	}
+	faceVerifier, err := services.NewFaceVerifier(cfg.FaceVerifier, faceService, faceEmbeddingService, cfg.FaceMatchThreshold)
+	if err != nil {
+		log.Fatal("Failed to configure face verification:", err)
+	}
+	faceVerifier = services.NewRecordingFaceVerifier(faceVerifier, cfg.FaceVerifier, attemptRepo)
+	thresholdService := services.NewFaceThresholdService(userRepo, officeRepo, cfg.FaceMatchThreshold)
+	livenessService := services.NewLivenessService(livenessChallengeRepo, services.NewHTTPLivenessChecker(faceService), cfg.LivenessMode,
+		time.Duration(cfg.LivenessChallengeTTLSeconds)*time.Second, cfg.LivenessActions, cfg.LivenessMinScore)
+	photoService := services.NewPhotoFingerprintService(punchPhotoRepo, cfg.PhotoNearDuplicateDistance,
+		time.Duration(cfg.PhotoMaxCaptureSkewMinutes)*time.Minute, time.Duration(cfg.PhotoHistoryDays)*24*time.Hour)
+	attemptService := services.NewFaceVerificationAttemptService(attemptRepo, time.Duration(cfg.FalseRejectRetryMinutes)*time.Minute)
+	attendanceService := services.NewAttendanceService(attendanceRepo, shiftService, officeService, leaveService, calendarService, cfg.DayBoundaryHour, cfg.GeofenceMode, faceVerifier, attemptService, thresholdService, livenessService, photoService, cloudinaryService)
+	correctionService := services.NewAttendanceCorrectionService(correctionRepo, attendanceRepo, shiftService, cloudinaryService, cfg.DayBoundaryHour)
+	overtimeService := services.NewOvertimeService(overtimeRepo, attendanceRepo)
+	serviceCredentialService := services.NewServiceCredentialService(serviceCredentialRepo)
+	embeddingAuditService := services.NewEmbeddingAuditService(embeddingAuditRepo)
+	kioskService := services.NewKioskService(kioskDeviceRepo, officeService, faceEmbeddingService, services.NewHTTPFaceEmbedder(faceService), attendanceService, thresholdService, cfg.KioskMinMargin)
+	enrollmentService := services.NewFaceEnrollmentService(enrollmentRepo, faceEmbeddingService, services.NewHTTPFaceAnalyzer(faceService), cloudinaryService, services.EnrollmentQuality{
+		MinFacePixels: cfg.EnrollmentMinFacePixels,
+		MinSharpness:  cfg.EnrollmentMinSharpness,
+		MinBrightness: cfg.EnrollmentMinBrightness,
+		MaxBrightness: cfg.EnrollmentMaxBrightness,
+	}, cfg.EnrollmentMinCaptures)
+	userService := services.NewUserService(userRepo, cloudinaryService, authService)
+	if promoted, err := userService.GrantAdminRole(strings.Split(cfg.AdminEmails, ",")); errors.Is(err, services.ErrAdminExists) {
+		log.Printf("Warning: ADMIN_EMAILS ignored since an admin already exists, clear it")
+	} else if err != nil {
+		log.Printf("Warning: failed to grant the admin role to ADMIN_EMAILS: %v", err)
+	} else if promoted > 0 {
+		log.Printf("Granted the admin role to %d user(s) from ADMIN_EMAILS", promoted)
+	}
 	taskService := services.NewTaskService(taskRepo)
 	trainingService := services.NewTrainingService(trainingRepo)
-	faceEmbeddingService := services.NewFaceEmbeddingService(faceEmbeddingRepo)
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(authService)
+	authHandler := handlers.NewAuthHandler(authService, passwordResetService)
 	attendanceHandler := handlers.NewAttendanceHandler(attendanceService)
 	userHandler := handlers.NewUserHandler(userService)
 	taskHandler := handlers.NewTaskHandler(taskService)
 	trainingHandler := handlers.NewTrainingHandler(trainingService)
-	faceEmbeddingHandler := handlers.NewFaceEmbeddingHandler(faceEmbeddingService)
+	faceEmbeddingHandler := handlers.NewFaceEmbeddingHandler(faceEmbeddingService, embeddingAuditService)
+	shiftHandler := handlers.NewShiftHandler(shiftService)
+	officeHandler := handlers.NewOfficeHandler(officeService)
+	leaveHandler := handlers.NewLeaveHandler(leaveService)
+	correctionHandler := handlers.NewAttendanceCorrectionHandler(correctionService)
+	calendarHandler := handlers.NewCalendarHandler(calendarService)
+	overtimeHandler := handlers.NewOvertimeHandler(overtimeService)
+	attemptHandler := handlers.NewFaceVerificationAttemptHandler(attemptService)
+	thresholdHandler := handlers.NewFaceThresholdHandler(thresholdService)
+	serviceCredentialHandler := handlers.NewServiceCredentialHandler(serviceCredentialService)
+	kioskHandler := handlers.NewKioskHandler(kioskService)
+	livenessHandler := handlers.NewLivenessHandler(livenessService)
+	enrollmentHandler := handlers.NewFaceEnrollmentHandler(enrollmentService, embeddingAuditService)
+
+	// Background jobs
+	jobs := scheduler.New()
+	if cfg.AutoClockOutEnabled {
+		delay := time.Duration(cfg.AutoClockOutAfterMinutes) * time.Minute
+		maxSession := time.Duration(cfg.AutoClockOutMaxSessionHours) * time.Hour
+		jobs.Add(scheduler.Job{
+			Name:     "auto-clock-out",
+			Interval: time.Duration(cfg.AutoClockOutIntervalMinutes) * time.Minute,
+			Run: func() error {
+				closed, err := attendanceService.AutoClockOut(time.Now(), delay, maxSession)
+				if closed > 0 {
+					log.Printf("Auto clock-out closed %d session(s)", closed)
+				}
+				return err
+			},
+		})
+	}
+	jobs.Add(scheduler.Job{
+		Name:     "face-model-migration",
+		Interval: time.Duration(cfg.FaceModelMigrationIntervalHours) * time.Hour,
+		Run: func() error {
+			migration, err := faceEmbeddingService.MigrateModel()
+			if err != nil {
+				return err
+			}
+			if migration.Stamped > 0 || migration.Flagged > 0 || migration.Unflagged > 0 {
+				log.Printf("Face model %s: %d embedding(s) stamped, %d user(s) flagged for re-enrollment, %d cleared",
+					faceEmbeddingService.ActiveModel(), migration.Stamped, migration.Flagged, migration.Unflagged)
+			}
+			return nil
+		},
+	})
+	jobs.Add(scheduler.Job{
+		Name:     "liveness-challenge-cleanup",
+		Interval: time.Hour,
+		Run: func() error {
+			_, err := livenessService.DeleteExpiredChallenges(time.Now().Add(-time.Hour))
+			return err
+		},
+	})
+	jobs.Add(scheduler.Job{
+		Name:     "auth-session-cleanup",
+		Interval: 24 * time.Hour,
+		Run: func() error {
+			// Kept for a week after they end so reused refresh tokens are still recognised
+			_, err := authService.DeleteExpiredSessions(time.Now().Add(-7 * 24 * time.Hour))
+			return err
+		},
+	})
+	jobs.Add(scheduler.Job{
+		Name:     "password-reset-cleanup",
+		Interval: 24 * time.Hour,
+		Run: func() error {
+			_, err := passwordResetService.DeleteExpiredTokens(time.Now())
+			return err
+		},
+	})
+	jobs.Start()
+	defer jobs.Stop()
 
 	// Setup router
 	router := gin.Default()
@@ -67,6 +234,9 @@ func main() {
 	// CORS middleware
 	router.Use(middleware.CORS())
 
+	// Page opened by password reset links (PASSWORD_RESET_URL)
+	router.GET("/reset-password", authHandler.ResetPasswordPage)
+
 	// API routes
 	api := router.Group("/api/v1")
 	{
@@ -76,31 +246,57 @@ func main() {
 			auth.POST("/login", authHandler.Login)
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/forgot-password", authHandler.ForgotPassword)
-			auth.GET("/me", middleware.AuthMiddleware(cfg.JWTSecret), authHandler.GetMe)
+			auth.POST("/reset-password", authHandler.ResetPassword)
+			auth.POST("/refresh", authHandler.Refresh)
+			auth.GET("/me", middleware.AuthMiddleware(authService), authHandler.GetMe)
+			auth.POST("/logout", middleware.AuthMiddleware(authService), authHandler.Logout)
+			auth.GET("/sessions", middleware.AuthMiddleware(authService), authHandler.GetSessions)
+			auth.POST("/sessions/:id/revoke", middleware.AuthMiddleware(authService), authHandler.RevokeSession)
 		}
 
 		// Attendance routes
 		attendance := api.Group("/attendance")
-		attendance.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+		attendance.Use(middleware.AuthMiddleware(authService))
 		{
+			attendance.POST("/liveness-challenge", livenessHandler.IssueChallenge)
 			attendance.POST("/clock-in", attendanceHandler.ClockIn)
 			attendance.POST("/clock-out", attendanceHandler.ClockOut)
+			attendance.POST("/break-start", attendanceHandler.StartBreak)
+			attendance.POST("/break-end", attendanceHandler.EndBreak)
 			attendance.GET("/today", attendanceHandler.GetTodayAttendance)
 			attendance.GET("/history", attendanceHandler.GetHistory)
+			attendance.POST("/corrections", correctionHandler.RequestCorrection)
+			attendance.GET("/corrections", correctionHandler.GetMyCorrections)
+		}
+
+		// Kiosk routes, for shared clock-in terminals authenticated with a
+		// device key sent in X-Device-Key
+		kiosk := api.Group("/kiosk")
+		kiosk.Use(middleware.KioskAuthMiddleware(kioskService))
+		{
+			kiosk.POST("/liveness-challenge", livenessHandler.IssueKioskChallenge)
+			kiosk.POST("/clock-in", kioskHandler.ClockIn)
 		}
 
 		// User routes
 		user := api.Group("/user")
-		user.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+		user.Use(middleware.AuthMiddleware(authService))
 		{
 			user.POST("/upload-profile-photo", userHandler.UploadProfilePhoto)
 			user.PUT("/profile", userHandler.UpdateProfile)
 			user.PUT("/change-password", userHandler.ChangePassword)
+			user.GET("/face-embeddings", faceEmbeddingHandler.GetMyEmbeddings)
+			user.DELETE("/face-embeddings/:id", faceEmbeddingHandler.DeleteMyEmbedding)
+			user.POST("/face-enrollments", enrollmentHandler.StartEnrollment)
+			user.GET("/face-enrollments", enrollmentHandler.GetMyEnrollments)
+			user.POST("/face-enrollments/:id/captures", enrollmentHandler.AddCapture)
+			user.POST("/face-enrollments/:id/submit", enrollmentHandler.SubmitEnrollment)
+			user.POST("/face-enrollments/:id/cancel", enrollmentHandler.CancelEnrollment)
 		}
 
 		// Task routes
 		task := api.Group("/tasks")
-		task.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+		task.Use(middleware.AuthMiddleware(authService))
 		{
 			task.POST("", taskHandler.CreateTask)
 			task.GET("", taskHandler.GetTasks)
@@ -110,18 +306,129 @@ func main() {
 
 		// Training routes
 		training := api.Group("/trainings")
-		training.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+		training.Use(middleware.AuthMiddleware(authService))
 		{
 			training.GET("", trainingHandler.GetTrainings)
 			training.GET("/:id", trainingHandler.GetTraining)
 		}
 
-		// Face Embedding routes (used by face recognition service)
-		// Note: These endpoints are internal and should be protected in production
+		// Leave routes
+		leave := api.Group("/leave")
+		leave.Use(middleware.AuthMiddleware(authService))
+		{
+			leave.GET("/types", leaveHandler.GetLeaveTypes)
+			leave.POST("/requests", leaveHandler.RequestLeave)
+			leave.GET("/requests", leaveHandler.GetMyLeaveRequests)
+			leave.POST("/requests/:id/cancel", leaveHandler.CancelLeave)
+			leave.GET("/balances", leaveHandler.GetMyBalances)
+		}
+
+		// Overtime routes
+		overtime := api.Group("/overtime")
+		overtime.Use(middleware.AuthMiddleware(authService))
+		{
+			overtime.POST("/requests", overtimeHandler.RequestOvertime)
+			overtime.GET("/requests", overtimeHandler.GetMyOvertimeRequests)
+			overtime.GET("/summary", overtimeHandler.GetMySummary)
+		}
+
+		// Company calendar routes
+		calendar := api.Group("/calendar")
+		calendar.Use(middleware.AuthMiddleware(authService))
+		{
+			calendar.GET("/holidays", calendarHandler.GetHolidays)
+			calendar.GET("/working-days", calendarHandler.GetWorkingDays)
+		}
+
+		// Admin routes, each group open to the users granted its permission
+		admin := api.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(authService))
+		{
+			shifts := admin.Group("", middleware.RequirePermission(models.PermissionShiftsManage))
+			shifts.POST("/shifts", shiftHandler.CreateShift)
+			shifts.GET("/shifts", shiftHandler.GetShifts)
+			shifts.GET("/shifts/:id", shiftHandler.GetShift)
+			shifts.PUT("/shifts/:id", shiftHandler.UpdateShift)
+			shifts.DELETE("/shifts/:id", shiftHandler.DeleteShift)
+			shifts.PUT("/users/:user_id/shift", shiftHandler.AssignShift)
+
+			offices := admin.Group("", middleware.RequirePermission(models.PermissionOfficesManage))
+			offices.POST("/offices", officeHandler.CreateOffice)
+			offices.GET("/offices", officeHandler.GetOffices)
+			offices.GET("/offices/:id", officeHandler.GetOffice)
+			offices.PUT("/offices/:id", officeHandler.UpdateOffice)
+			offices.DELETE("/offices/:id", officeHandler.DeleteOffice)
+			offices.PUT("/users/:user_id/offices", officeHandler.AssignOffices)
+
+			users := admin.Group("", middleware.RequirePermission(models.PermissionUsersManage))
+			users.GET("/users/:user_id/sessions", authHandler.GetUserSessions)
+			users.POST("/users/:user_id/sessions/revoke", authHandler.RevokeUserSessions)
+
+			// Only admins hand out roles, so a granted permission cannot be
+			// turned into more
+			roles := admin.Group("", middleware.RequireRole(models.RoleAdmin))
+			roles.GET("/roles", userHandler.GetRoles)
+			roles.PUT("/users/:user_id/role", userHandler.UpdateUserRole)
+
+			leaveTypes := admin.Group("", middleware.RequirePermission(models.PermissionLeaveManage))
+			leaveTypes.POST("/leave-types", leaveHandler.CreateLeaveType)
+			leaveTypes.PUT("/leave-types/:id", leaveHandler.UpdateLeaveType)
+
+			leaveRequests := admin.Group("", middleware.RequirePermission(models.PermissionLeaveApprove))
+			leaveRequests.GET("/leave-requests", leaveHandler.GetLeaveRequests)
+			leaveRequests.POST("/leave-requests/:id/approve", leaveHandler.ApproveLeave)
+			leaveRequests.POST("/leave-requests/:id/reject", leaveHandler.RejectLeave)
+
+			corrections := admin.Group("", middleware.RequirePermission(models.PermissionAttendanceReview))
+			corrections.GET("/attendance-corrections", correctionHandler.GetCorrections)
+			corrections.POST("/attendance-corrections/:id/approve", correctionHandler.ApproveCorrection)
+			corrections.POST("/attendance-corrections/:id/reject", correctionHandler.RejectCorrection)
+			corrections.GET("/attendance/:id/corrections", correctionHandler.GetCorrectionHistory)
+
+			overtimeRequests := admin.Group("", middleware.RequirePermission(models.PermissionOvertimeApprove))
+			overtimeRequests.GET("/overtime-requests", overtimeHandler.GetOvertimeRequests)
+			overtimeRequests.POST("/overtime-requests/:id/approve", overtimeHandler.ApproveOvertime)
+			overtimeRequests.POST("/overtime-requests/:id/reject", overtimeHandler.RejectOvertime)
+			overtimeRequests.GET("/overtime/summary", overtimeHandler.GetSummaries)
+
+			face := admin.Group("", middleware.RequirePermission(models.PermissionFaceManage))
+			face.PUT("/offices/:id/face-threshold", thresholdHandler.SetSiteThreshold)
+			face.PUT("/users/:user_id/face-threshold", thresholdHandler.SetUserThreshold)
+			face.GET("/face-verification-attempts", attemptHandler.GetAttempts)
+			face.GET("/face-verification-attempts/threshold-report", attemptHandler.GetThresholdReport)
+			face.GET("/users/:user_id/face-embeddings", faceEmbeddingHandler.GetUserEmbeddings)
+			face.DELETE("/users/:user_id/face-embeddings/:id", faceEmbeddingHandler.DeleteUserEmbedding)
+			face.GET("/face-reenrollment", faceEmbeddingHandler.GetReenrollmentRequired)
+			face.GET("/face-enrollments", enrollmentHandler.GetEnrollments)
+			face.GET("/face-enrollments/:id", enrollmentHandler.GetEnrollment)
+			face.POST("/face-enrollments/:id/approve", enrollmentHandler.ApproveEnrollment)
+			face.POST("/face-enrollments/:id/reject", enrollmentHandler.RejectEnrollment)
+			face.GET("/embedding-audit", faceEmbeddingHandler.GetAuditEntries)
+
+			// Service keys can read and write any embedding and kiosk keys can
+			// clock anyone in, so only admins issue them
+			devices := admin.Group("", middleware.RequireRole(models.RoleAdmin))
+			devices.GET("/kiosk-devices", kioskHandler.GetDevices)
+			devices.POST("/kiosk-devices", kioskHandler.RegisterDevice)
+			devices.POST("/kiosk-devices/:id/revoke", kioskHandler.RevokeDevice)
+			devices.GET("/service-credentials", serviceCredentialHandler.GetCredentials)
+			devices.POST("/service-credentials", serviceCredentialHandler.CreateCredential)
+			devices.POST("/service-credentials/:id/rotate", serviceCredentialHandler.RotateCredential)
+			devices.POST("/service-credentials/:id/revoke", serviceCredentialHandler.RevokeCredential)
+
+			calendarDays := admin.Group("", middleware.RequirePermission(models.PermissionCalendarManage))
+			calendarDays.POST("/calendar/holidays", calendarHandler.CreateHoliday)
+			calendarDays.DELETE("/calendar/holidays/:id", calendarHandler.DeleteHoliday)
+			calendarDays.POST("/calendar/import", calendarHandler.ImportHolidays)
+			calendarDays.PUT("/calendar/working-days", calendarHandler.SetWorkingDays)
+		}
+
+		// Face Embedding routes (used by face recognition service), authenticated
+		// with a service key sent in X-Service-Key. Embeddings are only added
+		// through enrollments approved by HR.
 		embeddings := api.Group("/embeddings")
 		{
-			embeddings.POST("", faceEmbeddingHandler.SaveEmbedding)
-			embeddings.GET("/user/:user_id", faceEmbeddingHandler.GetEmbedding)
+			embeddings.GET("/user/:user_id", middleware.ServiceAuthMiddleware(serviceCredentialService, models.ScopeEmbeddingsRead), faceEmbeddingHandler.GetEmbedding)
 		}
 	}
 
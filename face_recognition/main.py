@@ -11,6 +11,7 @@ from typing import List, Optional
 import json
 from pathlib import Path
 import requests
+import hmac
 
 app = Flask(__name__)
 # Configure CORS to allow all origins and methods (for development)
@@ -27,9 +28,31 @@ CORS(app, resources={
 # Can be overridden via environment variable
 BACKEND_API_URL = os.getenv('BACKEND_API_URL', 'http://localhost:8080/api/v1')
 
+# Service key issued by an admin (POST /admin/service-credentials) with the
+# embeddings:read scope
+BACKEND_SERVICE_KEY = os.getenv('BACKEND_SERVICE_KEY', '')
+
+def backend_headers():
+    """Headers authenticating this service to the backend"""
+    return {'X-Service-Key': BACKEND_SERVICE_KEY}
+
+# Shared secret the backend sends in X-Face-Service-Key (its FACE_SERVICE_KEY).
+# Only the backend may set the threshold of a verification
+FACE_SERVICE_KEY = os.getenv('FACE_SERVICE_KEY', '')
+
+def is_backend_request():
+    """Whether the request carries the backend's service key"""
+    key = request.headers.get('X-Face-Service-Key', '')
+    return bool(FACE_SERVICE_KEY) and hmac.compare_digest(key, FACE_SERVICE_KEY)
+
 # Storage mode: 'database' (via backend API) or 'file' (JSON file)
 STORAGE_MODE = os.getenv('STORAGE_MODE', 'database')  # Default to database
 
+# Model that produces the embeddings, must match FACE_MODEL_NAME/FACE_MODEL_VERSION
+# of the backend. Embeddings of another model are not comparable.
+FACE_MODEL_NAME = os.getenv('FACE_MODEL_NAME', 'buffalo_l')
+FACE_MODEL_VERSION = os.getenv('FACE_MODEL_VERSION', '1')
+
 # Load InsightFace modelP
 face_analyzer = None
 
@@ -134,6 +157,15 @@ def extract_embedding(image: Image.Image) -> Optional[np.ndarray]:
         traceback.print_exc()
         return None
 
+def detect_largest_face(image: Image.Image):
+    """Detect the largest face in an image, with its landmarks and pose"""
+    if face_analyzer is None:
+        return None
+    faces = face_analyzer.get(preprocess_image(image))
+    if len(faces) == 0:
+        return None
+    return max(faces, key=lambda f: (f.bbox[2] - f.bbox[0]) * (f.bbox[3] - f.bbox[1]))
+
 def cosine_similarity(embedding1: np.ndarray, embedding2: np.ndarray) -> float:
     """Calculate cosine similarity between two embeddings"""
     return np.dot(embedding1, embedding2)
@@ -175,11 +207,14 @@ def load_embedding_from_database(user_id: str) -> Optional[dict]:
     """Load embedding from database via backend API"""
     try:
         url = f"{BACKEND_API_URL}/embeddings/user/{user_id}"
-        response = requests.get(url, timeout=5)
+        response = requests.get(url, headers=backend_headers(), timeout=5)
         if response.status_code == 200:
             data = response.json()
+            # A user may have several reference faces, fall back to the single one
+            stored = data.get('embeddings') or [{'embedding': data['embedding']}]
             return {
                 'embedding': json.loads(data['embedding']),  # Parse JSON string to list
+                'embeddings': [json.loads(item['embedding']) for item in stored],
                 'user_id': data['user_id']
             }
         elif response.status_code == 404:
@@ -191,25 +226,36 @@ def load_embedding_from_database(user_id: str) -> Optional[dict]:
         print(f"⚠️  Error loading embedding from database: {e}")
         return None
 
-def save_embedding_to_database(user_id: str, embedding: np.ndarray) -> bool:
-    """Save embedding to database via backend API"""
-    try:
-        url = f"{BACKEND_API_URL}/embeddings"
-        embedding_json = json.dumps(embedding.tolist())
-        payload = {
-            'user_id': user_id,
-            'embedding': embedding_json
-        }
-        response = requests.post(url, json=payload, timeout=10)
-        if response.status_code == 200:
-            print(f"💾 Saved embedding to database for user: {user_id}")
-            return True
-        else:
-            print(f"❌ Error saving embedding to database: {response.status_code} - {response.text}")
-            return False
-    except Exception as e:
-        print(f"❌ Error saving embedding to database: {e}")
-        return False
+def enroll_capture_in_database(auth_header: str, photo_bytes: bytes, filename: str):
+    """Add the photo as a capture of the user's enrollment via the backend API.
+    The backend checks its quality, and its embedding stays pending until HR
+    approves the enrollment. Returns the response status and body."""
+    headers = {'Authorization': auth_header}
+    enrollments_url = f"{BACKEND_API_URL}/user/face-enrollments"
+
+    # Add to the open enrollment, or start one
+    response = requests.get(enrollments_url, headers=headers, timeout=10)
+    if response.status_code != 200:
+        return response.status_code, response.json()
+    enrollment = next((e for e in response.json().get('data') or [] if e['status'] == 'open'), None)
+    if enrollment is None:
+        response = requests.post(enrollments_url, headers=headers, timeout=10)
+        if response.status_code != 200:
+            return response.status_code, response.json()
+        enrollment = response.json()['data']
+
+    response = requests.post(f"{enrollments_url}/{enrollment['id']}/captures",
+                             files={'photo': (filename, photo_bytes)}, headers=headers, timeout=30)
+    if response.status_code != 200:
+        return response.status_code, response.json()
+    capture = response.json()['data']
+    if not capture['passed']:
+        return 422, {"error": f"Photo failed the quality checks: {capture['rejections']}", "capture": capture}
+
+    # Submit for approval once enough captures passed, otherwise keep it open
+    response = requests.post(f"{enrollments_url}/{enrollment['id']}/submit", headers=headers, timeout=10)
+    status = 'pending' if response.status_code == 200 else 'open'
+    return 200, {"enrollment_id": enrollment['id'], "status": status, "capture": capture}
 
 def load_embeddings_file():
     """Load embeddings from JSON file (legacy file mode)"""
@@ -237,9 +283,6 @@ def save_embeddings_file():
     except Exception as e:
         print(f"❌ Error saving embeddings: {e}")
 
-# In-memory cache for embeddings (used in both modes for performance)
-embeddings_cache = {}
-
 # Initialize based on storage mode
 if STORAGE_MODE == 'file':
     embeddings_store = {}
@@ -255,19 +298,30 @@ def health():
 
 @app.route('/upload-profile', methods=['POST'])
 def upload_profile():
-    """Upload profile photo and extract embedding"""
+    """Upload profile photo as a face enrollment capture"""
     try:
         if 'photo' not in request.files:
             return jsonify({"error": "No photo provided"}), 400
         
         file = request.files['photo']
-        user_id = request.form.get('user_id')
+        photo_bytes = file.read()
         
+        if STORAGE_MODE == 'database':
+            # Enrolled through the backend with the caller's own token, so the
+            # capture is quality checked and waits for HR approval
+            auth_header = request.headers.get('Authorization')
+            if not auth_header:
+                return jsonify({"error": "Authorization header is required"}), 401
+            status_code, body = enroll_capture_in_database(auth_header, photo_bytes, file.filename or 'photo.jpg')
+            print(f"[UPLOAD] Enrollment capture response: {status_code}")
+            return jsonify(body), status_code
+        
+        user_id = request.form.get('user_id')
         if not user_id:
             return jsonify({"error": "user_id is required"}), 400
         
         # Read image
-        image = Image.open(io.BytesIO(file.read()))
+        image = Image.open(io.BytesIO(photo_bytes))
         
         # Extract embedding with improved preprocessing
         embedding = extract_embedding(image)
@@ -280,32 +334,13 @@ def upload_profile():
             print(f"[UPLOAD] ⚠️  Embedding norm is {embedding_norm:.4f}, normalizing...")
             embedding = embedding / embedding_norm
         
-        # Store embedding based on storage mode
-        embedding_list = embedding.tolist()
-        
-        if STORAGE_MODE == 'database':
-            # Save to database via backend API
-            success = save_embedding_to_database(user_id, embedding)
-            if not success:
-                return jsonify({"error": "Failed to save embedding to database"}), 500
-            
-            # Also cache in memory for faster access
-            embeddings_cache[user_id] = {
-                'embedding': embedding_list,
-                'user_id': user_id
-            }
-            print(f"[UPLOAD] ✅ Profile photo uploaded and saved to DATABASE for user: {user_id}")
-        else:
-            # Save to file (legacy mode)
-            embeddings_store[user_id] = {
-                'embedding': embedding_list,
-                'user_id': user_id
-            }
-            save_embeddings_file()
-            print(f"[UPLOAD] ✅ Profile photo uploaded and saved to FILE for user: {user_id}")
-        
-        print(f"[UPLOAD] Embedding shape: {embedding.shape}")
-        print(f"[UPLOAD] Embedding norm: {np.linalg.norm(embedding):.4f}")
+        # Save to file (legacy mode)
+        embeddings_store[user_id] = {
+            'embedding': embedding.tolist(),
+            'user_id': user_id
+        }
+        save_embeddings_file()
+        print(f"[UPLOAD] ✅ Profile photo uploaded and saved to FILE for user: {user_id}")
         
         return jsonify({
             "message": "Profile photo uploaded successfully",
@@ -315,6 +350,174 @@ def upload_profile():
     except Exception as e:
         return jsonify({"error": str(e)}), 500
 
+@app.route('/embed', methods=['POST'])
+def embed():
+    """Extract the embedding of the face in a photo, for 1:N identification by the backend"""
+    try:
+        if 'photo' not in request.files:
+            return jsonify({"error": "No photo provided"}), 400
+
+        file = request.files['photo']
+        try:
+            image = Image.open(io.BytesIO(file.read()))
+        except Exception as e:
+            return jsonify({"error": f"Invalid image file: {str(e)}"}), 400
+
+        embedding = extract_embedding(image)
+        if embedding is None:
+            return jsonify({"error": "No face detected in photo. Please ensure your face is clearly visible."}), 400
+        embedding = embedding / np.linalg.norm(embedding)
+
+        return jsonify({
+            "embedding": embedding.tolist(),
+            "model_name": FACE_MODEL_NAME,
+            "model_version": FACE_MODEL_VERSION
+        }), 200
+
+    except Exception as e:
+        print(f"[EMBED] Exception occurred: {str(e)}")
+        return jsonify({"error": str(e)}), 500
+
+@app.route('/analyze', methods=['POST'])
+def analyze():
+    """Detect every face in an enrollment capture, with boxes relative to the
+    image size, and the embedding of the largest one. The backend applies its
+    quality gates to the result."""
+    try:
+        if 'photo' not in request.files:
+            return jsonify({"error": "No photo provided"}), 400
+
+        file = request.files['photo']
+        try:
+            image = Image.open(io.BytesIO(file.read()))
+        except Exception as e:
+            return jsonify({"error": f"Invalid image file: {str(e)}"}), 400
+
+        if face_analyzer is None:
+            return jsonify({"error": "Face analyzer is not initialized"}), 500
+
+        img_array = preprocess_image(image)
+        height, width = img_array.shape[:2]
+        faces = face_analyzer.get(img_array)
+
+        result = {
+            "faces": [
+                {
+                    "x": float(face.bbox[0]) / width,
+                    "y": float(face.bbox[1]) / height,
+                    "width": float(face.bbox[2] - face.bbox[0]) / width,
+                    "height": float(face.bbox[3] - face.bbox[1]) / height,
+                    "score": float(face.det_score),
+                }
+                for face in faces
+            ],
+            "model_name": FACE_MODEL_NAME,
+            "model_version": FACE_MODEL_VERSION,
+        }
+        if faces:
+            largest_face = max(faces, key=lambda f: (f.bbox[2] - f.bbox[0]) * (f.bbox[3] - f.bbox[1]))
+            embedding = largest_face.normed_embedding
+            result["embedding"] = (embedding / np.linalg.norm(embedding)).tolist()
+
+        print(f"[ANALYZE] Found {len(faces)} face(s)")
+        return jsonify(result), 200
+
+    except Exception as e:
+        print(f"[ANALYZE] Exception occurred: {str(e)}")
+        return jsonify({"error": str(e)}), 500
+
+# Liveness: thresholds for the challenge actions, relative to the first frame
+LIVENESS_TURN_DEGREES = 20.0      # yaw change for turn_left / turn_right
+LIVENESS_NOD_DEGREES = 12.0       # pitch change for nod
+LIVENESS_BLINK_RATIO = 0.7        # eye aspect ratio below 70% of the open eyes
+LIVENESS_SAME_FACE_SIMILARITY = 0.5  # every frame must show the same person
+
+def eye_aspect_ratio(landmarks: np.ndarray) -> float:
+    """Mean eye aspect ratio of both eyes from the 68 point landmarks"""
+    def ratio(eye):
+        width = np.linalg.norm(eye[0] - eye[3])
+        if width == 0:
+            return 0.0
+        return (np.linalg.norm(eye[1] - eye[5]) + np.linalg.norm(eye[2] - eye[4])) / (2.0 * width)
+    points = landmarks[:, :2]
+    return (ratio(points[36:42]) + ratio(points[42:48])) / 2.0
+
+def action_performed(action: str, baseline: dict, frame: dict) -> bool:
+    """Whether a frame shows the action compared with the first frame. The
+    direction of turns follows InsightFace's yaw estimate."""
+    if action == 'blink':
+        return baseline['ear'] is not None and frame['ear'] is not None and frame['ear'] < baseline['ear'] * LIVENESS_BLINK_RATIO
+    if baseline['pose'] is None or frame['pose'] is None:
+        return False
+    pitch_change = frame['pose'][0] - baseline['pose'][0]
+    yaw_change = frame['pose'][1] - baseline['pose'][1]
+    if action == 'turn_left':
+        return yaw_change >= LIVENESS_TURN_DEGREES
+    if action == 'turn_right':
+        return yaw_change <= -LIVENESS_TURN_DEGREES
+    if action == 'nod':
+        return abs(pitch_change) >= LIVENESS_NOD_DEGREES
+    return False
+
+@app.route('/liveness', methods=['POST'])
+def liveness():
+    """Check that the frames show one live face performing the actions in order"""
+    try:
+        frames = request.files.getlist('frames')
+        actions = [a for a in request.form.get('actions', '').split(',') if a]
+        if len(frames) < 2:
+            return jsonify({"error": "At least two frames are required"}), 400
+        if not actions:
+            return jsonify({"error": "actions are required"}), 400
+
+        observations = []
+        for frame in frames:
+            try:
+                image = Image.open(io.BytesIO(frame.read()))
+            except Exception as e:
+                return jsonify({"error": f"Invalid image file: {str(e)}"}), 400
+            face = detect_largest_face(image)
+            if face is None:
+                continue
+            landmarks = getattr(face, 'landmark_3d_68', None)
+            pose = getattr(face, 'pose', None)
+            observations.append({
+                'embedding': face.normed_embedding,
+                'ear': eye_aspect_ratio(landmarks) if landmarks is not None else None,
+                'pose': pose,
+            })
+
+        if len(observations) < 2:
+            return jsonify({"score": 0.0, "completed": [], "reason": "no face in enough frames"}), 200
+
+        baseline = observations[0]
+        for observation in observations[1:]:
+            if cosine_similarity(baseline['embedding'], observation['embedding']) < LIVENESS_SAME_FACE_SIMILARITY:
+                return jsonify({"score": 0.0, "completed": [], "reason": "frames show different faces"}), 200
+
+        # Each action must appear after the previous one
+        completed = []
+        index = 1
+        for action in actions:
+            found = False
+            while index < len(observations):
+                observation = observations[index]
+                index += 1
+                if action_performed(action, baseline, observation):
+                    found = True
+                    break
+            if not found:
+                break
+            completed.append(action)
+
+        score = len(completed) / len(actions)
+        print(f"[LIVENESS] Actions {actions}, completed {completed}, score {score:.2f}")
+        return jsonify({"score": score, "completed": completed}), 200
+
+    except Exception as e:
+        print(f"[LIVENESS] Exception occurred: {str(e)}")
+        return jsonify({"error": str(e)}), 500
+
 @app.route('/verify', methods=['POST'])
 def verify():
     """Verify face against stored profile"""
@@ -355,18 +558,13 @@ def verify():
         stored_embedding_data = None
         
         if STORAGE_MODE == 'database':
-            # Try cache first
-            if user_id in embeddings_cache:
-                stored_embedding_data = embeddings_cache[user_id]
-                print(f"[VERIFY] ✅ Found user {user_id} in cache")
-            else:
-                # Load from database
-                print(f"[VERIFY] Loading embedding from database for user: {user_id}")
-                stored_embedding_data = load_embedding_from_database(user_id)
-                if stored_embedding_data:
-                    # Cache it for next time
-                    embeddings_cache[user_id] = stored_embedding_data
-                    print(f"[VERIFY] ✅ Loaded and cached embedding from database")
+            # Loaded on every verification, not cached: embeddings are added,
+            # deleted and approved in the backend, and a stale copy would keep
+            # matching a deleted face
+            print(f"[VERIFY] Loading embedding from database for user: {user_id}")
+            stored_embedding_data = load_embedding_from_database(user_id)
+            if stored_embedding_data:
+                print(f"[VERIFY] ✅ Loaded embedding from database")
         else:
             # Load from file (legacy mode)
             load_embeddings_file()
@@ -383,16 +581,19 @@ def verify():
         
         print(f"[VERIFY] ✅ User {user_id} profile found")
         
-        # Get stored embedding
-        stored_embedding = np.array(stored_embedding_data['embedding'])
-        print(f"[VERIFY] Stored embedding shape: {stored_embedding.shape}, New embedding shape: {new_embedding.shape}")
-        
-        # Ensure embeddings are normalized
+        # Get stored embeddings, matching against the closest reference face
+        stored_embeddings = stored_embedding_data.get('embeddings') or [stored_embedding_data['embedding']]
         new_embedding = new_embedding / np.linalg.norm(new_embedding)
-        stored_embedding = stored_embedding / np.linalg.norm(stored_embedding)
-        
-        # Calculate cosine similarity (dot product of normalized vectors)
-        similarity = cosine_similarity(new_embedding, stored_embedding)
+        similarity = -1.0
+        for stored in stored_embeddings:
+            stored_embedding = np.array(stored)
+            print(f"[VERIFY] Stored embedding shape: {stored_embedding.shape}, New embedding shape: {new_embedding.shape}")
+
+            # Ensure embeddings are normalized
+            stored_embedding = stored_embedding / np.linalg.norm(stored_embedding)
+
+            # Calculate cosine similarity (dot product of normalized vectors)
+            similarity = max(similarity, cosine_similarity(new_embedding, stored_embedding))
         
         # Improved threshold for better accuracy and easier detection
         # Cosine similarity range: 1.0 = identical, 0.0 = completely different
@@ -406,8 +607,17 @@ def verify():
         # Adaptive threshold: Lower for easier detection, but still secure
         base_threshold = 0.62  # Lowered from 0.70 for better usability
         
-        # Use the threshold
+        # The backend sends the threshold for this user and site; fall back to
+        # the base threshold when it does not. Other callers cannot lower it.
         threshold = base_threshold
+        requested_threshold = request.form.get('threshold')
+        if requested_threshold and not is_backend_request():
+            print(f"[VERIFY] ⚠️  Ignoring threshold from an unauthenticated caller")
+        elif requested_threshold:
+            try:
+                threshold = float(requested_threshold)
+            except ValueError:
+                print(f"[VERIFY] ⚠️  Ignoring invalid threshold: {requested_threshold}")
         verified = bool(similarity >= threshold)
         
         # Calculate confidence percentage
@@ -6,6 +6,7 @@ class AppConstants {
   
   // Storage Keys
   static const String tokenKey = 'auth_token';
+  static const String refreshTokenKey = 'refresh_token';
   static const String userKey = 'user_data';
   
   // Face Recognition
@@ -1,4 +1,3 @@
-import 'package:dio/dio.dart';
 import 'package:get_it/get_it.dart';
 import 'package:shared_preferences/shared_preferences.dart';
 
@@ -54,17 +53,6 @@ Future<void> initializeDependencies() async {
   // Dio Client (Singleton for main API)
   sl.registerLazySingleton<DioClient>(() => DioClient());
   
-  // Separate Dio for Face Recognition Service
-  sl.registerLazySingleton<Dio>(
-    () => Dio(BaseOptions(
-      baseUrl: 'http://192.168.1.142:5001',
-      connectTimeout: const Duration(seconds: 60),
-      receiveTimeout: const Duration(seconds: 120), // Longer for face recognition processing
-      sendTimeout: const Duration(seconds: 90), // For sending large files
-    )),
-    instanceName: 'faceRecognitionDio',
-  );
-  
   // Local Storage
   sl.registerLazySingleton<LocalStorage>(
     () => LocalStorageImpl(sharedPreferences: sl()),
@@ -83,9 +71,7 @@ Future<void> initializeDependencies() async {
   );
   
   sl.registerLazySingleton<FaceRecognitionRemoteDataSource>(
-    () => FaceRecognitionRemoteDataSourceImpl(
-      dio: sl(instanceName: 'faceRecognitionDio'),
-    ),
+    () => FaceRecognitionRemoteDataSourceImpl(dioClient: sl()),
   );
   
   sl.registerLazySingleton<TaskRemoteDataSource>(
@@ -143,11 +129,9 @@ Future<void> initializeDependencies() async {
   // Attendance Use Cases
   sl.registerLazySingleton(() => ClockInUseCase(
         attendanceRepository: sl(),
-        faceRecognitionRepository: sl(),
       ));
   sl.registerLazySingleton(() => ClockOutUseCase(
         attendanceRepository: sl(),
-        faceRecognitionRepository: sl(),
       ));
   sl.registerLazySingleton(() => GetTodayAttendanceUseCase(repository: sl()));
   sl.registerLazySingleton(() => GetAttendanceHistoryUseCase(repository: sl()));
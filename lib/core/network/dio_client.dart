@@ -5,6 +5,9 @@ import '../constants/app_constants.dart';
 class DioClient {
   late Dio _dio;
   
+  // A refresh token works once, so requests failing together share one refresh
+  static Future<String?>? _pendingRefresh;
+  
   DioClient() {
     _dio = Dio(
       BaseOptions(
@@ -29,7 +32,25 @@ class DioClient {
           }
           return handler.next(options);
         },
-        onError: (error, handler) {
+        onError: (error, handler) async {
+          // Access tokens are short-lived: renew with the refresh token and
+          // retry the request once
+          final request = error.requestOptions;
+          if (error.response?.statusCode == 401 &&
+              request.extra['retried'] != true &&
+              !request.path.startsWith('/auth/')) {
+            _pendingRefresh ??= _refreshToken().whenComplete(() => _pendingRefresh = null);
+            final token = await _pendingRefresh;
+            if (token != null) {
+              request.headers['Authorization'] = 'Bearer $token';
+              request.extra['retried'] = true;
+              try {
+                return handler.resolve(await _dio.fetch(request));
+              } on DioException catch (e) {
+                return handler.next(e);
+              }
+            }
+          }
           return handler.next(error);
         },
       ),
@@ -37,5 +58,37 @@ class DioClient {
   }
   
   Dio get dio => _dio;
+  
+  /// Exchanges the stored refresh token for new tokens. Returns the new
+  /// access token, or null when the session has ended and the user has to
+  /// log in again.
+  Future<String?> _refreshToken() async {
+    final prefs = await SharedPreferences.getInstance();
+    final refreshToken = prefs.getString(AppConstants.refreshTokenKey);
+    if (refreshToken == null || refreshToken.isEmpty) {
+      return null;
+    }
+    
+    try {
+      final response = await Dio(BaseOptions(baseUrl: AppConstants.baseUrl)).post(
+        '/auth/refresh',
+        data: {'refresh_token': refreshToken},
+      );
+      final token = response.data['token']?.toString();
+      final nextRefreshToken = response.data['refresh_token']?.toString();
+      if (token == null || nextRefreshToken == null) {
+        return null;
+      }
+      await prefs.setString(AppConstants.tokenKey, token);
+      await prefs.setString(AppConstants.refreshTokenKey, nextRefreshToken);
+      return token;
+    } on DioException catch (e) {
+      if (e.response?.statusCode == 401) {
+        await prefs.remove(AppConstants.tokenKey);
+        await prefs.remove(AppConstants.refreshTokenKey);
+      }
+      return null;
+    }
+  }
 }
 
@@ -8,6 +8,7 @@ abstract class AuthRemoteDataSource {
   Future<void> register(String name, String email, String password, String employeeId);
   Future<void> forgotPassword(String email);
   Future<UserModel> getCurrentUser();
+  Future<void> logout();
 }
 
 class AuthRemoteDataSourceImpl implements AuthRemoteDataSource {
@@ -60,6 +61,7 @@ class AuthRemoteDataSourceImpl implements AuthRemoteDataSource {
       return {
         'user': userModel,
         'token': tokenString,
+        'refresh_token': responseData['refresh_token']?.toString(),
       };
     } on DioException catch (e) {
       print('DEBUG: AuthRemoteDataSource.login - DioException: ${e.response?.statusCode} - ${e.message}');
@@ -113,10 +115,21 @@ class AuthRemoteDataSourceImpl implements AuthRemoteDataSource {
         // Clear invalid token
         final prefs = await SharedPreferences.getInstance();
         await prefs.remove('auth_token');
+        await prefs.remove('refresh_token');
         await prefs.remove('user_data');
       }
       throw Exception(e.response?.data['message'] ?? 'Failed to get user');
     }
   }
+  
+  @override
+  Future<void> logout() async {
+    try {
+      // Revokes the session on the server so the tokens stop working
+      await dioClient.dio.post('/auth/logout');
+    } on DioException catch (e) {
+      throw Exception(e.response?.data['message'] ?? 'Logout failed');
+    }
+  }
 }
 
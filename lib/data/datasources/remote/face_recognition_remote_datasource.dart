@@ -1,77 +1,59 @@
 import 'package:dio/dio.dart';
-import '../../../core/constants/app_constants.dart';
+import '../../../core/network/dio_client.dart';
 
 abstract class FaceRecognitionRemoteDataSource {
-  Future<String> uploadProfilePhoto(String photoPath, String userId);
-  Future<bool> verifyFace(String photoPath, String userId);
+  /// Adds the photo as a capture of the user's face enrollment and submits it
+  /// for HR approval once enough captures passed. Returns the enrollment
+  /// status: 'open' while more captures are needed, 'pending' once submitted.
+  Future<String> addEnrollmentCapture(String photoPath);
 }
 
 class FaceRecognitionRemoteDataSourceImpl implements FaceRecognitionRemoteDataSource {
-  final Dio dio;
+  final DioClient dioClient;
   
-  FaceRecognitionRemoteDataSourceImpl({required this.dio});
+  FaceRecognitionRemoteDataSourceImpl({required this.dioClient});
   
   @override
-  Future<String> uploadProfilePhoto(String photoPath, String userId) async {
+  Future<String> addEnrollmentCapture(String photoPath) async {
     try {
-      final formData = FormData.fromMap({
-        'photo': await MultipartFile.fromFile(photoPath),
-        'user_id': userId, // Include user_id for face recognition service
-      });
-      
-      print('DEBUG: Uploading profile photo to face recognition service for user: $userId');
-      final response = await dio.post(
-        '${AppConstants.faceRecognitionUrl}/upload-profile',
-        data: formData,
-      );
-      print('DEBUG: Face recognition upload response: ${response.data}');
-      
-      return response.data['embedding_id'] as String;
-    } on DioException catch (e) {
-      print('DEBUG: Face recognition upload failed: ${e.response?.data}');
-      throw Exception(e.response?.data['error'] ?? e.response?.data['message'] ?? 'Upload failed');
-    }
-  }
-  
-  @override
-  Future<bool> verifyFace(String photoPath, String userId) async {
-    try {
-      print('DEBUG: VerifyFace - Starting verification for user: $userId');
-      print('DEBUG: VerifyFace - Photo path: $photoPath');
+      // Add to the open enrollment, or start one
+      final enrollments = await dioClient.dio.get('/user/face-enrollments');
+      final open = (enrollments.data['data'] as List? ?? [])
+          .cast<Map<String, dynamic>>()
+          .where((e) => e['status'] == 'open');
+      final String enrollmentId;
+      if (open.isNotEmpty) {
+        enrollmentId = open.first['id'] as String;
+      } else {
+        final started = await dioClient.dio.post('/user/face-enrollments');
+        enrollmentId = started.data['data']['id'] as String;
+      }
       
       final formData = FormData.fromMap({
         'photo': await MultipartFile.fromFile(photoPath),
-        'user_id': userId,
       });
-      
-      print('DEBUG: VerifyFace - Making POST request to /verify');
-      final response = await dio.post(
-        '${AppConstants.faceRecognitionUrl}/verify',
+      final response = await dioClient.dio.post(
+        '/user/face-enrollments/$enrollmentId/captures',
         data: formData,
       );
-      
-      print('DEBUG: VerifyFace - Response status: ${response.statusCode}');
-      print('DEBUG: VerifyFace - Response data: ${response.data}');
-      
-      final verified = response.data['verified'] as bool? ?? false;
-      final similarity = response.data['similarity'] as double?;
-      
-      print('DEBUG: VerifyFace - Verified: $verified, Similarity: $similarity');
-      
-      return verified;
+      final capture = response.data['data'];
+      if (capture['passed'] != true) {
+        throw Exception('Photo failed the quality checks: ${capture['rejections']}');
+      }
+      
+      // Not enough captures yet (422) keeps the enrollment open
+      try {
+        await dioClient.dio.post('/user/face-enrollments/$enrollmentId/submit');
+        return 'pending';
+      } on DioException catch (e) {
+        if (e.response?.statusCode == 422) {
+          return 'open';
+        }
+        rethrow;
+      }
     } on DioException catch (e) {
-      print('DEBUG: VerifyFace - DioException: ${e.response?.statusCode}');
-      print('DEBUG: VerifyFace - Error data: ${e.response?.data}');
-      
-      final errorMessage = e.response?.data is Map 
-          ? (e.response?.data['error'] ?? e.response?.data['message'] ?? 'Verification failed')
-          : 'Verification failed: ${e.message}';
-      
-      throw Exception(errorMessage);
-    } catch (e) {
-      print('DEBUG: VerifyFace - Exception: $e');
-      throw Exception('Verification failed: $e');
+      print('DEBUG: Face enrollment failed: ${e.response?.data}');
+      throw Exception(e.response?.data['error'] ?? e.response?.data['message'] ?? 'Upload failed');
     }
   }
 }
-
@@ -1,3 +1,4 @@
+import '../../core/constants/app_constants.dart';
 import '../../domain/entities/user.dart';
 import '../../domain/repositories/auth_repository.dart';
 import '../datasources/local/local_storage.dart';
@@ -22,6 +23,7 @@ class AuthRepositoryImpl implements AuthRepository {
     
     final userModel = result['user'] as UserModel?;
     final token = result['token'] as String?;
+    final refreshToken = result['refresh_token'] as String?;
     
     if (userModel == null) {
       throw Exception('UserModel tidak ditemukan di response');
@@ -33,6 +35,9 @@ class AuthRepositoryImpl implements AuthRepository {
     
     // Save to local storage
     await localStorage.saveToken(token);
+    if (refreshToken != null && refreshToken.isNotEmpty) {
+      await localStorage.saveString(AppConstants.refreshTokenKey, refreshToken);
+    }
     await localStorage.saveUser(userModel);
     
     return {
@@ -58,7 +63,14 @@ class AuthRepositoryImpl implements AuthRepository {
   
   @override
   Future<void> logout() async {
+    try {
+      await remoteDataSource.logout();
+    } catch (e) {
+      // Signing out locally still works when the server cannot be reached
+      print('Error logging out on server: $e');
+    }
     await localStorage.deleteToken();
+    await localStorage.remove(AppConstants.refreshTokenKey);
     await localStorage.deleteUser();
   }
   
@@ -7,13 +7,7 @@ class FaceRecognitionRepositoryImpl implements FaceRecognitionRepository {
   FaceRecognitionRepositoryImpl({required this.remoteDataSource});
   
   @override
-  Future<String> uploadProfilePhoto(String photoPath, String userId) {
-    return remoteDataSource.uploadProfilePhoto(photoPath, userId);
-  }
-  
-  @override
-  Future<bool> verifyFace(String photoPath, String userId) {
-    return remoteDataSource.verifyFace(photoPath, userId);
+  Future<String> addEnrollmentCapture(String photoPath) {
+    return remoteDataSource.addEnrollmentCapture(photoPath);
   }
 }
-
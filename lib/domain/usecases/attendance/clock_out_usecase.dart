@@ -1,5 +1,4 @@
 import '../../repositories/attendance_repository.dart';
-import '../../repositories/face_recognition_repository.dart';
 import '../usecase.dart';
 
 /// Parameters for clock out use case
@@ -16,17 +15,14 @@ class ClockOutParams {
 /// Use case for clocking out
 /// 
 /// Handles:
-/// - Face verification
 /// - Location validation
 /// - Clock out time recording
 /// - Error handling for verification failures
 class ClockOutUseCase implements UseCase<void, ClockOutParams> {
   final AttendanceRepository attendanceRepository;
-  final FaceRecognitionRepository faceRecognitionRepository;
 
   const ClockOutUseCase({
     required this.attendanceRepository,
-    required this.faceRecognitionRepository,
   });
 
   @override
@@ -41,23 +37,7 @@ class ClockOutUseCase implements UseCase<void, ClockOutParams> {
       throw Exception('Lokasi tidak tersedia');
     }
 
-    // Verify face first
-    try {
-      final isVerified = await faceRecognitionRepository.verifyFace(
-        params.photoPath,
-        '', // userId - will be retrieved from token by repository
-      );
-
-      if (!isVerified) {
-        throw Exception(
-          'Verifikasi wajah gagal. Pastikan wajah Anda terlihat jelas.',
-        );
-      }
-    } catch (e) {
-      throw Exception('Gagal memverifikasi wajah: ${e.toString()}');
-    }
-
-    // If verified, proceed with clock out
+    // The backend verifies the face against the enrolled profile on clock out
     try {
       await attendanceRepository.clockOut(
         params.photoPath,
@@ -60,9 +60,10 @@ class UserBloc extends Bloc<UserEvent, UserState> {
       final userId = currentState.user.id;
       print('DEBUG: UserBloc - User ID: $userId');
       
-      // STEP 1: Upload to face recognition service FIRST (extract embedding)
+      // STEP 1: Add the photo to the face enrollment FIRST (quality checked,
+      // used for verification once HR approves it)
       final faceRecognitionRepo = sl<FaceRecognitionRepository>();
-      await faceRecognitionRepo.uploadProfilePhoto(event.photoPath, userId);
+      final enrollmentStatus = await faceRecognitionRepo.addEnrollmentCapture(event.photoPath);
       
       // STEP 2: Upload to backend (for display)
       final photoUrl = await userRepository.uploadProfilePhoto(event.photoPath);
@@ -73,10 +74,14 @@ class UserBloc extends Bloc<UserEvent, UserState> {
       final updatedUser = currentState.user.copyWith(profilePhotoUrl: photoUrl);
       authBloc.add(UpdateUserEvent(user: updatedUser));
       
-      emit(UserSuccess(message: 'Foto profil berhasil diubah'));
+      emit(UserSuccess(
+        message: enrollmentStatus == 'pending'
+            ? 'Foto profil berhasil diubah, wajah menunggu persetujuan HR'
+            : 'Foto profil berhasil diubah, ambil foto wajah lagi untuk melengkapi pendaftaran wajah',
+      ));
     } catch (e) {
       final errorMsg = e.toString();
-      if (errorMsg.contains('No face detected') || errorMsg.contains('face embedding')) {
+      if (errorMsg.contains('No face detected') || errorMsg.contains('face embedding') || errorMsg.contains('no_face')) {
         emit(UserError(message: 'Tidak ada wajah terdeteksi di foto. Pastikan wajah Anda terlihat jelas.'));
       } else {
         emit(UserError(message: 'Gagal mengupload foto: $errorMsg'));
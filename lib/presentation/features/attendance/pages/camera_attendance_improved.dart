@@ -4,17 +4,13 @@ import 'package:geolocator/geolocator.dart';
 import 'package:geocoding/geocoding.dart';
 import 'package:permission_handler/permission_handler.dart';
 import 'package:go_router/go_router.dart';
-import 'package:flutter_bloc/flutter_bloc.dart';
 
-import '../../../../core/di/injection_container.dart';
 import '../../../../core/routes/app_routes.dart';
 import '../../../../core/utils/logger.dart';
 import '../../../../core/widgets/loading_overlay.dart';
-import '../../../../domain/repositories/face_recognition_repository.dart';
 import '../../../bloc/attendance/attendance_bloc.dart';
-import '../../../bloc/auth/auth_bloc.dart';
 
-/// Improved Camera Attendance Page with face verification BEFORE confirmation
+/// Improved Camera Attendance Page, the backend verifies the face on submit
 class CameraAttendanceImproved extends StatefulWidget {
   final String userId;
   final bool isClockIn;
@@ -108,8 +104,8 @@ class _CameraAttendanceImprovedState extends State<CameraAttendanceImproved> {
     super.dispose();
   }
 
-  /// NEW IMPROVED FLOW: Verify face IMMEDIATELY after capture
-  Future<void> _captureAndVerifyPhoto() async {
+  /// Capture the photo and location, then go to the confirmation page
+  Future<void> _capturePhoto() async {
     if (_controller == null || !_controller!.value.isInitialized) return;
     
     setState(() => _isCapturing = true);
@@ -149,60 +145,8 @@ class _CameraAttendanceImprovedState extends State<CameraAttendanceImproved> {
       
       if (!mounted) return;
       
-      // 3. SHOW LOADING - VERIFYING FACE
-      _showVerificationDialog(context);
-      
-      // 4. VERIFY FACE - REAL IMPLEMENTATION
-      try {
-        AppLogger.info('Starting face verification...');
-        
-        // Get AuthBloc to get user info for verification
-        final authState = context.read<AuthBloc>().state;
-        if (authState is! AuthAuthenticated) {
-          throw Exception('User not authenticated');
-        }
-        
-        // Call REAL face verification API
-        final faceRecognitionRepo = sl<FaceRecognitionRepository>();
-        final isVerified = await faceRecognitionRepo.verifyFace(
-          photo.path,
-          authState.user.id,
-        );
-        
-        AppLogger.info('Face verification result: $isVerified');
-        
-        if (!mounted) return;
-        
-        // Close loading dialog
-        Navigator.of(context, rootNavigator: true).pop();
-        
-        if (isVerified) {
-          // 5a. VERIFICATION SUCCESS → Navigate to confirmation
-          AppLogger.info('Face verification SUCCESS');
-          _navigateToConfirmation(photo.path, location, verified: true);
-        } else {
-          // 5b. VERIFICATION FAILED → Show error
-          AppLogger.error('Face verification FAILED');
-          _showVerificationFailedDialog(context);
-        }
-        
-      } catch (e) {
-        if (!mounted) return;
-        
-        // Close loading dialog
-        Navigator.of(context, rootNavigator: true).pop();
-        
-        // Check if error is about profile not found
-        final errorMessage = e.toString().toLowerCase();
-        if (errorMessage.contains('profile not found') || 
-            errorMessage.contains('user profile not found') ||
-            errorMessage.contains('404')) {
-          _showProfileNotFoundDialog(context);
-        } else {
-          // Show generic error
-        _showVerificationErrorDialog(context, e.toString());
-        }
-      }
+      // 3. CONFIRM - the backend verifies the face when the punch is submitted
+      _navigateToConfirmation(photo.path, location);
       
     } catch (e) {
       if (mounted) {
@@ -217,149 +161,7 @@ class _CameraAttendanceImprovedState extends State<CameraAttendanceImproved> {
     }
   }
 
-  void _showVerificationDialog(BuildContext context) {
-    showDialog(
-      context: context,
-      barrierDismissible: false,
-      builder: (context) => PopScope(
-        canPop: false,
-        child: Dialog(
-          child: Padding(
-            padding: const EdgeInsets.all(24),
-            child: Column(
-              mainAxisSize: MainAxisSize.min,
-              children: [
-                const CircularProgressIndicator(),
-                const SizedBox(height: 16),
-                const Text(
-                  'Memverifikasi Wajah...',
-                  style: TextStyle(
-                    fontSize: 16,
-                    fontWeight: FontWeight.w600,
-                  ),
-                ),
-                const SizedBox(height: 8),
-                Text(
-                  'Mohon tunggu sebentar',
-                  style: TextStyle(fontSize: 14, color: Colors.grey[600]),
-                ),
-              ],
-            ),
-          ),
-        ),
-      ),
-    );
-  }
-
-  void _showVerificationFailedDialog(BuildContext context) {
-    showDialog(
-      context: context,
-      builder: (dialogContext) => AlertDialog(
-        icon: const Icon(
-          Icons.error_outline,
-          color: Colors.red,
-          size: 64,
-        ),
-        title: const Text(
-          'Wajah Tidak Cocok',
-          textAlign: TextAlign.center,
-        ),
-        content: const Text(
-          'Wajah yang terdeteksi tidak cocok dengan foto profil Anda. Pastikan:\n\n• Pencahayaan cukup\n• Wajah menghadap kamera\n• Tidak ada yang menutupi wajah',
-          textAlign: TextAlign.center,
-        ),
-        actions: [
-          TextButton(
-            onPressed: () {
-              Navigator.pop(dialogContext);
-              setState(() => _isCapturing = false);
-            },
-            child: const Text('Coba Lagi'),
-          ),
-          TextButton(
-            onPressed: () {
-              Navigator.pop(dialogContext);
-              if (Navigator.of(context).canPop()) {
-                Navigator.of(context).pop();
-              } else {
-                context.go(AppRoutes.home);
-              }
-            },
-            child: const Text('Batal'),
-          ),
-        ],
-      ),
-    );
-  }
-
-  void _showProfileNotFoundDialog(BuildContext context) {
-    showDialog(
-      context: context,
-      builder: (dialogContext) => AlertDialog(
-        icon: const Icon(
-          Icons.account_circle_outlined,
-          color: Colors.orange,
-          size: 64,
-        ),
-        title: const Text('Profil Belum Lengkap'),
-        content: const Text(
-          'Anda belum mengupload foto profil untuk verifikasi wajah.\n\n'
-          'Silakan upload foto profil Anda terlebih dahulu di menu Profil → Edit Profil → Tap icon kamera.',
-          textAlign: TextAlign.center,
-        ),
-        actions: [
-          TextButton(
-            onPressed: () {
-              Navigator.pop(dialogContext);
-              // Navigate back to home
-              if (Navigator.of(context).canPop()) {
-                Navigator.of(context).pop();
-              } else {
-                context.go(AppRoutes.home);
-              }
-            },
-            child: const Text('Ke Profil'),
-          ),
-          TextButton(
-            onPressed: () {
-              Navigator.pop(dialogContext);
-              setState(() => _isCapturing = false);
-            },
-            child: const Text('Tutup'),
-          ),
-        ],
-      ),
-    );
-  }
-
-  void _showVerificationErrorDialog(BuildContext context, String error) {
-    showDialog(
-      context: context,
-      builder: (dialogContext) => AlertDialog(
-        icon: const Icon(
-          Icons.warning_amber_rounded,
-          color: Colors.orange,
-          size: 64,
-        ),
-        title: const Text('Verifikasi Gagal'),
-        content: Text(
-          'Terjadi kesalahan saat verifikasi wajah:\n\n$error',
-          textAlign: TextAlign.center,
-        ),
-        actions: [
-          TextButton(
-            onPressed: () {
-              Navigator.pop(dialogContext);
-              setState(() => _isCapturing = false);
-            },
-            child: const Text('Coba Lagi'),
-          ),
-        ],
-      ),
-    );
-  }
-
-  void _navigateToConfirmation(String photoPath, String location, {required bool verified}) {
+  void _navigateToConfirmation(String photoPath, String location) {
     context.navigateToAttendanceConfirmation(
       photoPath: photoPath,
       location: location,
@@ -546,7 +348,7 @@ class _CameraAttendanceImprovedState extends State<CameraAttendanceImproved> {
           right: 0,
           child: Center(
             child: GestureDetector(
-              onTap: _isCapturing ? null : _captureAndVerifyPhoto,
+              onTap: _isCapturing ? null : _capturePhoto,
               child: Container(
                 width: 70,
                 height: 70,